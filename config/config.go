@@ -1,7 +1,10 @@
 package config
 
 import (
+	"log"
 	"os"
+	"strconv"
+	"strings"
 )
 
 // Config ilovaning konfiguratsiya sozlamalarini saqlaydi
@@ -15,6 +18,23 @@ type Config struct {
 	DatabaseUser     string
 	DatabasePassword string
 	DatabaseName     string
+
+	// Ishga tushishda superadmin rolini oladigan Telegram ID'lar (vergul bilan ajratilgan)
+	SuperAdminIDs []int64
+
+	// Narx qoidalari: zalda xizmat haqi (subtotal foizida). Yetkazib berish narxi hududlardan olinadi
+	ServiceChargePercent float64
+
+	// Xodimlar chati (guruh yoki shaxsiy): bot bekor qilingan buyurtmalar va stoldan chaqiruvlar haqida shu yerga xabar yuboradi. 0 - yuborilmaydi
+	StaffChatID int64
+	// Mijoz o'zi bekor qila oladigan buyurtma holatlari (vergul bilan ajratilgan kodlar)
+	CustomerCancellableStatuses []string
+
+	// Necha oydan eski yakunlangan/o'chirilgan buyurtmalar arxivga ko'chiriladi (0 - arxivlash o'chirilgan)
+	OrderArchiveMonths int
+
+	// Mini App havolasi (masalan, https://t.me/amur_bot/menu): stol QR kodlari shu havolaga ?startapp=<token> qo'shib yaratiladi
+	MiniAppURL string
 }
 
 // LoadConfig environment variable'lardan konfiguratsiyani yuklaydi
@@ -29,6 +49,17 @@ func LoadConfig() *Config {
 		DatabaseUser:     getEnv("DB_USER", "postgres"),
 		DatabasePassword: getEnv("DB_PASSWORD", "samandar"),
 		DatabaseName:     getEnv("DB_NAME", "amur_db"),
+
+		SuperAdminIDs: getEnvInt64List("SUPERADMIN_IDS"),
+
+		ServiceChargePercent: getEnvFloat("SERVICE_CHARGE_PERCENT", 0),
+
+		StaffChatID:                 getEnvInt64("STAFF_CHAT_ID", 0),
+		CustomerCancellableStatuses: getEnvList("CUSTOMER_CANCELLABLE_STATUSES", []string{"accepted"}),
+
+		OrderArchiveMonths: int(getEnvInt64("ORDER_ARCHIVE_MONTHS", 12)),
+
+		MiniAppURL: getEnv("MINI_APP_URL", ""),
 	}
 }
 
@@ -39,3 +70,64 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvFloat son qiymatli environment variable'ni oladi; bo'lmasa yoki noto'g'ri bo'lsa default value qaytariladi
+func getEnvFloat(key string, defaultValue float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || value < 0 {
+		log.Printf("%s noto'g'ri qiymat (%q), default ishlatiladi: %v", key, raw, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvInt64 butun son qiymatli environment variable'ni oladi; bo'lmasa yoki noto'g'ri bo'lsa default value qaytariladi
+func getEnvInt64(key string, defaultValue int64) int64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil {
+		log.Printf("%s noto'g'ri qiymat (%q), default ishlatiladi: %d", key, raw, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvList vergul bilan ajratilgan qiymatlar ro'yxatini o'qiydi; o'zgaruvchi bo'lmasa default value qaytariladi
+func getEnvList(key string, defaultValue []string) []string {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue
+	}
+	var values []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// getEnvInt64List vergul bilan ajratilgan raqamlar ro'yxatini o'qiydi, noto'g'ri qiymatlar o'tkazib yuboriladi
+func getEnvInt64List(key string) []int64 {
+	var values []int64
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			log.Printf("%s ichidagi noto'g'ri qiymat o'tkazib yuborildi: %q", key, part)
+			continue
+		}
+		values = append(values, value)
+	}
+	return values
+}
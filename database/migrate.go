@@ -0,0 +1,237 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey pg_advisory_lock uchun kalit: bir vaqtda faqat bitta instansiya migratsiya qiladi
+const migrationLockKey int64 = 7243190501
+
+// migrationFileRe fayl nomini ajratadi: 0001_init.up.sql -> (0001, init, up)
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration bitta versiyalangan sxema o'zgarishi
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus migratsiya va uning qo'llanilgan vaqti (qo'llanilmagan bo'lsa nil)
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// loadMigrations binarga joylangan SQL fayllarni o'qiydi va versiya bo'yicha tartiblaydi
+func loadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("migratsiya fayllarini o'qishda xatolik: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migratsiya fayli nomi noto'g'ri: %s", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		body, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("migratsiya faylini o'qishda xatolik (%s): %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("%d-versiyada ikki xil nomli migratsiya: %s va %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("%04d_%s migratsiyasida up yoki down fayli yo'q", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// withMigrationLock alohida ulanishda advisory lock oladi va fn ni shu ulanish bilan bajaradi.
+// Advisory lock sessiyaga bog'liq, shuning uchun barcha migratsiyalar bitta ulanishda bajariladi.
+func (d *Database) withMigrationLock(fn func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migratsiya uchun ulanish olishda xatolik: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("migratsiya lockini olishda xatolik: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			log.Printf("Migratsiya lockini bo'shatishda xatolik: %v", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`); err != nil {
+		return fmt.Errorf("schema_migrations jadvalini yaratishda xatolik: %w", err)
+	}
+
+	return fn(ctx, conn)
+}
+
+// appliedVersions qo'llanilgan migratsiyalar va ularning vaqtini qaytaradi
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("qo'llanilgan migratsiyalarni olishda xatolik: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// runMigration bitta migratsiya SQL'ini va schema_migrations yozuvini bitta tranzaksiyada bajaradi
+func runMigration(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// MigrateUp barcha qo'llanilmagan migratsiyalarni tartib bilan bajaradi va qo'llanilganlar sonini qaytaradi
+func (d *Database) MigrateUp() (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = d.withMigrationLock(func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if err := runMigration(ctx, conn, m.Up,
+				`INSERT INTO schema_migrations(version, name) VALUES ($1, $2)`, m.Version, m.Name); err != nil {
+				log.Printf("%04d_%s migratsiyasini bajarishda xatolik: %v", m.Version, m.Name, err)
+				return fmt.Errorf("%04d_%s migratsiyasi bajarilmadi: %w", m.Version, m.Name, err)
+			}
+			log.Printf("✅ Migratsiya qo'llanildi: %04d_%s", m.Version, m.Name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// MigrateDown oxirgi qo'llanilgan steps ta migratsiyani teskari tartibda bekor qiladi
+func (d *Database) MigrateDown(steps int) (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = d.withMigrationLock(func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if err := runMigration(ctx, conn, m.Down,
+				`DELETE FROM schema_migrations WHERE version = $1`, m.Version); err != nil {
+				log.Printf("%04d_%s migratsiyasini bekor qilishda xatolik: %v", m.Version, m.Name, err)
+				return fmt.Errorf("%04d_%s migratsiyasi bekor qilinmadi: %w", m.Version, m.Name, err)
+			}
+			log.Printf("↩️ Migratsiya bekor qilindi: %04d_%s", m.Version, m.Name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// MigrationStatus har bir migratsiya holatini (qo'llanilgan yoki kutilmoqda) qaytaradi
+func (d *Database) MigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	err = d.withMigrationLock(func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			status := MigrationStatus{Version: m.Version, Name: m.Name}
+			if appliedAt, ok := applied[m.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS basket_orders;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS tables;
+DROP TABLE IF EXISTS foods;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,85 @@
+-- Asosiy jadvallar: users, foods, tables, orders, order_items, basket_orders.
+-- IF NOT EXISTS ishlatilgan, chunki migratsiyalar tizimidan oldin yaratilgan bazalarda jadvallar allaqachon bor.
+
+CREATE TABLE IF NOT EXISTS users (
+	userid SERIAL PRIMARY KEY,
+	telegram_id BIGINT UNIQUE NOT NULL,
+	first_name TEXT NOT NULL DEFAULT 'N/A',
+	username TEXT NOT NULL DEFAULT 'N/A',
+	language_code TEXT NOT NULL DEFAULT 'uz',
+	phone TEXT,
+	password_hash TEXT,
+	role TEXT NOT NULL DEFAULT 'customer',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS foods (
+	food_id SERIAL PRIMARY KEY,
+	food_name TEXT NOT NULL,
+	food_category TEXT NOT NULL,
+	food_price DECIMAL(10,2) NOT NULL,
+	food_image TEXT DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- orders dan oldin yaratiladi (foreign key uchun)
+CREATE TABLE IF NOT EXISTS tables (
+	table_id SERIAL PRIMARY KEY,
+	table_name TEXT UNIQUE NOT NULL,
+	qr_code_token TEXT UNIQUE NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+	order_id SERIAL PRIMARY KEY,
+	telegram_id BIGINT NOT NULL,
+	order_status TEXT NOT NULL DEFAULT 'pending',
+	total_price DECIMAL(10,2) NOT NULL DEFAULT 0.0,
+	order_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	delivery_type TEXT NOT NULL,
+	delivery_latitude DECIMAL(10,8),
+	delivery_longitude DECIMAL(11,8),
+	table_id INTEGER,
+	comment TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (telegram_id) REFERENCES users(telegram_id) ON DELETE CASCADE,
+	FOREIGN KEY (table_id) REFERENCES tables(table_id) ON DELETE SET NULL
+);
+
+-- Eski bazalarda orders jadvali bu ustunlarsiz yaratilgan bo'lishi mumkin
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS order_status TEXT NOT NULL DEFAULT 'pending';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS order_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_type TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_latitude DECIMAL(10,8);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_longitude DECIMAL(11,8);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS table_id INTEGER;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS comment TEXT;
+
+CREATE TABLE IF NOT EXISTS order_items (
+	order_item_id SERIAL PRIMARY KEY,
+	order_id INTEGER NOT NULL,
+	food_id INTEGER NOT NULL,
+	quantity INTEGER NOT NULL,
+	item_price DECIMAL(10,2) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE,
+	FOREIGN KEY (food_id) REFERENCES foods(food_id) ON DELETE CASCADE
+);
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS item_price DECIMAL(10,2) NOT NULL DEFAULT 0.0;
+
+CREATE TABLE IF NOT EXISTS basket_orders (
+	basket_order_id SERIAL PRIMARY KEY,
+	telegram_id BIGINT NOT NULL,
+	food_id INTEGER NOT NULL,
+	quantity INTEGER NOT NULL DEFAULT 1,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(telegram_id, food_id),
+	FOREIGN KEY (telegram_id) REFERENCES users(telegram_id) ON DELETE CASCADE,
+	FOREIGN KEY (food_id) REFERENCES foods(food_id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS superseded_refresh_tokens;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS login_throttles;
+DROP TABLE IF EXISTS login_codes;
@@ -0,0 +1,48 @@
+-- Bot orqali beriladigan bir martalik kodlar
+CREATE TABLE IF NOT EXISTS login_codes (
+	code_id SERIAL PRIMARY KEY,
+	telegram_id BIGINT NOT NULL,
+	code_hash TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (telegram_id) REFERENCES users(telegram_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_login_codes_telegram_id ON login_codes(telegram_id);
+
+-- Telefon va IP bo'yicha noto'g'ri urinishlar hisobi
+CREATE TABLE IF NOT EXISTS login_throttles (
+	scope TEXT NOT NULL, -- 'phone' yoki 'ip'
+	throttle_key TEXT NOT NULL,
+	failed_count INTEGER NOT NULL DEFAULT 0,
+	locked_until TIMESTAMP,
+	last_failed_at TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (scope, throttle_key)
+);
+
+-- Refresh tokenlar va sessiyalarni bekor qilish uchun
+CREATE TABLE IF NOT EXISTS sessions (
+	session_id TEXT PRIMARY KEY,
+	telegram_id BIGINT NOT NULL,
+	refresh_token_hash TEXT NOT NULL,
+	user_agent TEXT,
+	ip_address TEXT,
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP,
+	revoke_reason TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (telegram_id) REFERENCES users(telegram_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_telegram_id ON sessions(telegram_id);
+
+-- Almashtirilgan barcha refresh tokenlar (faqat hash). Ulardan istalgani qayta kelsa token o'g'irlangan
+-- deb hisoblanadi va sessiya bekor qilinadi.
+CREATE TABLE IF NOT EXISTS superseded_refresh_tokens (
+	session_id TEXT NOT NULL REFERENCES sessions(session_id) ON DELETE CASCADE,
+	token_hash TEXT NOT NULL,
+	superseded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (session_id, token_hash)
+);
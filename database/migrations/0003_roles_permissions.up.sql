@@ -0,0 +1,70 @@
+-- Rol -> ruxsatlar xaritasi bazada saqlanadi
+CREATE TABLE IF NOT EXISTS roles (
+	role_name TEXT PRIMARY KEY,
+	description TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS permissions (
+	permission_code TEXT PRIMARY KEY,
+	description TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS role_permissions (
+	role_name TEXT NOT NULL,
+	permission_code TEXT NOT NULL,
+	PRIMARY KEY (role_name, permission_code),
+	FOREIGN KEY (role_name) REFERENCES roles(role_name) ON UPDATE CASCADE ON DELETE CASCADE,
+	FOREIGN KEY (permission_code) REFERENCES permissions(permission_code) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+INSERT INTO roles(role_name, description) VALUES
+	('customer', 'Mijoz'),
+	('waiter', 'Ofitsiant'),
+	('cook', 'Oshpaz'),
+	('courier', 'Kuryer'),
+	('manager', 'Menejer'),
+	('superadmin', 'Superadmin')
+ON CONFLICT (role_name) DO NOTHING;
+
+INSERT INTO permissions(permission_code, description) VALUES
+	('menu.write', 'Menyuni o''zgartirish'),
+	('orders.read.all', 'Barcha buyurtmalarni ko''rish'),
+	('orders.status.update', 'Buyurtma holatini o''zgartirish'),
+	('orders.delete', 'Buyurtmani o''chirish'),
+	('users.read', 'Foydalanuvchilarni ko''rish'),
+	('users.roles.manage', 'Foydalanuvchi rollarini boshqarish'),
+	('sessions.revoke', 'Foydalanuvchi sessiyalarini bekor qilish'),
+	('stats.read', 'Statistikani ko''rish')
+ON CONFLICT (permission_code) DO NOTHING;
+
+INSERT INTO role_permissions(role_name, permission_code) VALUES
+	('waiter', 'orders.read.all'),
+	('waiter', 'orders.status.update'),
+	('cook', 'orders.read.all'),
+	('cook', 'orders.status.update'),
+	('courier', 'orders.status.update'),
+	('manager', 'menu.write'),
+	('manager', 'orders.read.all'),
+	('manager', 'orders.status.update'),
+	('manager', 'orders.delete'),
+	('manager', 'users.read'),
+	('manager', 'sessions.revoke'),
+	('manager', 'stats.read')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions(role_name, permission_code)
+SELECT 'superadmin', permission_code FROM permissions
+ON CONFLICT DO NOTHING;
+
+-- Eski rollar: 'user' -> 'customer', 'admin' -> 'manager'
+UPDATE users SET role = 'customer' WHERE role = 'user' OR role = '';
+UPDATE users SET role = 'manager' WHERE role = 'admin';
+UPDATE users SET role = 'customer' WHERE role NOT IN (SELECT role_name FROM roles);
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'customer';
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'users_role_fkey') THEN
+		ALTER TABLE users ADD CONSTRAINT users_role_fkey
+			FOREIGN KEY (role) REFERENCES roles(role_name) ON UPDATE CASCADE;
+	END IF;
+END $$;
@@ -0,0 +1,28 @@
+-- Telefon raqamlarini E.164 ga keltirish (pkg/phone.Normalize bilan bir xil qoidalar)
+UPDATE users u
+SET phone = CASE WHEN length(x.digits) = 9 THEN '+998' || x.digits ELSE '+' || x.digits END
+FROM (
+	SELECT telegram_id,
+		CASE WHEN left(btrim(phone), 2) = '00'
+			THEN substr(regexp_replace(phone, '[^0-9]', '', 'g'), 3)
+			ELSE regexp_replace(phone, '[^0-9]', '', 'g')
+		END AS digits
+	FROM users
+	WHERE phone IS NOT NULL AND phone <> ''
+) x
+WHERE u.telegram_id = x.telegram_id
+	AND x.digits <> ''
+	AND u.phone <> CASE WHEN length(x.digits) = 9 THEN '+998' || x.digits ELSE '+' || x.digits END;
+
+-- Takrorlangan raqam eng oxirgi yangilangan foydalanuvchida qoladi
+UPDATE users SET phone = '', updated_at = CURRENT_TIMESTAMP
+WHERE userid IN (
+	SELECT userid FROM (
+		SELECT userid, ROW_NUMBER() OVER (PARTITION BY phone ORDER BY updated_at DESC, userid DESC) AS rn
+		FROM users
+		WHERE phone IS NOT NULL AND phone <> ''
+	) ranked
+	WHERE ranked.rn > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_phone_unique ON users(phone) WHERE phone IS NOT NULL AND phone <> '';
@@ -0,0 +1,15 @@
+-- Idempotency-Key sarlavhasi bilan kelgan so'rovlar natijasi (qayta yuborilganda shu javob qaytariladi)
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	telegram_id BIGINT NOT NULL,
+	idempotency_key TEXT NOT NULL,
+	request_method TEXT NOT NULL,
+	request_path TEXT NOT NULL,
+	request_hash TEXT NOT NULL,
+	status_code INTEGER, -- NULL: so'rov hali bajarilmoqda
+	response_body BYTEA,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	completed_at TIMESTAMP,
+	PRIMARY KEY (telegram_id, idempotency_key),
+	FOREIGN KEY (telegram_id) REFERENCES users(telegram_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
@@ -0,0 +1,12 @@
+ALTER TABLE order_items DROP COLUMN IF EXISTS note;
+ALTER TABLE order_items DROP COLUMN IF EXISTS modifiers;
+
+-- Eski UNIQUE(telegram_id, food_id) ni tiklash uchun modifikatorli qatorlar o'chiriladi
+DELETE FROM basket_orders WHERE modifiers_key <> '' OR note <> '';
+ALTER TABLE basket_orders DROP CONSTRAINT IF EXISTS basket_orders_line_key;
+ALTER TABLE basket_orders DROP COLUMN IF EXISTS note;
+ALTER TABLE basket_orders DROP COLUMN IF EXISTS modifiers_key;
+ALTER TABLE basket_orders ADD CONSTRAINT basket_orders_telegram_id_food_id_key UNIQUE (telegram_id, food_id);
+
+DROP TABLE IF EXISTS modifier_options;
+DROP TABLE IF EXISTS modifier_groups;
@@ -0,0 +1,33 @@
+-- Menyu modifikatorlari: har bir ovqat uchun variant guruhlari (o'lcham, achchiqlik, qo'shimchalar)
+CREATE TABLE IF NOT EXISTS modifier_groups (
+	group_id SERIAL PRIMARY KEY,
+	food_id INTEGER NOT NULL,
+	group_name TEXT NOT NULL,
+	min_select INTEGER NOT NULL DEFAULT 0, -- 1 va undan ko'p bo'lsa guruh majburiy
+	max_select INTEGER NOT NULL DEFAULT 1,
+	sort_order INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (food_id) REFERENCES foods(food_id) ON DELETE CASCADE,
+	CHECK (min_select >= 0 AND max_select >= 1 AND min_select <= max_select)
+);
+CREATE INDEX IF NOT EXISTS idx_modifier_groups_food_id ON modifier_groups(food_id);
+
+CREATE TABLE IF NOT EXISTS modifier_options (
+	option_id SERIAL PRIMARY KEY,
+	group_id INTEGER NOT NULL,
+	option_name TEXT NOT NULL,
+	price_delta DECIMAL(10,2) NOT NULL DEFAULT 0.0,
+	sort_order INTEGER NOT NULL DEFAULT 0,
+	FOREIGN KEY (group_id) REFERENCES modifier_groups(group_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_modifier_options_group_id ON modifier_options(group_id);
+
+-- Savatchada bitta ovqat turli modifikatorlar yoki izoh bilan alohida qatorlarda turishi mumkin
+ALTER TABLE basket_orders ADD COLUMN IF NOT EXISTS modifiers_key TEXT NOT NULL DEFAULT ''; -- Tartiblangan option_id lar: "3,7"
+ALTER TABLE basket_orders ADD COLUMN IF NOT EXISTS note TEXT NOT NULL DEFAULT '';
+ALTER TABLE basket_orders DROP CONSTRAINT IF EXISTS basket_orders_telegram_id_food_id_key;
+ALTER TABLE basket_orders ADD CONSTRAINT basket_orders_line_key UNIQUE (telegram_id, food_id, modifiers_key, note);
+
+-- Buyurtma qatorida tanlangan modifikatorlarning o'sha paytdagi nusxasi va qator izohi
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS modifiers JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS note TEXT;
@@ -0,0 +1,5 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS discount_amount;
+ALTER TABLE orders DROP COLUMN IF EXISTS service_charge;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_fee;
+ALTER TABLE orders DROP COLUMN IF EXISTS subtotal;
+ALTER TABLE basket_orders DROP COLUMN IF EXISTS unit_price_at_add;
@@ -0,0 +1,12 @@
+-- Savatchaga qo'shilgan paytdagi bitta dona narxi (modifikatorlar bilan): narx o'zgarganini aniqlash uchun
+ALTER TABLE basket_orders ADD COLUMN IF NOT EXISTS unit_price_at_add DECIMAL(10,2);
+UPDATE basket_orders b SET unit_price_at_add = f.food_price
+FROM foods f
+WHERE b.food_id = f.food_id AND b.unit_price_at_add IS NULL AND b.modifiers_key = '';
+
+-- Buyurtma narxining tarkibi: total_price = subtotal + delivery_fee + service_charge - discount_amount
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS subtotal DECIMAL(10,2) NOT NULL DEFAULT 0.0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_fee DECIMAL(10,2) NOT NULL DEFAULT 0.0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS service_charge DECIMAL(10,2) NOT NULL DEFAULT 0.0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0.0;
+UPDATE orders SET subtotal = total_price WHERE subtotal = 0 AND total_price <> 0;
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS order_status_history;
+
+UPDATE orders SET order_status = CASE order_status
+	WHEN 'accepted' THEN 'buyurtma qabul qilindi'
+	WHEN 'preparing' THEN 'buyurtma tayyorlanmoqda'
+	WHEN 'ready' THEN 'buyurtma tayyor'
+	WHEN 'on_the_way' THEN 'buyurtma tayyor'
+	WHEN 'delivered' THEN 'buyurtma yetkazildi'
+	WHEN 'picked_up' THEN 'buyurtma yetkazildi'
+	WHEN 'served' THEN 'buyurtma yetkazildi'
+	WHEN 'cancelled' THEN 'bekor qilindi'
+	ELSE order_status
+END;
+ALTER TABLE orders ALTER COLUMN order_status SET DEFAULT 'pending';
+ALTER TABLE orders DROP COLUMN IF EXISTS status_changed_at;
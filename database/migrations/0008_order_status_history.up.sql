@@ -0,0 +1,36 @@
+-- Buyurtma holatlari o'zbekcha matn o'rniga barqaror kodlarda saqlanadi (matnlar ilovada tarjima qilinadi)
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+UPDATE orders SET status_changed_at = COALESCE(updated_at, order_time, CURRENT_TIMESTAMP);
+
+UPDATE orders SET order_status = CASE order_status
+	WHEN 'pending' THEN 'accepted'
+	WHEN 'buyurtma qabul qilindi' THEN 'accepted'
+	WHEN 'buyurtma tayyorlanmoqda' THEN 'preparing'
+	WHEN 'buyurtma tayyor' THEN 'ready'
+	WHEN 'bekor qilindi' THEN 'cancelled'
+	WHEN 'buyurtma yetkazildi' THEN CASE delivery_type
+		WHEN 'o''zi olib ketish' THEN 'picked_up'
+		WHEN 'zalga' THEN 'served'
+		ELSE 'delivered'
+	END
+	ELSE order_status
+END;
+ALTER TABLE orders ALTER COLUMN order_status SET DEFAULT 'accepted';
+
+-- Holat o'zgarishlari tarixi: kim, qachon va nima sababdan o'zgartirgan
+CREATE TABLE IF NOT EXISTS order_status_history (
+	history_id SERIAL PRIMARY KEY,
+	order_id INTEGER NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+	from_status TEXT,
+	to_status TEXT NOT NULL,
+	actor_telegram_id BIGINT,
+	reason TEXT,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS order_status_history_order_id_idx ON order_status_history(order_id, created_at);
+
+-- Mavjud buyurtmalar uchun joriy holat tarixning birinchi yozuvi bo'ladi (muallif noma'lum)
+INSERT INTO order_status_history(order_id, from_status, to_status, created_at)
+SELECT order_id, NULL, order_status, status_changed_at FROM orders;
@@ -0,0 +1,8 @@
+-- Diqqat: arxivlangan buyurtmalar asosiy jadvalga qaytarilmaydi
+DROP TABLE IF EXISTS order_items_archive;
+DROP TABLE IF EXISTS orders_archive;
+
+DROP INDEX IF EXISTS orders_telegram_id_active_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS delete_reason;
+ALTER TABLE orders DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE orders DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,38 @@
+-- Buyurtmalar o'chirilmaydi, faqat o'chirilgan deb belgilanadi (daromad tarixi saqlanib qoladi)
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS deleted_by BIGINT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delete_reason TEXT;
+CREATE INDEX IF NOT EXISTS orders_telegram_id_active_idx ON orders(telegram_id, order_time DESC) WHERE deleted_at IS NULL;
+
+-- Eski buyurtmalar arxivi. Arxivlash jarayoni buyurtmani shu jadvallarga ko'chirib, asosiy jadvaldan o'chiradi.
+CREATE TABLE IF NOT EXISTS orders_archive (
+	order_id INTEGER PRIMARY KEY,
+	telegram_id BIGINT NOT NULL,
+	order_time TIMESTAMP,
+	order_status TEXT NOT NULL,
+	delivery_type TEXT,
+	total_price DECIMAL(10,2) NOT NULL DEFAULT 0.0,
+	subtotal DECIMAL(10,2) NOT NULL DEFAULT 0.0,
+	delivery_fee DECIMAL(10,2) NOT NULL DEFAULT 0.0,
+	service_charge DECIMAL(10,2) NOT NULL DEFAULT 0.0,
+	discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0.0,
+	deleted_at TIMESTAMP,
+	deleted_by BIGINT,
+	delete_reason TEXT,
+	order_data JSONB NOT NULL,                     -- orders qatorining to'liq nusxasi
+	status_history JSONB NOT NULL DEFAULT '[]',    -- order_status_history yozuvlari
+	archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS orders_archive_order_time_idx ON orders_archive(order_time);
+CREATE INDEX IF NOT EXISTS orders_archive_telegram_id_idx ON orders_archive(telegram_id);
+
+CREATE TABLE IF NOT EXISTS order_items_archive (
+	order_item_id INTEGER PRIMARY KEY,
+	order_id INTEGER NOT NULL REFERENCES orders_archive(order_id) ON DELETE CASCADE,
+	food_id INTEGER,
+	quantity INTEGER NOT NULL,
+	item_price DECIMAL(10,2) NOT NULL,
+	modifiers JSONB NOT NULL DEFAULT '[]',
+	note TEXT
+);
+CREATE INDEX IF NOT EXISTS order_items_archive_order_id_idx ON order_items_archive(order_id);
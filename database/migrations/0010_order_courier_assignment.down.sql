@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE permission_code IN ('orders.read.assigned', 'orders.assign');
+
+DROP INDEX IF EXISTS orders_courier_telegram_id_idx;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_courier_telegram_id_fkey;
+ALTER TABLE orders DROP COLUMN IF EXISTS courier_telegram_id;
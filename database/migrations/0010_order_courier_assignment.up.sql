@@ -0,0 +1,22 @@
+-- Kuryerga biriktirilgan buyurtmalar: kuryer faqat o'ziga biriktirilganlarini ko'radi va holatini o'zgartiradi
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS courier_telegram_id BIGINT;
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'orders_courier_telegram_id_fkey') THEN
+		ALTER TABLE orders ADD CONSTRAINT orders_courier_telegram_id_fkey
+			FOREIGN KEY (courier_telegram_id) REFERENCES users(telegram_id) ON DELETE SET NULL;
+	END IF;
+END $$;
+CREATE INDEX IF NOT EXISTS orders_courier_telegram_id_idx ON orders(courier_telegram_id) WHERE courier_telegram_id IS NOT NULL;
+
+INSERT INTO permissions(permission_code, description) VALUES
+	('orders.read.assigned', 'O''ziga biriktirilgan buyurtmalarni ko''rish'),
+	('orders.assign', 'Buyurtmani kuryerga biriktirish')
+ON CONFLICT (permission_code) DO NOTHING;
+
+INSERT INTO role_permissions(role_name, permission_code) VALUES
+	('courier', 'orders.read.assigned'),
+	('manager', 'orders.assign'),
+	('superadmin', 'orders.read.assigned'),
+	('superadmin', 'orders.assign')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS order_items_order_id_idx;
+DROP INDEX IF EXISTS orders_status_idx;
+DROP INDEX IF EXISTS orders_total_price_id_idx;
+DROP INDEX IF EXISTS orders_order_time_id_idx;
@@ -0,0 +1,5 @@
+-- GET /api/admin/orders: vaqt va narx bo'yicha kursorli sahifalash uchun indekslar
+CREATE INDEX IF NOT EXISTS orders_order_time_id_idx ON orders(order_time, order_id);
+CREATE INDEX IF NOT EXISTS orders_total_price_id_idx ON orders(total_price, order_id);
+CREATE INDEX IF NOT EXISTS orders_status_idx ON orders(order_status);
+CREATE INDEX IF NOT EXISTS order_items_order_id_idx ON order_items(order_id);
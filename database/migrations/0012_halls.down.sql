@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS orders_table_id_idx;
+ALTER TABLE tables DROP COLUMN IF EXISTS hall_id;
+DROP TABLE IF EXISTS halls;
@@ -0,0 +1,20 @@
+-- Zallar (Zal-1, Zal-2, Terassa): stollar zallarga biriktiriladi, buyurtma javobida zal nomi ko'rsatiladi
+CREATE TABLE IF NOT EXISTS halls (
+	hall_id SERIAL PRIMARY KEY,
+	hall_name TEXT UNIQUE NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE tables ADD COLUMN IF NOT EXISTS hall_id INTEGER REFERENCES halls(hall_id) ON DELETE SET NULL;
+
+-- Eski bazalarda orders.table_id foreign keysiz qo'shilgan bo'lishi mumkin
+UPDATE orders SET table_id = NULL WHERE table_id IS NOT NULL AND table_id NOT IN (SELECT table_id FROM tables);
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'orders_table_id_fkey') THEN
+		ALTER TABLE orders ADD CONSTRAINT orders_table_id_fkey
+			FOREIGN KEY (table_id) REFERENCES tables(table_id) ON DELETE SET NULL;
+	END IF;
+END $$;
+CREATE INDEX IF NOT EXISTS orders_table_id_idx ON orders(table_id) WHERE table_id IS NOT NULL;
@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE permission_code = 'tables.manage';
+
+DROP INDEX IF EXISTS tables_hall_id_idx;
+ALTER TABLE tables DROP COLUMN IF EXISTS is_active;
+ALTER TABLE tables DROP COLUMN IF EXISTS capacity;
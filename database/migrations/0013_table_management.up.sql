@@ -0,0 +1,13 @@
+-- Stollarni boshqarish: sig'imi va faollik belgisi; nofaol stolga QR orqali buyurtma berilmaydi
+ALTER TABLE tables ADD COLUMN IF NOT EXISTS capacity INTEGER NOT NULL DEFAULT 4;
+ALTER TABLE tables ADD COLUMN IF NOT EXISTS is_active BOOLEAN NOT NULL DEFAULT TRUE;
+CREATE INDEX IF NOT EXISTS tables_hall_id_idx ON tables(hall_id);
+
+INSERT INTO permissions(permission_code, description) VALUES
+	('tables.manage', 'Zallar va stollarni boshqarish')
+ON CONFLICT (permission_code) DO NOTHING;
+
+INSERT INTO role_permissions(role_name, permission_code) VALUES
+	('manager', 'tables.manage'),
+	('superadmin', 'tables.manage')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE permission_code = 'tables.sessions.manage';
+
+DROP INDEX IF EXISTS orders_table_session_id_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS table_session_id;
+DROP TABLE IF EXISTS table_sessions;
@@ -0,0 +1,24 @@
+-- Stol sessiyasi (ochiq hisob): stol QR kodi birinchi skanerlanganda ochiladi, shu stoldagi barcha buyurtmalar unga biriktiriladi
+CREATE TABLE IF NOT EXISTS table_sessions (
+	session_id SERIAL PRIMARY KEY,
+	table_id INTEGER NOT NULL REFERENCES tables(table_id) ON DELETE CASCADE,
+	opened_by BIGINT REFERENCES users(telegram_id) ON DELETE SET NULL,
+	opened_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	closed_at TIMESTAMP,
+	closed_by BIGINT REFERENCES users(telegram_id) ON DELETE SET NULL
+);
+-- Har bir stolda bir vaqtda faqat bitta ochiq sessiya bo'lishi mumkin
+CREATE UNIQUE INDEX IF NOT EXISTS table_sessions_one_open_idx ON table_sessions(table_id) WHERE closed_at IS NULL;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS table_session_id INTEGER REFERENCES table_sessions(session_id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS orders_table_session_id_idx ON orders(table_session_id) WHERE table_session_id IS NOT NULL;
+
+INSERT INTO permissions(permission_code, description) VALUES
+	('tables.sessions.manage', 'Stol hisoblarini ko''rish va yopish')
+ON CONFLICT (permission_code) DO NOTHING;
+
+INSERT INTO role_permissions(role_name, permission_code) VALUES
+	('waiter', 'tables.sessions.manage'),
+	('manager', 'tables.sessions.manage'),
+	('superadmin', 'tables.sessions.manage')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,16 @@
+-- Stoldan chaqiruvlar: "ofitsiantni chaqirish" va "hisobni olib kelish". Xodimlar chatiga bot orqali yuboriladi.
+CREATE TABLE IF NOT EXISTS table_requests (
+	request_id SERIAL PRIMARY KEY,
+	table_id INTEGER NOT NULL REFERENCES tables(table_id) ON DELETE CASCADE,
+	session_id INTEGER REFERENCES table_sessions(session_id) ON DELETE SET NULL,
+	request_type TEXT NOT NULL CHECK (request_type IN ('call_waiter', 'bring_bill')),
+	telegram_id BIGINT REFERENCES users(telegram_id) ON DELETE SET NULL, -- Tugmani bosgan mehmon
+	repeat_count INTEGER NOT NULL DEFAULT 1,                            -- Qabul qilinguncha necha marta bosilgan
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_requested_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	staff_message_id INTEGER,                                           -- Xodimlar chatidagi xabar (tugmani olib tashlash uchun)
+	acknowledged_at TIMESTAMP,
+	acknowledged_by BIGINT REFERENCES users(telegram_id) ON DELETE SET NULL
+);
+-- Qayta bosishlar bitta kutilayotgan chaqiruvga yig'iladi
+CREATE UNIQUE INDEX IF NOT EXISTS table_requests_one_pending_idx ON table_requests(table_id, request_type) WHERE acknowledged_at IS NULL;
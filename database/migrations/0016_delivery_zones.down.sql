@@ -0,0 +1,6 @@
+DELETE FROM permissions WHERE permission_code = 'delivery_zones.manage';
+
+DROP INDEX IF EXISTS orders_delivery_zone_id_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_eta_minutes;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_zone_id;
+DROP TABLE IF EXISTS delivery_zones;
@@ -0,0 +1,27 @@
+-- Yetkazib berish hududlari: har bir hudud GeoJSON ko'pburchagi, yetkazib berish narxi, minimal buyurtma summasi va taxminiy vaqti bilan.
+-- Hech bir faol hududga tushmaydigan manzilga yetkazib berish buyurtmasi qabul qilinmaydi.
+CREATE TABLE IF NOT EXISTS delivery_zones (
+	zone_id SERIAL PRIMARY KEY,
+	zone_name TEXT NOT NULL UNIQUE,
+	polygon JSONB NOT NULL,                                  -- GeoJSON MultiPolygon geometriyasi ([longitude, latitude])
+	delivery_fee NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (delivery_fee >= 0),
+	min_order_amount NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (min_order_amount >= 0), -- Subtotal bo'yicha
+	eta_minutes INTEGER NOT NULL CHECK (eta_minutes > 0),   -- Taxminiy yetkazib berish vaqti
+	is_active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Buyurtma qaysi hududga tushgani va o'sha paytdagi taxminiy vaqt (hudud keyin o'zgarsa ham saqlanadi)
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_zone_id INTEGER REFERENCES delivery_zones(zone_id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_eta_minutes INTEGER;
+CREATE INDEX IF NOT EXISTS orders_delivery_zone_id_idx ON orders(delivery_zone_id);
+
+INSERT INTO permissions(permission_code, description) VALUES
+	('delivery_zones.manage', 'Yetkazib berish hududlarini boshqarish')
+ON CONFLICT (permission_code) DO NOTHING;
+
+INSERT INTO role_permissions(role_name, permission_code) VALUES
+	('manager', 'delivery_zones.manage'),
+	('superadmin', 'delivery_zones.manage')
+ON CONFLICT DO NOTHING;
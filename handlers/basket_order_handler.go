@@ -5,6 +5,7 @@ import (
 	"amur/models"
 	"amur/service"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -44,16 +45,12 @@ func (h *BasketOrderHandler) sendSuccessResponse(w http.ResponseWriter, message
 
 // getTelegramIDFromContext yordamchi funksiya
 func (h *BasketOrderHandler) getTelegramIDFromContext(w http.ResponseWriter, r *http.Request) (int64, bool) {
-	// OLD: userID, ok := r.Context().Value(middleware.UserContextKey).(int)
-	// YANGI: Contextdan Telegram IDni to'g'ridan-to'g'ri int64 sifatida olish
-	telegramID, ok := r.Context().Value(middleware.TelegramIDContextKey).(int64) // <--- Shu qatorni o'zgartiring!
+	principal, ok := middleware.PrincipalFromContext(r.Context())
 	if !ok {
 		h.sendErrorResponse(w, http.StatusInternalServerError, "Foydalanuvchi Telegram IDsi kontekstda topilmadi", "Autentifikatsiya xatoligi. AuthMiddleware to'g'ri ishlamagan bo'lishi mumkin.")
 		return 0, false
 	}
-	// Endi int dan int64 ga o'girish shart emas, chunki biz to'g'ridan-to'g'ri int64 sifatida olyapmiz
-	// telegramID := int64(userID) // Bu qator endi kerak emas
-	return telegramID, true
+	return principal.TelegramID, true
 }
 
 // AddToBasket savatchaga mahsulot qo'shish
@@ -77,30 +74,202 @@ func (h *BasketOrderHandler) AddToBasket(w http.ResponseWriter, r *http.Request)
 
 	order, err := h.basketService.AddToBasket(telegramID, &req)
 	if err != nil {
-		h.sendErrorResponse(w, http.StatusInternalServerError, "Savatchaga mahsulot qo'shishda xatolik", err.Error())
+		h.sendBasketError(w, "Savatchaga mahsulot qo'shishda xatolik", err)
 		return
 	}
 
 	h.sendSuccessResponse(w, "Mahsulot savatchaga muvaffaqiyatli qo'shildi/yangilandi", order)
 }
 
-// GetBasketOrders savatchadagi barcha mahsulotlarni olish
-// GET /api/basket-order
+// priceQuery to'lovlarni hisoblash uchun ixtiyoriy delivery_type, latitude va longitude parametrlarini o'qiydi
+func (h *BasketOrderHandler) priceQuery(w http.ResponseWriter, r *http.Request) (models.BasketPriceQuery, bool) {
+	values := r.URL.Query()
+	query := models.BasketPriceQuery{DeliveryType: values.Get("delivery_type")}
+	var err error
+	if query.Latitude, err = optionalFloat(values.Get("latitude")); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri latitude qiymati", err.Error())
+		return query, false
+	}
+	if query.Longitude, err = optionalFloat(values.Get("longitude")); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri longitude qiymati", err.Error())
+		return query, false
+	}
+	return query, true
+}
+
+// optionalFloat bo'sh qiymat uchun nil, aks holda sonni qaytaradi
+func optionalFloat(raw string) (*float64, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// GetBasketOrders savatchadagi barcha mahsulotlarni narx tarkibi bilan olish
+// GET /api/basket-order?delivery_type=yetkazib%20berish&latitude=41.31&longitude=69.24
+// (hammasi ixtiyoriy; yetkazib berish narxi faqat manzil bilan hisoblanadi, aks holda delivery_fee va grand_total null)
 func (h *BasketOrderHandler) GetBasketOrders(w http.ResponseWriter, r *http.Request) {
 	telegramID, ok := h.getTelegramIDFromContext(w, r)
 	if !ok {
 		return
 	}
+	query, ok := h.priceQuery(w, r)
+	if !ok {
+		return
+	}
 
-	orders, err := h.basketService.GetBasketOrders(telegramID)
+	orders, err := h.basketService.GetBasketOrders(telegramID, query)
 	if err != nil {
-		h.sendErrorResponse(w, http.StatusInternalServerError, "Savatchani olishda xatolik", err.Error())
+		h.sendBasketError(w, "Savatchani olishda xatolik", err)
 		return
 	}
 
 	h.sendSuccessResponse(w, "Savatcha muvaffaqiyatli olindi", orders)
 }
 
+// UpdateBasketQuantity savatchadagi mahsulot miqdorini to'g'ridan-to'g'ri o'rnatish (0 - o'chirish)
+// PATCH /api/basket-order/{foodID}
+func (h *BasketOrderHandler) UpdateBasketQuantity(w http.ResponseWriter, r *http.Request) {
+	telegramID, ok := h.getTelegramIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	foodID, err := strconv.Atoi(mux.Vars(r)["foodID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri Food ID", err.Error())
+		return
+	}
+
+	var req models.UpdateBasketQuantityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+	if req.Quantity == nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Miqdor majburiy", "JSON tanasida 'quantity' maydoni bo'lishi kerak.")
+		return
+	}
+
+	order, err := h.basketService.SetQuantity(telegramID, foodID, *req.Quantity)
+	if err != nil {
+		h.sendBasketError(w, "Savatcha miqdorini o'zgartirishda xatolik", err)
+		return
+	}
+
+	if order == nil {
+		h.sendSuccessResponse(w, "Mahsulot savatchadan muvaffaqiyatli o'chirildi", nil)
+		return
+	}
+	h.sendSuccessResponse(w, "Savatcha miqdori muvaffaqiyatli yangilandi", order)
+}
+
+// UpdateBasketLine savatcha qatori miqdorini qator ID'si bo'yicha o'rnatish (0 - o'chirish).
+// Bitta ovqat turli modifikatorlar bilan bir nechta qatorda bo'lishi mumkin, shuning uchun qator ID'si ishlatiladi.
+// PATCH /api/basket-order/lines/{basketOrderID}
+func (h *BasketOrderHandler) UpdateBasketLine(w http.ResponseWriter, r *http.Request) {
+	telegramID, ok := h.getTelegramIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	basketOrderID, err := strconv.Atoi(mux.Vars(r)["basketOrderID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri savatcha qatori ID", err.Error())
+		return
+	}
+
+	var req models.UpdateBasketQuantityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+	if req.Quantity == nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Miqdor majburiy", "JSON tanasida 'quantity' maydoni bo'lishi kerak.")
+		return
+	}
+
+	order, err := h.basketService.SetLineQuantity(telegramID, basketOrderID, *req.Quantity)
+	if err != nil {
+		h.sendBasketError(w, "Savatcha qatorini o'zgartirishda xatolik", err)
+		return
+	}
+
+	if order == nil {
+		h.sendSuccessResponse(w, "Savatcha qatori muvaffaqiyatli o'chirildi", nil)
+		return
+	}
+	h.sendSuccessResponse(w, "Savatcha qatori muvaffaqiyatli yangilandi", order)
+}
+
+// RemoveBasketLine savatchadan bitta qatorni o'chirish
+// DELETE /api/basket-order/lines/{basketOrderID}
+func (h *BasketOrderHandler) RemoveBasketLine(w http.ResponseWriter, r *http.Request) {
+	telegramID, ok := h.getTelegramIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	basketOrderID, err := strconv.Atoi(mux.Vars(r)["basketOrderID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri savatcha qatori ID", err.Error())
+		return
+	}
+
+	if err := h.basketService.RemoveLine(telegramID, basketOrderID); err != nil {
+		h.sendBasketError(w, "Savatcha qatorini o'chirishda xatolik", err)
+		return
+	}
+
+	h.sendSuccessResponse(w, "Savatcha qatori muvaffaqiyatli o'chirildi", nil)
+}
+
+// SyncBasket butun savatchani mijozdagi savatcha bilan bitta so'rovda almashtirish
+// PUT /api/basket-order?delivery_type=...&latitude=...&longitude=... (javobda GET bilan bir xil savatcha xulosasi qaytadi)
+func (h *BasketOrderHandler) SyncBasket(w http.ResponseWriter, r *http.Request) {
+	telegramID, ok := h.getTelegramIDFromContext(w, r)
+	if !ok {
+		return
+	}
+	query, ok := h.priceQuery(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SyncBasketRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	basket, err := h.basketService.ReplaceBasket(telegramID, req.Items, query)
+	if err != nil {
+		h.sendBasketError(w, "Savatchani yangilashda xatolik", err)
+		return
+	}
+
+	h.sendSuccessResponse(w, "Savatcha muvaffaqiyatli yangilandi", basket)
+}
+
+// sendBasketError servis xatolarini mos HTTP statusiga aylantiradi
+func (h *BasketOrderHandler) sendBasketError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidBasketItem), errors.Is(err, service.ErrBasketQuantityLimit),
+		errors.Is(err, service.ErrInvalidModifiers), errors.Is(err, service.ErrLineNoteTooLong),
+		errors.Is(err, service.ErrInvalidDeliveryType), errors.Is(err, service.ErrInvalidDeliveryLocation),
+		errors.Is(err, service.ErrOutsideDeliveryZone):
+		h.sendErrorResponse(w, http.StatusBadRequest, message, err.Error())
+	case errors.Is(err, service.ErrBasketFoodNotFound), errors.Is(err, service.ErrBasketItemNotFound):
+		h.sendErrorResponse(w, http.StatusNotFound, message, err.Error())
+	default:
+		h.sendErrorResponse(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
 // RemoveFromBasket savatchadan mahsulotni o'chirish
 // DELETE /api/basket-order/{foodID} (telegramID endi URLda emas)
 func (h *BasketOrderHandler) RemoveFromBasket(w http.ResponseWriter, r *http.Request) {
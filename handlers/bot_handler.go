@@ -2,22 +2,30 @@ package handlers
 
 import (
 	"amur/models"
+	"amur/pkg/phone"
 	"amur/service"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 type BotHandler struct {
-	bot         *tgbotapi.BotAPI
-	userService *service.UserService
+	bot               *tgbotapi.BotAPI
+	userService       *service.UserService
+	permissionService *service.PermissionService
+	tableService      *service.TableService
 }
 
-func NewBotHandler(bot *tgbotapi.BotAPI, userService *service.UserService) *BotHandler {
+func NewBotHandler(bot *tgbotapi.BotAPI, userService *service.UserService, permissionService *service.PermissionService, tableService *service.TableService) *BotHandler {
 	return &BotHandler{
-		bot:         bot,
-		userService: userService,
+		bot:               bot,
+		userService:       userService,
+		permissionService: permissionService,
+		tableService:      tableService,
 	}
 }
 
@@ -36,39 +44,53 @@ func (h *BotHandler) HandleStart(chatID int64) {
 func (h *BotHandler) HandleContact(update tgbotapi.Update) {
 	chatID := update.Message.Chat.ID
 
+	// Faqat foydalanuvchining o'z kontakti qabul qilinadi, aks holda raqam tasdiqlangan hisoblanmaydi
+	contact := update.Message.Contact
+	if contact.UserID != 0 && contact.UserID != update.Message.From.ID {
+		msg := tgbotapi.NewMessage(chatID, "⚠️ Iltimos, tugma orqali o'zingizning telefon raqamingizni yuboring.")
+		h.bot.Send(msg)
+		return
+	}
+
 	user := h.extractUserFromContact(update)
+	isNewUser := !h.userService.UserExists(user.TelegramID) // Saqlashdan oldin tekshiramiz
 
-	// SaveOrUpdateUser endi 2 ta qiymat qaytaradi
-	savedUser, err := h.userService.SaveOrUpdateUser(user) // <-- Shu qatorni o'zgartirdik
+	savedUser, previousOwners, err := h.userService.SaveContact(user)
 	if err != nil {
 		log.Printf("Foydalanuvchini saqlashda yoki yangilashda xatolik: %v", err)
-		msg := tgbotapi.NewMessage(chatID, "❌ Ma'lumotlarni saqlashda xatolik yuz berdi. Qaytadan urinib ko'ring.")
+		text := "❌ Ma'lumotlarni saqlashda xatolik yuz berdi. Qaytadan urinib ko'ring."
+		if errors.Is(err, service.ErrInvalidPhoneNumber) {
+			text = "❌ Telefon raqami noto'g'ri formatda."
+		}
+		msg := tgbotapi.NewMessage(chatID, text)
 		h.bot.Send(msg)
 		return
 	}
 
-	// GenerateUserCode, GetUserCount, UserExists funksiyalari UserService ichida bo'lishi kerak.
-	// Agar service/user_service.go ichida bu funksiyalar to'g'ridan-to'g'ri
-	// repository.UserRepository metodlarini chaqirsa ham,
-	// BotHandler ularni UserService orqali chaqirishi kerak.
-	// Men service/user_service.go da bu funksiyalarni yana qayta kiritib beraman.
+	// Raqam avval boshqa hisobga biriktirilgan bo'lsa, eski egasini ogohlantiramiz
+	for _, previousOwner := range previousOwners {
+		h.SendMessage(previousOwner, "ℹ️ Telefon raqamingiz boshqa Telegram hisobiga biriktirildi va bu hisobdan olib tashlandi. "+
+			"Agar bu siz bo'lmasangiz, /start orqali raqamingizni qayta yuboring.")
+	}
 
-	code := h.userService.GenerateUserCode(savedUser.TelegramID) // <-- savedUser.TelegramID ishlatildi
-	userCount, err := h.userService.GetUserCount()               // <-- GetUserCount endi error qaytaradi
+	code, err := h.userService.IssueLoginCode(savedUser.TelegramID)
 	if err != nil {
-		log.Printf("Foydalanuvchilar sonini olishda xatolik: %v", err)
-		// Xato bo'lsa ham ishni davom ettirish yoki xato xabarini yuborish mumkin
-		userCount = 0 // Default qiymat
+		log.Printf("Kirish kodini yaratishda xatolik: %v", err)
+		msg := tgbotapi.NewMessage(chatID, "❌ Kod yaratishda xatolik yuz berdi. /code buyrug'i orqali qaytadan urinib ko'ring.")
+		h.bot.Send(msg)
+		return
 	}
 
 	var messageText string
-	// UserExists endi GetUserByID ni chaqirishi kerak, yoki UserService ichida bo'lishi kerak.
-	// UserService ichida UserExists funksiyasi allaqachon bor.
-	if h.userService.UserExists(savedUser.TelegramID) { // <-- savedUser.TelegramID ishlatildi
-		messageText = fmt.Sprintf("ℹ️ Ma'lumotlaringiz yangilandi.\nSizning code:\n```%s```", code)
+	if isNewUser {
+		userCount, err := h.userService.GetUserCount() // <-- GetUserCount endi error qaytaradi
+		if err != nil {
+			log.Printf("Foydalanuvchilar sonini olishda xatolik: %v", err)
+			userCount = 0 // Default qiymat
+		}
+		messageText = fmt.Sprintf("✅ Raqamingiz saqlandi!\n%s\n\n📊 Jami foydalanuvchilar: %d", loginCodeText(code), userCount)
 	} else {
-		messageText = fmt.Sprintf("✅ Raqamingiz saqlandi!\nSizning code:\n```%s```\n\n📊 Jami foydalanuvchilar: %d",
-			code, userCount)
+		messageText = fmt.Sprintf("ℹ️ Ma'lumotlaringiz yangilandi.\n%s", loginCodeText(code))
 	}
 
 	msg := tgbotapi.NewMessage(chatID, messageText)
@@ -76,6 +98,143 @@ func (h *BotHandler) HandleContact(update tgbotapi.Update) {
 	h.bot.Send(msg)
 }
 
+// HandleCode ro'yxatdan o'tgan foydalanuvchiga yangi bir martalik kirish kodini yuboradi
+func (h *BotHandler) HandleCode(chatID, telegramID int64) {
+	user, err := h.userService.GetUserByID(telegramID)
+	if err != nil || user.PhoneNumber == "" {
+		msg := tgbotapi.NewMessage(chatID, "📱 Avval telefon raqamingizni yuboring. /start bosing.")
+		h.bot.Send(msg)
+		return
+	}
+
+	code, err := h.userService.IssueLoginCode(user.TelegramID)
+	if err != nil {
+		log.Printf("Kirish kodini yaratishda xatolik: %v", err)
+		msg := tgbotapi.NewMessage(chatID, "❌ Kod yaratishda xatolik yuz berdi. Qaytadan urinib ko'ring.")
+		h.bot.Send(msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, loginCodeText(code))
+	msg.ParseMode = "Markdown"
+	h.bot.Send(msg)
+}
+
+// loginCodeText kod va uning amal qilish muddati haqidagi xabar matnini tayyorlaydi
+func loginCodeText(code string) string {
+	return fmt.Sprintf("Sizning code:\n```%s```\n⏳ Kod %d daqiqa amal qiladi va faqat bir marta ishlatiladi.",
+		code, int(service.LoginCodeTTL.Minutes()))
+}
+
+// HandleSetRole foydalanuvchi rolini bot orqali o'zgartiradi: /setrole <telegram_id> <rol>
+// Faqat users.roles.manage ruxsatiga ega foydalanuvchilar (superadmin) ishlata oladi.
+func (h *BotHandler) HandleSetRole(chatID, actorTelegramID int64, args string) {
+	allowed, err := h.permissionService.HasPermission(actorTelegramID, models.PermUsersRolesManage)
+	if err != nil {
+		log.Printf("Ruxsatni tekshirishda xatolik: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "❌ Ruxsatni tekshirishda xatolik yuz berdi."))
+		return
+	}
+	if !allowed {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "⛔ Sizda bu buyruq uchun ruxsat yo'q."))
+		return
+	}
+
+	fields := strings.Fields(args)
+	var targetID int64
+	if len(fields) == 2 {
+		targetID, err = strconv.ParseInt(fields[0], 10, 64)
+	}
+	if len(fields) != 2 || err != nil {
+		roles, _ := h.permissionService.ListRoles()
+		var names []string
+		for _, role := range roles {
+			names = append(names, role.RoleName)
+		}
+		h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("ℹ️ Foydalanish: /setrole <telegram_id> <rol>\nRollar: %s", strings.Join(names, ", "))))
+		return
+	}
+
+	user, err := h.permissionService.AssignRole(actorTelegramID, targetID, fields[1])
+	if err != nil {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "❌ "+err.Error()))
+		return
+	}
+	h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ %s (ID: %d) endi '%s' rolida.", user.FirstName, user.TelegramID, user.Role)))
+}
+
+// SendMessage foydalanuvchiga oddiy matnli xabar yuboradi (service.Notifier interfeysi)
+func (h *BotHandler) SendMessage(chatID int64, text string) error {
+	_, err := h.bot.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
+
+// SendMessageWithButton bitta inline tugmali xabar yuboradi va uning ID sini qaytaradi (service.ActionNotifier interfeysi)
+func (h *BotHandler) SendMessageWithButton(chatID int64, text, buttonText, callbackData string) (int, error) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(buttonText, callbackData)),
+	)
+	sent, err := h.bot.Send(msg)
+	if err != nil {
+		return 0, err
+	}
+	return sent.MessageID, nil
+}
+
+// EditMessage xabar matnini almashtiradi; inline tugmalar olib tashlanadi (service.ActionNotifier interfeysi)
+func (h *BotHandler) EditMessage(chatID int64, messageID int, text string) error {
+	_, err := h.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, text))
+	return err
+}
+
+// HandleCallback inline tugmalar bosilishini qayta ishlaydi. Hozircha faqat stol chaqiruvini qabul qilish
+// ("Boryapman") bor va uni faqat tables.sessions.manage ruxsatiga ega xodimlar bosa oladi.
+func (h *BotHandler) HandleCallback(callback *tgbotapi.CallbackQuery) {
+	data, ok := strings.CutPrefix(callback.Data, service.TableRequestAckCallbackPrefix)
+	if !ok {
+		h.answerCallback(callback.ID, "")
+		return
+	}
+	requestID, err := strconv.Atoi(data)
+	if err != nil {
+		h.answerCallback(callback.ID, "❌ Noto'g'ri chaqiruv")
+		return
+	}
+
+	allowed, err := h.permissionService.HasPermission(callback.From.ID, models.PermTableSessionsManage)
+	if err != nil {
+		log.Printf("Ruxsatni tekshirishda xatolik: %v", err)
+		h.answerCallback(callback.ID, "❌ Ruxsatni tekshirishda xatolik yuz berdi.")
+		return
+	}
+	if !allowed {
+		h.answerCallback(callback.ID, "⛔ Sizda bu amal uchun ruxsat yo'q.")
+		return
+	}
+
+	if _, err := h.tableService.AcknowledgeTableRequest(requestID, callback.From.ID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrTableRequestAcknowledged):
+			h.answerCallback(callback.ID, "ℹ️ Bu chaqiruvni boshqa xodim allaqachon qabul qilgan.")
+		case errors.Is(err, service.ErrTableRequestNotFound):
+			h.answerCallback(callback.ID, "❌ Chaqiruv topilmadi.")
+		default:
+			log.Printf("Stol chaqiruvini qabul qilishda xatolik: %v", err)
+			h.answerCallback(callback.ID, "❌ Xatolik yuz berdi, qaytadan urinib ko'ring.")
+		}
+		return
+	}
+	h.answerCallback(callback.ID, "✅ Qabul qilindi")
+}
+
+// answerCallback tugma bosilganini tasdiqlaydi (Telegram tugmadagi "yuklanmoqda" belgisini o'chiradi)
+func (h *BotHandler) answerCallback(callbackID, text string) {
+	if _, err := h.bot.Request(tgbotapi.NewCallback(callbackID, text)); err != nil {
+		log.Printf("Callback javobini yuborishda xatolik: %v", err)
+	}
+}
+
 func (h *BotHandler) HandleStats(chatID int64) {
 	count, err := h.userService.GetUserCount() // <-- GetUserCount endi error qaytaradi
 	if err != nil {
@@ -122,12 +281,19 @@ func (h *BotHandler) extractUserFromContact(update tgbotapi.Update) *models.User
 		languageCode = "uz"
 	}
 
+	// Telegram raqamni "998..." yoki "+998..." ko'rinishida yuborishi mumkin, E.164 ga keltiramiz.
+	// Noto'g'ri raqam o'zgarishsiz qoldiriladi va saqlashda rad etiladi.
+	phoneNumber, err := phone.Normalize(contact.PhoneNumber)
+	if err != nil {
+		phoneNumber = contact.PhoneNumber
+	}
+
 	return &models.User{
 		TelegramID:   telegramID, // <-- o'zgartirildi
 		FirstName:    firstName,
 		Username:     username,
 		LanguageCode: languageCode,
-		PhoneNumber:  contact.PhoneNumber,
+		PhoneNumber:  phoneNumber,
 		// LastName:     from.LastName, // Agar kerak bo'lsa
 	}
 }
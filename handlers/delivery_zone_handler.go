@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"amur/models"
+	"amur/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type DeliveryZoneHandler struct {
+	zoneService *service.DeliveryZoneService
+}
+
+func NewDeliveryZoneHandler(zoneService *service.DeliveryZoneService) *DeliveryZoneHandler {
+	return &DeliveryZoneHandler{zoneService: zoneService}
+}
+
+// sendErrorResponse yordamchi funksiyasi xato javobini yuborish uchun
+func (h *DeliveryZoneHandler) sendErrorResponse(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":   message,
+		"details": details,
+	})
+}
+
+// sendSuccessResponse yordamchi funksiyasi muvaffaqiyatli javobni yuborish uchun
+func (h *DeliveryZoneHandler) sendSuccessResponse(w http.ResponseWriter, message string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": message,
+		"data":    data,
+	})
+}
+
+// sendZoneError hudud servis xatolarini mos HTTP statusiga aylantiradi
+func (h *DeliveryZoneHandler) sendZoneError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrDeliveryZoneNotFound):
+		h.sendErrorResponse(w, http.StatusNotFound, message, err.Error())
+	case errors.Is(err, service.ErrDeliveryZoneNameRequired), errors.Is(err, service.ErrInvalidDeliveryZone),
+		errors.Is(err, service.ErrInvalidDeliveryLocation), errors.Is(err, service.ErrOutsideDeliveryZone):
+		h.sendErrorResponse(w, http.StatusBadRequest, message, err.Error())
+	case errors.Is(err, service.ErrDeliveryZoneNameTaken):
+		h.sendErrorResponse(w, http.StatusConflict, message, err.Error())
+	default:
+		h.sendErrorResponse(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// CheckDelivery manzilga yetkazib berish mumkinligini va narxi, minimal summasi, taxminiy vaqtini qaytaradi
+// GET /api/delivery-zones/check?latitude=41.31&longitude=69.24
+func (h *DeliveryZoneHandler) CheckDelivery(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	latitude, err := strconv.ParseFloat(query.Get("latitude"), 64)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri latitude qiymati", err.Error())
+		return
+	}
+	longitude, err := strconv.ParseFloat(query.Get("longitude"), 64)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri longitude qiymati", err.Error())
+		return
+	}
+
+	quote, err := h.zoneService.QuoteDelivery(latitude, longitude)
+	if err != nil {
+		h.sendZoneError(w, "Yetkazib berishni tekshirishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Bu manzilga yetkazib beriladi", quote)
+}
+
+// GetZones hududlar ro'yxati (ko'pburchaklari bilan)
+// GET /api/admin/delivery-zones?include_inactive=
+func (h *DeliveryZoneHandler) GetZones(w http.ResponseWriter, r *http.Request) {
+	includeInactive := true
+	if raw := r.URL.Query().Get("include_inactive"); raw != "" {
+		var err error
+		if includeInactive, err = strconv.ParseBool(raw); err != nil {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri include_inactive qiymati", err.Error())
+			return
+		}
+	}
+
+	zones, err := h.zoneService.GetZones(includeInactive)
+	if err != nil {
+		h.sendZoneError(w, "Hududlarni olishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Hududlar muvaffaqiyatli olindi", zones)
+}
+
+// GetZone bitta hudud
+// GET /api/admin/delivery-zones/{zoneID}
+func (h *DeliveryZoneHandler) GetZone(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := strconv.Atoi(mux.Vars(r)["zoneID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri hudud ID", err.Error())
+		return
+	}
+
+	zone, err := h.zoneService.GetZone(zoneID)
+	if err != nil {
+		h.sendZoneError(w, "Hududni olishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Hudud muvaffaqiyatli topildi", zone)
+}
+
+// CreateZone yangi hudud yaratadi; polygon - GeoJSON (Polygon, MultiPolygon, Feature yoki FeatureCollection)
+// POST /api/admin/delivery-zones {"zone_name": "Markaz", "polygon": {...}, "delivery_fee": 10000, "min_order_amount": 50000, "eta_minutes": 40}
+func (h *DeliveryZoneHandler) CreateZone(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateDeliveryZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	zone, err := h.zoneService.CreateZone(&req)
+	if err != nil {
+		h.sendZoneError(w, "Hududni yaratishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Hudud muvaffaqiyatli yaratildi", zone)
+}
+
+// UpdateZone hudud ma'lumotlarini o'zgartiradi (faqat yuborilgan maydonlar)
+// PUT /api/admin/delivery-zones/{zoneID}
+func (h *DeliveryZoneHandler) UpdateZone(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := strconv.Atoi(mux.Vars(r)["zoneID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri hudud ID", err.Error())
+		return
+	}
+	var req models.UpdateDeliveryZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	zone, err := h.zoneService.UpdateZone(zoneID, &req)
+	if err != nil {
+		h.sendZoneError(w, "Hududni yangilashda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Hudud muvaffaqiyatli yangilandi", zone)
+}
+
+// DeleteZone hududni o'chiradi
+// DELETE /api/admin/delivery-zones/{zoneID}
+func (h *DeliveryZoneHandler) DeleteZone(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := strconv.Atoi(mux.Vars(r)["zoneID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri hudud ID", err.Error())
+		return
+	}
+
+	if err := h.zoneService.DeleteZone(zoneID); err != nil {
+		h.sendZoneError(w, "Hududni o'chirishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Hudud muvaffaqiyatli o'chirildi", nil)
+}
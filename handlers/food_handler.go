@@ -4,7 +4,9 @@ import (
 	// Yangi middleware paketini import qilish
 	"amur/models"
 	"amur/service"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -294,6 +296,36 @@ func (h *FoodHandler) DeleteFood(w http.ResponseWriter, r *http.Request) {
 	h.sendSuccessResponse(w, "Ovqat muvaffaqiyatli o'chirildi", nil)
 }
 
+// PUT /api/foods/{id}/modifiers - Ovqatning modifikator guruhlarini almashtirish (menu.write ruxsati bilan)
+func (h *FoodHandler) SetFoodModifiers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri ID", err.Error())
+		return
+	}
+
+	var req models.SetFoodModifiersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	food, err := h.foodService.SetFoodModifiers(id, &req)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.sendErrorResponse(w, http.StatusNotFound, "Ovqat topilmadi", err.Error())
+		} else if errors.Is(err, service.ErrInvalidModifierGroups) {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Modifikatorlar noto'g'ri", err.Error())
+		} else {
+			h.sendErrorResponse(w, http.StatusInternalServerError, "Modifikatorlarni saqlashda xatolik", err.Error())
+		}
+		return
+	}
+
+	h.sendSuccessResponse(w, "Ovqat modifikatorlari muvaffaqiyatli yangilandi", food)
+}
+
 // GET /api/foods/category/{category} - Kategoriya bo'yicha ovqatlarni olish (Ruxsat talab qilinmaydi yoki oddiy user)
 func (h *FoodHandler) GetFoodsByCategory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
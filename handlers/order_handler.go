@@ -4,11 +4,12 @@ import (
 	"amur/middleware" // Yangi middleware paketini import qilish
 	"amur/models"
 	"amur/service"
-	"database/sql"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -45,17 +46,21 @@ func (h *OrderHandler) sendSuccessResponse(w http.ResponseWriter, message string
 
 // getTelegramIDFromContext yordamchi funksiya
 func (h *OrderHandler) getTelegramIDFromContext(w http.ResponseWriter, r *http.Request) (int64, bool) {
-	// OLD: userID, ok := r.Context().Value(middleware.UserContextKey).(int)
-	// YANGI: Contextdan Telegram IDni to'g'ridan-to'g'ri int64 sifatida olish
-	telegramID, ok := r.Context().Value(middleware.TelegramIDContextKey).(int64) // <-- Shu qatorni o'zgartiring!
+	principal, ok := h.getPrincipalFromContext(w, r)
 	if !ok {
-		h.sendErrorResponse(w, http.StatusInternalServerError, "Foydalanuvchi Telegram IDsi kontekstda topilmadi", "Autentifikatsiya xatoligi. AuthMiddleware to'g'ri ishlamagan bo'lishi mumkin.")
 		return 0, false
 	}
-	// Agar oldingi kodda userID ni int dan int64 ga o'girish shart bo'lgan bo'lsa,
-	// endi biz to'g'ridan-to'g'ri int64 qabul qilganimiz uchun bu qator endi kerak emas:
-	// telegramID := int64(userID)
-	return telegramID, true
+	return principal.TelegramID, true
+}
+
+// getPrincipalFromContext so'rov egasini (ID, rol, ruxsatlar) kontekstdan oladi
+func (h *OrderHandler) getPrincipalFromContext(w http.ResponseWriter, r *http.Request) (*models.Principal, bool) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Foydalanuvchi Telegram IDsi kontekstda topilmadi", "Autentifikatsiya xatoligi. AuthMiddleware to'g'ri ishlamagan bo'lishi mumkin.")
+		return nil, false
+	}
+	return principal, true
 }
 
 // Qolgan funksiyalar (CreateOrder, GetOrderDetails, GetUserOrders, UpdateOrderStatus, GetOrderStats, DeleteOrderAdmin) o'zgarishsiz qoladi.
@@ -86,9 +91,16 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 
 	order, err := h.orderService.CreateOrder(telegramID, &req) // 'order' deb o'zgartirdim, avvalgi kodda 'orderDetails' edi.
 	if err != nil {
-		if errors.Is(err, errors.New("savatcha bo'sh, buyurtma berish mumkin emas")) {
-			h.sendErrorResponse(w, http.StatusBadRequest, "Buyurtma yaratishda xatolik", err.Error())
-		} else if errors.Is(err, errors.New("yetkazib berish uchun lokatsiya ma'lumotlari (latitude va longitude) majburiy")) {
+		if errors.Is(err, service.ErrEmptyBasket) ||
+			errors.Is(err, service.ErrDeliveryLocationRequired) ||
+			errors.Is(err, service.ErrTableTokenRequired) ||
+			errors.Is(err, service.ErrInvalidDeliveryType) ||
+			errors.Is(err, service.ErrUnknownTable) ||
+			errors.Is(err, service.ErrTableInactive) ||
+			errors.Is(err, service.ErrInvalidModifiers) ||
+			errors.Is(err, service.ErrInvalidDeliveryLocation) ||
+			errors.Is(err, service.ErrOutsideDeliveryZone) ||
+			errors.Is(err, service.ErrDeliveryMinimumNotMet) {
 			h.sendErrorResponse(w, http.StatusBadRequest, "Buyurtma yaratishda xatolik", err.Error())
 		} else {
 			h.sendErrorResponse(w, http.StatusInternalServerError, "Buyurtma yaratishda xatolik", err.Error())
@@ -99,9 +111,15 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	h.sendSuccessResponse(w, "Buyurtma muvaffaqiyatli yaratildi", order)
 }
 
-// GetOrderDetails buyurtma ma'lumotlarini (elementlari bilan birga) olish
+// GetOrderDetails buyurtma ma'lumotlarini (elementlari bilan birga) olish.
+// Mijoz faqat o'z buyurtmasini, kuryer o'ziga biriktirilganini, orders.read.all ruxsati bor xodim esa istalganini ko'radi.
 // GET /api/orders/{orderID}
 func (h *OrderHandler) GetOrderDetails(w http.ResponseWriter, r *http.Request) {
+	principal, ok := h.getPrincipalFromContext(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	orderIDStr := vars["orderID"]
 	orderID, err := strconv.Atoi(orderIDStr)
@@ -110,9 +128,9 @@ func (h *OrderHandler) GetOrderDetails(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orderDetails, err := h.orderService.GetOrderDetails(orderID)
+	orderDetails, err := h.orderService.GetOrderDetailsFor(principal, orderID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, service.ErrOrderNotFound) {
 			h.sendErrorResponse(w, http.StatusNotFound, "Buyurtma topilmadi", err.Error())
 		} else {
 			h.sendErrorResponse(w, http.StatusInternalServerError, "Buyurtma ma'lumotlarini olishda xatolik", err.Error())
@@ -140,10 +158,14 @@ func (h *OrderHandler) GetUserOrders(w http.ResponseWriter, r *http.Request) {
 	h.sendSuccessResponse(w, "Foydalanuvchi buyurtmalari muvaffaqiyatli olindi", allOrderDetails)
 }
 
-// UpdateOrderStatus buyurtma holatini yangilash (Faqat admin uchun bo'lishi kerak)
-// PUT /api/orders/{orderID}/status
+// UpdateOrderStatus buyurtma holatini yangilash (orders.status.update ruxsati bilan)
+// PUT /api/orders/{orderID}/status  {"status": "preparing", "reason": "..."}
 func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
-	// Bu yerda ham admin roli tekshirilishi kerak.
+	actor, ok := h.getPrincipalFromContext(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	orderIDStr := vars["orderID"]
 	orderID, err := strconv.Atoi(orderIDStr)
@@ -152,36 +174,232 @@ func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var requestBody map[string]string
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+	var req models.UpdateOrderStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
 		return
 	}
-
-	newStatus, ok := requestBody["status"]
-	if !ok || newStatus == "" {
+	if req.Status == "" {
 		h.sendErrorResponse(w, http.StatusBadRequest, "Status maydoni majburiy", "JSON tanasida 'status' maydoni bo'lishi kerak.")
 		return
 	}
 
-	err = h.orderService.UpdateOrderStatus(orderID, newStatus)
+	order, err := h.orderService.UpdateOrderStatus(orderID, actor, req.Status, req.Reason)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			h.sendErrorResponse(w, http.StatusNotFound, "Buyurtma topilmadi", err.Error())
-		} else {
-			h.sendErrorResponse(w, http.StatusInternalServerError, "Buyurtma holatini yangilashda xatolik", err.Error())
+		h.sendOrderStatusError(w, "Buyurtma holatini yangilashda xatolik", err)
+		return
+	}
+
+	h.sendSuccessResponse(w, "Buyurtma holati muvaffaqiyatli yangilandi", order)
+}
+
+// CancelOrder mijozning o'z buyurtmasini bekor qilishi (faqat dastlabki holatlarda, sabab majburiy)
+// POST /api/orders/{orderID}/cancel  {"reason": "..."}
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	telegramID, ok := h.getTelegramIDFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	orderID, err := strconv.Atoi(mux.Vars(r)["orderID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri Buyurtma ID", err.Error())
+		return
+	}
+
+	var req models.CancelOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	order, err := h.orderService.CancelOrderByCustomer(orderID, telegramID, req.Reason)
+	if err != nil {
+		h.sendOrderStatusError(w, "Buyurtmani bekor qilishda xatolik", err)
+		return
+	}
+
+	h.sendSuccessResponse(w, "Buyurtma bekor qilindi", order)
+}
+
+// GetOrderStatusHistory buyurtma holatlari tarixini olish (buyurtmani ko'rish huquqi bo'lganlar uchun)
+// GET /api/orders/{orderID}/history
+func (h *OrderHandler) GetOrderStatusHistory(w http.ResponseWriter, r *http.Request) {
+	principal, ok := h.getPrincipalFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	orderID, err := strconv.Atoi(mux.Vars(r)["orderID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri Buyurtma ID", err.Error())
+		return
+	}
+
+	history, err := h.orderService.GetOrderStatusHistory(orderID, principal)
+	if err != nil {
+		h.sendOrderStatusError(w, "Buyurtma tarixini olishda xatolik", err)
+		return
+	}
+
+	h.sendSuccessResponse(w, "Buyurtma tarixi muvaffaqiyatli olindi", history)
+}
+
+// ListOrdersAdmin xodimlar uchun barcha buyurtmalar ro'yxati (orders.read.all ruxsati bilan)
+// GET /api/admin/orders?status=&delivery_type=&table_id=&telegram_id=&from=&to=&min_total=&sort=time|price&order=asc|desc&limit=&cursor=&include_deleted=
+// from/to RFC3339 yoki YYYY-MM-DD formatida; "to" sanasi kun oxirigacha kiradi.
+func (h *OrderHandler) ListOrdersAdmin(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.OrderListFilter{
+		Status:       query.Get("status"),
+		DeliveryType: query.Get("delivery_type"),
+		SortBy:       query.Get("sort"),
+	}
+
+	var err error
+	if filter.IncludeDeleted, err = parseIncludeDeleted(r); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri include_deleted qiymati", err.Error())
+		return
+	}
+	switch query.Get("order") {
+	case "", "desc":
+	case "asc":
+		filter.Ascending = true
+	default:
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri order qiymati", "order faqat 'asc' yoki 'desc' bo'lishi mumkin")
+		return
+	}
+	if raw := query.Get("limit"); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri limit qiymati", err.Error())
+			return
+		}
+	}
+	if raw := query.Get("table_id"); raw != "" {
+		tableID, err := strconv.Atoi(raw)
+		if err != nil {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri table_id qiymati", err.Error())
+			return
+		}
+		filter.TableID = &tableID
+	}
+	if raw := query.Get("telegram_id"); raw != "" {
+		telegramID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri telegram_id qiymati", err.Error())
+			return
+		}
+		filter.TelegramID = &telegramID
+	}
+	if raw := query.Get("min_total"); raw != "" {
+		minTotal, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri min_total qiymati", err.Error())
+			return
+		}
+		filter.MinTotal = &minTotal
+	}
+	if filter.From, err = parseTimeParam(query.Get("from"), false); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri from sanasi", err.Error())
+		return
+	}
+	if filter.To, err = parseTimeParam(query.Get("to"), true); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri to sanasi", err.Error())
+		return
+	}
+
+	page, err := h.orderService.ListOrders(filter, query.Get("cursor"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidOrderFilter) || errors.Is(err, service.ErrInvalidOrderStatus) ||
+			errors.Is(err, service.ErrInvalidDeliveryType) {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Buyurtmalar ro'yxatini olishda xatolik", err.Error())
+			return
 		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Buyurtmalar ro'yxatini olishda xatolik", err.Error())
 		return
 	}
 
-	h.sendSuccessResponse(w, "Buyurtma holati muvaffaqiyatli yangilandi", nil)
+	h.sendSuccessResponse(w, "Buyurtmalar ro'yxati muvaffaqiyatli olindi", page)
+}
+
+// parseTimeParam RFC3339 yoki YYYY-MM-DD formatidagi sanani o'qiydi (bo'sh bo'lsa nil).
+// endOfDay bo'lsa faqat sana berilganda keyingi kun boshi qaytadi, shunda shu kun ham oraliqqa kiradi.
+func parseTimeParam(raw string, endOfDay bool) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
 }
 
-// GetOrderStats buyurtma statistikasini olish (Faqat admin uchun)
-// GET /api/orders/stats
+// AssignCourier buyurtmani kuryerga biriktirish (orders.assign ruxsati bilan)
+// PUT /api/admin/orders/{orderID}/courier  {"courier_telegram_id": 123} (null - kuryerni olib tashlash)
+func (h *OrderHandler) AssignCourier(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.Atoi(mux.Vars(r)["orderID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri Buyurtma ID", err.Error())
+		return
+	}
+
+	var req models.AssignCourierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	order, err := h.orderService.AssignCourier(orderID, req.CourierTelegramID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCourier) {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Kuryerni biriktirishda xatolik", err.Error())
+			return
+		}
+		h.sendOrderStatusError(w, "Kuryerni biriktirishda xatolik", err)
+		return
+	}
+
+	h.sendSuccessResponse(w, "Kuryer muvaffaqiyatli biriktirildi", order)
+}
+
+// GetOrderStatuses barcha holat kodlari, ularning nomlari va yetkazib berish turlari bo'yicha o'tishlar
+// GET /api/order-statuses
+func (h *OrderHandler) GetOrderStatuses(w http.ResponseWriter, r *http.Request) {
+	h.sendSuccessResponse(w, "Buyurtma holatlari muvaffaqiyatli olindi", h.orderService.GetOrderStatuses())
+}
+
+// sendOrderStatusError holat bilan bog'liq servis xatolarini mos HTTP statusiga aylantiradi
+func (h *OrderHandler) sendOrderStatusError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		h.sendErrorResponse(w, http.StatusNotFound, "Buyurtma topilmadi", err.Error())
+	case errors.Is(err, service.ErrInvalidOrderStatus), errors.Is(err, service.ErrStatusReasonRequired),
+		errors.Is(err, service.ErrStatusReasonTooLong):
+		h.sendErrorResponse(w, http.StatusBadRequest, message, err.Error())
+	case errors.Is(err, service.ErrInvalidStatusTransition), errors.Is(err, service.ErrCancellationNotAllowed):
+		h.sendErrorResponse(w, http.StatusConflict, message, err.Error())
+	default:
+		h.sendErrorResponse(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// GetOrderStats buyurtma statistikasini olish (stats.read ruxsati bilan)
+// GET /api/orders/stats?include_deleted=true (o'chirilgan buyurtmalar sukut bo'yicha hisoblanmaydi)
 func (h *OrderHandler) GetOrderStats(w http.ResponseWriter, r *http.Request) {
-	// Bu yerda ham admin roli tekshirilishi kerak.
-	count, err := h.orderService.GetOrderStats()
+	includeDeleted, err := parseIncludeDeleted(r)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri include_deleted qiymati", err.Error())
+		return
+	}
+
+	count, err := h.orderService.GetOrderStats(includeDeleted)
 	if err != nil {
 		h.sendErrorResponse(w, http.StatusInternalServerError, "Buyurtma statistikasini olishda xatolik", err.Error())
 		return
@@ -190,11 +408,14 @@ func (h *OrderHandler) GetOrderStats(w http.ResponseWriter, r *http.Request) {
 	h.sendSuccessResponse(w, "Buyurtma statistikasi muvaffaqiyatli olindi", map[string]int{"total_orders": count})
 }
 
-// DeleteOrderAdmin buyurtmani o'chirish (FAQAT ADMIN UCHUN)
-// DELETE /api/admin/orders/{orderID}
+// DeleteOrderAdmin buyurtmani o'chirilgan deb belgilash (orders.delete ruxsati bilan).
+// Buyurtma bazadan o'chirilmaydi, faqat mijoz ro'yxatlari va statistikadan chiqariladi.
+// DELETE /api/admin/orders/{orderID}  {"reason": "..."} (tana ixtiyoriy)
 func (h *OrderHandler) DeleteOrderAdmin(w http.ResponseWriter, r *http.Request) {
-	// Bu funksiya uchun avtorizatsiya AuthMiddleware va RoleMiddleware orqali amalga oshiriladi.
-	// Handler ichida qayta tekshirish shart emas, chunki agar middleware ishlamagan bo'lsa, bu yerga yetib kelmaydi.
+	actorTelegramID, ok := h.getTelegramIDFromContext(w, r)
+	if !ok {
+		return
+	}
 
 	vars := mux.Vars(r)
 	orderIDStr := vars["orderID"]
@@ -204,15 +425,26 @@ func (h *OrderHandler) DeleteOrderAdmin(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = h.orderService.DeleteOrderAdmin(orderID)
+	var req models.DeleteOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	err = h.orderService.DeleteOrderAdmin(orderID, actorTelegramID, req.Reason)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			h.sendErrorResponse(w, http.StatusNotFound, "Buyurtma topilmadi", err.Error())
-		} else {
-			h.sendErrorResponse(w, http.StatusInternalServerError, "Buyurtmani o'chirishda xatolik", err.Error())
-		}
+		h.sendOrderStatusError(w, "Buyurtmani o'chirishda xatolik", err)
 		return
 	}
 
 	h.sendSuccessResponse(w, "Buyurtma muvaffaqiyatli o'chirildi", nil)
 }
+
+// parseIncludeDeleted ?include_deleted= parametrini o'qiydi (bo'lmasa false)
+func parseIncludeDeleted(r *http.Request) (bool, error) {
+	raw := r.URL.Query().Get("include_deleted")
+	if raw == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(raw)
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"amur/models"
+	"amur/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type TableHandler struct {
+	tableService *service.TableService
+}
+
+func NewTableHandler(tableService *service.TableService) *TableHandler {
+	return &TableHandler{tableService: tableService}
+}
+
+// sendErrorResponse yordamchi funksiyasi xato javobini yuborish uchun
+func (h *TableHandler) sendErrorResponse(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":   message,
+		"details": details,
+	})
+}
+
+// sendSuccessResponse yordamchi funksiyasi muvaffaqiyatli javobni yuborish uchun
+func (h *TableHandler) sendSuccessResponse(w http.ResponseWriter, message string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": message,
+		"data":    data,
+	})
+}
+
+// sendTableError zal/stol servis xatolarini mos HTTP statusiga aylantiradi
+func (h *TableHandler) sendTableError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrHallNotFound), errors.Is(err, service.ErrTableNotFound):
+		h.sendErrorResponse(w, http.StatusNotFound, message, err.Error())
+	case errors.Is(err, service.ErrHallNameRequired), errors.Is(err, service.ErrTableNameRequired),
+		errors.Is(err, service.ErrInvalidCapacity):
+		h.sendErrorResponse(w, http.StatusBadRequest, message, err.Error())
+	case errors.Is(err, service.ErrHallNameTaken), errors.Is(err, service.ErrTableNameTaken),
+		errors.Is(err, service.ErrTableHasOrders):
+		h.sendErrorResponse(w, http.StatusConflict, message, err.Error())
+	default:
+		h.sendErrorResponse(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// --- Zallar ---
+
+// GetHalls barcha zallar ro'yxati
+// GET /api/admin/halls
+func (h *TableHandler) GetHalls(w http.ResponseWriter, r *http.Request) {
+	halls, err := h.tableService.GetHalls()
+	if err != nil {
+		h.sendTableError(w, "Zallarni olishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Zallar muvaffaqiyatli olindi", halls)
+}
+
+// CreateHall yangi zal yaratadi
+// POST /api/admin/halls {"hall_name": "Terassa"}
+func (h *TableHandler) CreateHall(w http.ResponseWriter, r *http.Request) {
+	var req models.HallRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	hall, err := h.tableService.CreateHall(&req)
+	if err != nil {
+		h.sendTableError(w, "Zalni yaratishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Zal muvaffaqiyatli yaratildi", hall)
+}
+
+// UpdateHall zal nomini o'zgartiradi
+// PUT /api/admin/halls/{hallID}
+func (h *TableHandler) UpdateHall(w http.ResponseWriter, r *http.Request) {
+	hallID, err := strconv.Atoi(mux.Vars(r)["hallID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri zal ID", err.Error())
+		return
+	}
+	var req models.HallRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	hall, err := h.tableService.RenameHall(hallID, &req)
+	if err != nil {
+		h.sendTableError(w, "Zalni yangilashda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Zal muvaffaqiyatli yangilandi", hall)
+}
+
+// DeleteHall zalni o'chiradi (stollari zalsiz qoladi)
+// DELETE /api/admin/halls/{hallID}
+func (h *TableHandler) DeleteHall(w http.ResponseWriter, r *http.Request) {
+	hallID, err := strconv.Atoi(mux.Vars(r)["hallID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri zal ID", err.Error())
+		return
+	}
+
+	if err := h.tableService.DeleteHall(hallID); err != nil {
+		h.sendTableError(w, "Zalni o'chirishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Zal muvaffaqiyatli o'chirildi", nil)
+}
+
+// --- Stollar ---
+
+// GetTables stollar ro'yxati
+// GET /api/admin/tables?hall_id=&include_inactive=
+func (h *TableHandler) GetTables(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var hallID *int
+	if raw := query.Get("hall_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri hall_id qiymati", err.Error())
+			return
+		}
+		hallID = &id
+	}
+	includeInactive := true
+	if raw := query.Get("include_inactive"); raw != "" {
+		var err error
+		if includeInactive, err = strconv.ParseBool(raw); err != nil {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri include_inactive qiymati", err.Error())
+			return
+		}
+	}
+
+	tables, err := h.tableService.GetTables(hallID, includeInactive)
+	if err != nil {
+		h.sendTableError(w, "Stollarni olishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Stollar muvaffaqiyatli olindi", tables)
+}
+
+// GetTable bitta stol
+// GET /api/admin/tables/{tableID}
+func (h *TableHandler) GetTable(w http.ResponseWriter, r *http.Request) {
+	tableID, err := strconv.Atoi(mux.Vars(r)["tableID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri stol ID", err.Error())
+		return
+	}
+
+	table, err := h.tableService.GetTable(tableID)
+	if err != nil {
+		h.sendTableError(w, "Stolni olishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Stol muvaffaqiyatli topildi", table)
+}
+
+// CreateTable yangi stol yaratadi; QR token server tomonidan beriladi
+// POST /api/admin/tables {"table_name": "Terassa-5", "hall_id": 3, "capacity": 6}
+func (h *TableHandler) CreateTable(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateTableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	table, err := h.tableService.CreateTable(&req)
+	if err != nil {
+		h.sendTableError(w, "Stolni yaratishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Stol muvaffaqiyatli yaratildi", table)
+}
+
+// UpdateTable stol ma'lumotlarini o'zgartiradi (faqat yuborilgan maydonlar)
+// PUT /api/admin/tables/{tableID}
+func (h *TableHandler) UpdateTable(w http.ResponseWriter, r *http.Request) {
+	tableID, err := strconv.Atoi(mux.Vars(r)["tableID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri stol ID", err.Error())
+		return
+	}
+	var req models.UpdateTableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	table, err := h.tableService.UpdateTable(tableID, &req)
+	if err != nil {
+		h.sendTableError(w, "Stolni yangilashda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Stol muvaffaqiyatli yangilandi", table)
+}
+
+// RegenerateTableToken stolga yangi QR token beradi (eski QR kod ishlamay qoladi)
+// POST /api/admin/tables/{tableID}/token
+func (h *TableHandler) RegenerateTableToken(w http.ResponseWriter, r *http.Request) {
+	tableID, err := strconv.Atoi(mux.Vars(r)["tableID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri stol ID", err.Error())
+		return
+	}
+
+	table, err := h.tableService.RegenerateToken(tableID)
+	if err != nil {
+		h.sendTableError(w, "QR tokenni yangilashda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "QR token muvaffaqiyatli yangilandi", table)
+}
+
+// DeleteTable stolni o'chiradi (buyurtmalari bo'lsa 409)
+// DELETE /api/admin/tables/{tableID}
+func (h *TableHandler) DeleteTable(w http.ResponseWriter, r *http.Request) {
+	tableID, err := strconv.Atoi(mux.Vars(r)["tableID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri stol ID", err.Error())
+		return
+	}
+
+	if err := h.tableService.DeleteTable(tableID); err != nil {
+		h.sendTableError(w, "Stolni o'chirishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Stol muvaffaqiyatli o'chirildi", nil)
+}
+
+// sendQRFile tayyor QR faylni yuklab olish uchun yuboradi
+func (h *TableHandler) sendQRFile(w http.ResponseWriter, file *service.QRFile) {
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(file.Data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(file.Data)
+}
+
+// sendQRError QR kod yaratishdagi xatolarni mos HTTP statusiga aylantiradi
+func (h *TableHandler) sendQRError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidQRFormat), errors.Is(err, service.ErrInvalidQRSize),
+		errors.Is(err, service.ErrHallHasNoTables):
+		h.sendErrorResponse(w, http.StatusBadRequest, "QR kodni yaratishda xatolik", err.Error())
+	case errors.Is(err, service.ErrMiniAppURLNotConfigured):
+		h.sendErrorResponse(w, http.StatusServiceUnavailable, "QR kodni yaratishda xatolik", err.Error())
+	default:
+		h.sendTableError(w, "QR kodni yaratishda xatolik", err)
+	}
+}
+
+// GetTableQRCode stol QR kodini PNG yoki SVG ko'rinishida beradi (Mini App havolasi + stol tokeni)
+// GET /api/admin/tables/{tableID}/qr?format=png|svg&size=512
+func (h *TableHandler) GetTableQRCode(w http.ResponseWriter, r *http.Request) {
+	tableID, err := strconv.Atoi(mux.Vars(r)["tableID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri stol ID", err.Error())
+		return
+	}
+	size := 0
+	if raw := r.URL.Query().Get("size"); raw != "" {
+		if size, err = strconv.Atoi(raw); err != nil {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri size qiymati", err.Error())
+			return
+		}
+	}
+
+	file, err := h.tableService.TableQRCode(tableID, r.URL.Query().Get("format"), size)
+	if err != nil {
+		h.sendQRError(w, err)
+		return
+	}
+	h.sendQRFile(w, file)
+}
+
+// GetHallQRSheet zaldagi barcha faol stollar QR kodlarini nomlari bilan chop etish uchun beradi
+// GET /api/admin/halls/{hallID}/qr?format=pdf|zip
+func (h *TableHandler) GetHallQRSheet(w http.ResponseWriter, r *http.Request) {
+	hallID, err := strconv.Atoi(mux.Vars(r)["hallID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri zal ID", err.Error())
+		return
+	}
+
+	file, err := h.tableService.HallQRSheet(hallID, r.URL.Query().Get("format"))
+	if err != nil {
+		h.sendQRError(w, err)
+		return
+	}
+	h.sendQRFile(w, file)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"amur/middleware"
+	"amur/models"
+	"amur/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// sendTableRequestError stol chaqiruvlari bilan bog'liq servis xatolarini mos HTTP statusiga aylantiradi
+func (h *TableHandler) sendTableRequestError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrTableRequestNotFound):
+		h.sendErrorResponse(w, http.StatusNotFound, message, err.Error())
+	case errors.Is(err, service.ErrTableTokenRequired), errors.Is(err, service.ErrUnknownTable),
+		errors.Is(err, service.ErrTableInactive), errors.Is(err, service.ErrInvalidTableRequestType):
+		h.sendErrorResponse(w, http.StatusBadRequest, message, err.Error())
+	case errors.Is(err, service.ErrTableRequestAcknowledged):
+		h.sendErrorResponse(w, http.StatusConflict, message, err.Error())
+	default:
+		h.sendErrorResponse(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// CreateTableRequest mehmon stoldan ofitsiantni yoki hisobni chaqiradi. Qabul qilinmagan chaqiruv qayta bosilsa
+// xodimlarga yangi xabar yuborilmaydi, javobda "duplicate": true qaytadi.
+// POST /api/tables/requests {"token": "<QR token>", "type": "call_waiter" | "bring_bill"}
+func (h *TableHandler) CreateTableRequest(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Foydalanuvchi Telegram IDsi kontekstda topilmadi", "Autentifikatsiya xatoligi. AuthMiddleware to'g'ri ishlamagan bo'lishi mumkin.")
+		return
+	}
+	var req models.PlaceTableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	result, err := h.tableService.CreateTableRequest(req.Token, req.Type, principal.TelegramID)
+	if err != nil {
+		h.sendTableRequestError(w, "Chaqiruvni yuborishda xatolik", err)
+		return
+	}
+	message := "Chaqiruv xodimlarga yuborildi"
+	if result.Duplicate {
+		message = "Chaqiruv allaqachon yuborilgan, xodim tez orada keladi"
+	}
+	h.sendSuccessResponse(w, message, result)
+}
+
+// GetPendingTableRequests ofitsiantlar uchun hali qabul qilinmagan chaqiruvlar
+// GET /api/admin/table-requests
+func (h *TableHandler) GetPendingTableRequests(w http.ResponseWriter, r *http.Request) {
+	requests, err := h.tableService.GetPendingTableRequests()
+	if err != nil {
+		h.sendTableRequestError(w, "Stol chaqiruvlarini olishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Stol chaqiruvlari muvaffaqiyatli olindi", requests)
+}
+
+// AcknowledgeTableRequest xodim chaqiruvni qabul qiladi (botdagi "Boryapman" tugmasi bilan bir xil)
+// POST /api/admin/table-requests/{requestID}/acknowledge
+func (h *TableHandler) AcknowledgeTableRequest(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Foydalanuvchi Telegram IDsi kontekstda topilmadi", "Autentifikatsiya xatoligi. AuthMiddleware to'g'ri ishlamagan bo'lishi mumkin.")
+		return
+	}
+	requestID, err := strconv.Atoi(mux.Vars(r)["requestID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri chaqiruv ID", err.Error())
+		return
+	}
+
+	request, err := h.tableService.AcknowledgeTableRequest(requestID, principal.TelegramID)
+	if err != nil {
+		h.sendTableRequestError(w, "Chaqiruvni qabul qilishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Chaqiruv qabul qilindi", request)
+}
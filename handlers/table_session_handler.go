@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"amur/models"
+	"amur/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// sendTableSessionError stol hisobi bilan bog'liq servis xatolarini mos HTTP statusiga aylantiradi
+func (h *OrderHandler) sendTableSessionError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrTableSessionNotFound):
+		h.sendErrorResponse(w, http.StatusNotFound, message, err.Error())
+	case errors.Is(err, service.ErrTableTokenRequired), errors.Is(err, service.ErrUnknownTable),
+		errors.Is(err, service.ErrTableInactive):
+		h.sendErrorResponse(w, http.StatusBadRequest, message, err.Error())
+	case errors.Is(err, service.ErrTableSessionClosed), errors.Is(err, service.ErrTableSessionHasActiveOrders):
+		h.sendErrorResponse(w, http.StatusConflict, message, err.Error())
+	default:
+		h.sendErrorResponse(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// ScanTable mehmon stol QR kodini skanerlaganda stolning ochiq hisobini ochadi yoki unga qo'shiladi
+// POST /api/tables/scan {"token": "<QR token>"}
+func (h *OrderHandler) ScanTable(w http.ResponseWriter, r *http.Request) {
+	telegramID, ok := h.getTelegramIDFromContext(w, r)
+	if !ok {
+		return
+	}
+	var req models.ScanTableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	bill, err := h.orderService.ScanTable(req.Token, telegramID)
+	if err != nil {
+		h.sendTableSessionError(w, "Stol hisobini ochishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Stol hisobi muvaffaqiyatli olindi", bill)
+}
+
+// GetTableSessionBill stolning umumiy hisobi (barcha mehmonlar buyurtmalari bilan)
+// GET /api/table-sessions/{sessionID}/bill
+func (h *OrderHandler) GetTableSessionBill(w http.ResponseWriter, r *http.Request) {
+	principal, ok := h.getPrincipalFromContext(w, r)
+	if !ok {
+		return
+	}
+	sessionID, err := strconv.Atoi(mux.Vars(r)["sessionID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri hisob ID", err.Error())
+		return
+	}
+
+	bill, err := h.orderService.GetTableSessionBill(sessionID, principal)
+	if err != nil {
+		h.sendTableSessionError(w, "Stol hisobini olishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Stol hisobi muvaffaqiyatli olindi", bill)
+}
+
+// GetOpenTableSessions ofitsiantlar uchun ochiq stol hisoblari ro'yxati
+// GET /api/admin/table-sessions
+func (h *OrderHandler) GetOpenTableSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.orderService.GetOpenTableSessions()
+	if err != nil {
+		h.sendTableSessionError(w, "Ochiq stol hisoblarini olishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Ochiq stol hisoblari muvaffaqiyatli olindi", sessions)
+}
+
+// CloseTableSession ofitsiant stol hisobini yopadi
+// POST /api/admin/table-sessions/{sessionID}/close
+func (h *OrderHandler) CloseTableSession(w http.ResponseWriter, r *http.Request) {
+	principal, ok := h.getPrincipalFromContext(w, r)
+	if !ok {
+		return
+	}
+	sessionID, err := strconv.Atoi(mux.Vars(r)["sessionID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri hisob ID", err.Error())
+		return
+	}
+
+	bill, err := h.orderService.CloseTableSession(sessionID, principal)
+	if err != nil {
+		h.sendTableSessionError(w, "Stol hisobini yopishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Stol hisobi muvaffaqiyatli yopildi", bill)
+}
+
+// SplitTableSessionBill stol hisobini mehmonlar, teng qismlar yoki buyurtma qatorlari bo'yicha bo'ladi
+// POST /api/table-sessions/{sessionID}/split {"mode": "guest" | "even", "parts": 3 | "items", "assignments": [...]}
+func (h *OrderHandler) SplitTableSessionBill(w http.ResponseWriter, r *http.Request) {
+	principal, ok := h.getPrincipalFromContext(w, r)
+	if !ok {
+		return
+	}
+	sessionID, err := strconv.Atoi(mux.Vars(r)["sessionID"])
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri hisob ID", err.Error())
+		return
+	}
+	var req models.BillSplitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	split, err := h.orderService.SplitTableSessionBill(sessionID, principal, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidBillSplit) {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Hisobni bo'lishda xatolik", err.Error())
+			return
+		}
+		h.sendTableSessionError(w, "Hisobni bo'lishda xatolik", err)
+		return
+	}
+	h.sendSuccessResponse(w, "Hisob muvaffaqiyatli bo'lindi", split)
+}
@@ -1,22 +1,35 @@
 package handlers
 
 import (
+	"amur/middleware"
 	"amur/models"
 	"amur/service"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"math"
+	"net"
 	"net/http"
-	// "strconv" // Endi kerak emas
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
 )
 
 type UserHandler struct {
-	userService *service.UserService
+	userService       *service.UserService
+	sessionService    *service.SessionService
+	permissionService *service.PermissionService
 }
 
-func NewUserHandler(userService *service.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService *service.UserService, sessionService *service.SessionService, permissionService *service.PermissionService) *UserHandler {
+	return &UserHandler{
+		userService:       userService,
+		sessionService:    sessionService,
+		permissionService: permissionService,
+	}
 }
 
 // sendErrorResponse yordamchi funksiyasi
@@ -59,13 +72,20 @@ func (h *UserHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		TelegramID: req.UserID,
 		Username:   req.Username,
 		FirstName:  req.FirstName,
-		// PhoneNumber maydoni CreateUserRequestda yo'q, agar kerak bo'lsa uni ham qo'shish kerak.
-		// Hozirda Phone kontakt yuborishda bot orqali o'rnatiladi.
+		// Bu yerdagi raqam tasdiqlanmagan, shuning uchun boshqa hisobdagi raqamni egallab ololmaydi.
+		// Raqamni boshqa hisobdan o'tkazish faqat bot kontakti orqali mumkin.
+		PhoneNumber: req.PhoneNumber,
 	}
 
 	registeredUser, err := h.userService.SaveOrUpdateUser(userToRegister)
 	if err != nil {
-		h.sendErrorResponse(w, http.StatusInternalServerError, "Foydalanuvchini ro'yxatdan o'tkazishda/yangilashda xatolik", err.Error())
+		if errors.Is(err, service.ErrInvalidPhoneNumber) {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Telefon raqami noto'g'ri", err.Error())
+		} else if errors.Is(err, service.ErrPhoneNumberTaken) {
+			h.sendErrorResponse(w, http.StatusConflict, "Telefon raqami band", err.Error())
+		} else {
+			h.sendErrorResponse(w, http.StatusInternalServerError, "Foydalanuvchini ro'yxatdan o'tkazishda/yangilashda xatolik", err.Error())
+		}
 		return
 	}
 
@@ -113,23 +133,193 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		h.sendErrorResponse(w, http.StatusBadRequest, "Kod majburiy", "Kod bo'sh bo'lmasligi kerak.")
 		return
 	}
-	if len(req.Code) != 4 { // Kod uzunligini tekshirish
-		h.sendErrorResponse(w, http.StatusBadRequest, "Kod noto'g'ri", "Kod 4 raqamdan iborat bo'lishi kerak.")
+	if len(req.Code) != service.LoginCodeLength { // Kod uzunligini tekshirish
+		h.sendErrorResponse(w, http.StatusBadRequest, "Kod noto'g'ri", fmt.Sprintf("Kod %d raqamdan iborat bo'lishi kerak.", service.LoginCodeLength))
 		return
 	}
 
 	// UserService dagi yangi Login funksiyasini chaqiramiz
-	token, err := h.userService.Login(req.PhoneNumber, req.Code)
+	tokens, err := h.userService.Login(req.PhoneNumber, req.Code, clientInfo(r))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) { // Agar foydalanuvchi topilmasa
+		var lockedErr *service.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+			h.sendErrorResponse(w, http.StatusTooManyRequests, "Kirish vaqtincha bloklangan", err.Error())
+		} else if errors.Is(err, service.ErrInvalidPhoneNumber) {
+			h.sendErrorResponse(w, http.StatusBadRequest, "Telefon raqami noto'g'ri", err.Error())
+		} else if errors.Is(err, sql.ErrNoRows) { // Agar foydalanuvchi topilmasa
 			h.sendErrorResponse(w, http.StatusUnauthorized, "Autentifikatsiya muvaffaqiyatsiz", "Foydalanuvchi topilmadi. Iltimos, avval ro'yxatdan o'ting.")
-		} else if err.Error() == "kod noto'g'ri, iltimos, tekshirib qaytadan urinib ko'ring" {
+		} else if errors.Is(err, service.ErrInvalidLoginCode) {
 			h.sendErrorResponse(w, http.StatusUnauthorized, "Kod noto'g'ri", err.Error())
+		} else if errors.Is(err, service.ErrLoginCodeExpired) {
+			h.sendErrorResponse(w, http.StatusUnauthorized, "Kod eskirgan", err.Error())
+		} else {
+			h.sendErrorResponse(w, http.StatusInternalServerError, "Tizimga kirishda xatolik", err.Error())
+		}
+		return
+	}
+
+	h.sendSuccessResponse(w, "Muvaffaqiyatli tizimga kirish", tokens)
+}
+
+// TelegramLogin Telegram Mini App initData yoki Login Widget ma'lumotlari orqali tizimga kiritadi
+// POST /api/auth/telegram
+func (h *UserHandler) TelegramLogin(w http.ResponseWriter, r *http.Request) {
+	var req models.TelegramAuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+
+	tokens, err := h.userService.LoginWithTelegram(&req, clientInfo(r))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTelegramAuth) {
+			h.sendErrorResponse(w, http.StatusUnauthorized, "Telegram orqali kirish muvaffaqiyatsiz", err.Error())
 		} else {
 			h.sendErrorResponse(w, http.StatusInternalServerError, "Tizimga kirishda xatolik", err.Error())
 		}
 		return
 	}
 
-	h.sendSuccessResponse(w, "Muvaffaqiyatli tizimga kirish", models.LoginResponse{Token: token})
+	h.sendSuccessResponse(w, "Muvaffaqiyatli tizimga kirish", tokens)
+}
+
+// RefreshToken refresh token orqali yangi access token beradi. Refresh token har safar almashtiriladi.
+// POST /api/token/refresh
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+	if req.RefreshToken == "" {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Refresh token majburiy", "refresh_token bo'sh bo'lmasligi kerak.")
+		return
+	}
+
+	tokens, err := h.sessionService.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+			h.sendErrorResponse(w, http.StatusUnauthorized, "Tokenni yangilab bo'lmadi", err.Error())
+		} else {
+			h.sendErrorResponse(w, http.StatusInternalServerError, "Tokenni yangilashda xatolik", err.Error())
+		}
+		return
+	}
+
+	h.sendSuccessResponse(w, "Token muvaffaqiyatli yangilandi", tokens)
+}
+
+// Logout joriy sessiyani bekor qiladi, uning access va refresh tokenlari endi ishlamaydi
+// POST /api/logout
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Sessiya kontekstda topilmadi", "Autentifikatsiya xatoligi. AuthMiddleware to'g'ri ishlamagan bo'lishi mumkin.")
+		return
+	}
+
+	if err := h.sessionService.Logout(principal.SessionID); err != nil {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Tizimdan chiqishda xatolik", err.Error())
+		return
+	}
+
+	h.sendSuccessResponse(w, "Muvaffaqiyatli tizimdan chiqildi", nil)
+}
+
+// RevokeUserSessions foydalanuvchining barcha sessiyalarini bekor qiladi (admin funksiyasi)
+// DELETE /api/admin/users/{telegramID}/sessions
+func (h *UserHandler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	telegramID, err := strconv.ParseInt(mux.Vars(r)["telegramID"], 10, 64)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri Telegram ID", err.Error())
+		return
+	}
+
+	count, err := h.sessionService.RevokeAllForUser(telegramID, "admin tomonidan bekor qilindi")
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Sessiyalarni bekor qilishda xatolik", err.Error())
+		return
+	}
+
+	h.sendSuccessResponse(w, "Foydalanuvchi sessiyalari bekor qilindi", map[string]int{"revoked_sessions": count})
+}
+
+// UpdateUserRole foydalanuvchiga yangi rol beradi (users.roles.manage ruxsati kerak)
+// PUT /api/admin/users/{telegramID}/role
+func (h *UserHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Foydalanuvchi kontekstda topilmadi", "Autentifikatsiya xatoligi. AuthMiddleware to'g'ri ishlamagan bo'lishi mumkin.")
+		return
+	}
+
+	telegramID, err := strconv.ParseInt(mux.Vars(r)["telegramID"], 10, 64)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Noto'g'ri Telegram ID", err.Error())
+		return
+	}
+
+	var req models.UpdateUserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "So'rov tanasini tahlil qilishda xatolik", err.Error())
+		return
+	}
+	if req.Role == "" {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Rol majburiy", "JSON tanasida 'role' maydoni bo'lishi kerak.")
+		return
+	}
+
+	user, err := h.permissionService.AssignRole(principal.TelegramID, telegramID, req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnknownRole), errors.Is(err, service.ErrLastSuperAdmin):
+			h.sendErrorResponse(w, http.StatusBadRequest, "Rolni o'zgartirib bo'lmadi", err.Error())
+		case errors.Is(err, service.ErrUserNotFoundForRole):
+			h.sendErrorResponse(w, http.StatusNotFound, "Foydalanuvchi topilmadi", err.Error())
+		default:
+			h.sendErrorResponse(w, http.StatusInternalServerError, "Rolni o'zgartirishda xatolik", err.Error())
+		}
+		return
+	}
+
+	h.sendSuccessResponse(w, "Foydalanuvchi roli muvaffaqiyatli o'zgartirildi", user)
+}
+
+// GetRoles barcha rollar va ularning ruxsatlarini qaytaradi
+// GET /api/admin/roles
+func (h *UserHandler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.permissionService.ListRoles()
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Rollarni olishda xatolik", err.Error())
+		return
+	}
+	h.sendSuccessResponse(w, "Rollar muvaffaqiyatli olindi", roles)
+}
+
+// clientInfo so'rovdan mijoz IP manzili va User-Agent qiymatini oladi
+func clientInfo(r *http.Request) models.ClientInfo {
+	return models.ClientInfo{
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+}
+
+// clientIP so'rov yuborgan mijozning IP manzilini aniqlaydi.
+// Proxy sarlavhalariga faqat so'rov lokal reverse proxy orqali kelganda ishoniladi.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if ip := net.ParseIP(host); ip != nil && (ip.IsLoopback() || ip.IsPrivate()) {
+		if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+			return realIP
+		}
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+	return host
 }
@@ -4,6 +4,7 @@ import (
 	"amur/config"
 	"amur/database"
 	"amur/handlers"
+	"amur/middleware"
 	"amur/repository"
 	"amur/routes"
 	"amur/service"
@@ -14,6 +15,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -54,6 +56,76 @@ func createDatabaseIfNotExists(cfg *config.Config) {
 // 	return err != nil && (err.Error() == fmt.Sprintf("pq: database \"%s\" already exists", dbName))
 // }
 
+// runMigrateCommand migrate buyrug'ini bajaradi: up, down [N] (standart 1) yoki status
+func runMigrateCommand(connStr string, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Foydalanish: migrate up | down [N] | status")
+	}
+
+	db, err := database.Connect(connStr)
+	if err != nil {
+		log.Fatalf("PostgreSQL ma'lumotlar bazasiga ulanishda xatolik: %v", err)
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := db.MigrateUp()
+		if err != nil {
+			log.Fatalf("Migratsiyalarni qo'llashda xatolik: %v", err)
+		}
+		log.Printf("✅ %d ta migratsiya qo'llanildi.", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				log.Fatalf("Noto'g'ri qadamlar soni: %s", args[1])
+			}
+		}
+		reverted, err := db.MigrateDown(steps)
+		if err != nil {
+			log.Fatalf("Migratsiyalarni bekor qilishda xatolik: %v", err)
+		}
+		log.Printf("✅ %d ta migratsiya bekor qilindi.", reverted)
+	case "status":
+		statuses, err := db.MigrationStatus()
+		if err != nil {
+			log.Fatalf("Migratsiyalar holatini olishda xatolik: %v", err)
+		}
+		for _, status := range statuses {
+			state := "kutilmoqda"
+			if status.AppliedAt != nil {
+				state = "qo'llanilgan " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", status.Version, status.Name, state)
+		}
+	default:
+		log.Fatalf("Noma'lum migrate buyrug'i: %s (up, down, status)", args[0])
+	}
+}
+
+// runImportTablesCommand stollarni table.json formatidagi fayldan bazaga bir martalik yuklaydi (standart: table.json)
+func runImportTablesCommand(connStr string, args []string) {
+	filename := "table.json"
+	if len(args) > 0 {
+		filename = args[0]
+	}
+
+	db, err := database.NewDatabase(connStr)
+	if err != nil {
+		log.Fatalf("PostgreSQL ma'lumotlar bazasiga ulanishda xatolik: %v", err)
+	}
+	defer db.Close()
+
+	tableService := service.NewTableService(repository.NewUnitOfWork(db.GetDB()), repository.NewTableRepository(db.GetDB()))
+	result, err := tableService.ImportFromFile(filename)
+	if err != nil {
+		log.Fatalf("Stollarni import qilishda xatolik: %v", err)
+	}
+	log.Printf("✅ Import tugadi: %d ta yangi stol, %d tasi o'tkazib yuborildi.", result.Created, result.Skipped)
+}
+
 func main() {
 	cfg := config.LoadConfig()
 
@@ -70,6 +142,18 @@ func main() {
 		cfg.DatabasePort,
 	)
 
+	// `amur migrate up|down [N]|status` - faqat migratsiyalar bilan ishlash, server ishga tushmaydi
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCommand(connStr, os.Args[2:])
+		return
+	}
+
+	// `amur import-tables [fayl]` - stollarni table.json dan bazaga ko'chirish, server ishga tushmaydi
+	if len(os.Args) > 1 && os.Args[1] == "import-tables" {
+		runImportTablesCommand(connStr, os.Args[2:])
+		return
+	}
+
 	db, err := database.NewDatabase(connStr)
 	if err != nil {
 		log.Fatalf("PostgreSQL ma'lumotlar bazasiga ulanishda xatolik: %v", err)
@@ -79,21 +163,44 @@ func main() {
 
 	// Repository'larni yaratish
 	userRepo := repository.NewUserRepository(db.GetDB())
+	loginCodeRepo := repository.NewLoginCodeRepository(db.GetDB())
+	loginThrottleRepo := repository.NewLoginThrottleRepository(db.GetDB())
+	sessionRepo := repository.NewSessionRepository(db.GetDB())
+	permissionRepo := repository.NewPermissionRepository(db.GetDB())
 	foodRepo := repository.NewFoodRepository(db.GetDB())
 	basketOrderRepo := repository.NewBasketOrderRepository(db.GetDB())
 	orderRepo := repository.NewOrderRepository(db.GetDB())
+	tableRepo := repository.NewTableRepository(db.GetDB())
+	deliveryZoneRepo := repository.NewDeliveryZoneRepository(db.GetDB())
+	unitOfWork := repository.NewUnitOfWork(db.GetDB())
+	idempotencyRepo := repository.NewIdempotencyRepository(db.GetDB())
 
 	// Service'larni yaratish
-	userService := service.NewUserService(userRepo)
-	foodService := service.NewFoodService(foodRepo)
-	basketOrderService := service.NewBasketOrderService(basketOrderRepo, foodRepo)
-	orderService := service.NewOrderService(orderRepo, basketOrderRepo, foodRepo)
+	loginThrottleService := service.NewLoginThrottleService(loginThrottleRepo)
+	sessionService := service.NewSessionService(sessionRepo, userRepo, permissionRepo)
+	permissionService := service.NewPermissionService(permissionRepo, userRepo, sessionService)
+	permissionService.EnsureSuperAdmins(cfg.SuperAdminIDs)
+	userService := service.NewUserService(unitOfWork, userRepo, loginCodeRepo, loginThrottleService, sessionService, cfg.BotToken)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo)
+	foodService := service.NewFoodService(unitOfWork, foodRepo, basketOrderRepo)
+	// Savatcha xulosasi va buyurtma bir xil narx qoidalaridan foydalanadi
+	pricing := service.PricingPolicy{
+		ServiceChargePercent: cfg.ServiceChargePercent,
+	}
+	deliveryZoneService := service.NewDeliveryZoneService(deliveryZoneRepo)
+	basketOrderService := service.NewBasketOrderService(unitOfWork, basketOrderRepo, foodRepo, deliveryZoneService, pricing)
+	tableService := service.NewTableService(unitOfWork, tableRepo)
+	tableService.SetMiniAppURL(cfg.MiniAppURL) // Stol QR kodlari shu havolaga olib boradi
+	orderService := service.NewOrderService(unitOfWork, orderRepo, basketOrderRepo, foodRepo, tableRepo, deliveryZoneRepo, permissionService, pricing)
+	orderService.SetCustomerCancellableStatuses(cfg.CustomerCancellableStatuses)
 
 	// Handler'larni yaratish
-	userHandler := handlers.NewUserHandler(userService)
+	userHandler := handlers.NewUserHandler(userService, sessionService, permissionService)
 	foodHandler := handlers.NewFoodHandler(foodService)
 	basketOrderHandler := handlers.NewBasketOrderHandler(basketOrderService)
 	orderHandler := handlers.NewOrderHandler(orderService)
+	tableHandler := handlers.NewTableHandler(tableService)
+	deliveryZoneHandler := handlers.NewDeliveryZoneHandler(deliveryZoneService)
 
 	// Telegram botni sozlash
 	bot, err := tgbotapi.NewBotAPI(cfg.BotToken)
@@ -104,10 +211,23 @@ func main() {
 	bot.Debug = false
 	log.Printf("🤖 Bot @%s sifatida ishga tushdi", bot.Self.UserName)
 
-	botHandler := handlers.NewBotHandler(bot, userService)
+	botHandler := handlers.NewBotHandler(bot, userService, permissionService, tableService)
+	loginThrottleService.SetNotifier(botHandler)               // Login hujumlari haqida foydalanuvchini ogohlantirish uchun
+	orderService.SetStaffNotifier(botHandler, cfg.StaffChatID) // Mijoz bekor qilgan buyurtmalar haqida xodimlarni ogohlantirish uchun
+	tableService.SetStaffNotifier(botHandler, cfg.StaffChatID) // Stoldan ofitsiant/hisob chaqiruvlari uchun
+
+	// AuthMiddleware bekor qilingan sessiyalarni rad etishi uchun
+	middleware.SetSessionChecker(sessionService)
+
+	// POST /orders va /basket-order qayta yuborilganda ikki marta bajarilmasligi uchun
+	middleware.SetIdempotencyStore(idempotencyService)
+	stopCleanup := make(chan struct{})
+	defer close(stopCleanup)
+	idempotencyService.StartCleanup(time.Hour, stopCleanup)
+	orderService.StartArchiver(24*time.Hour, cfg.OrderArchiveMonths, stopCleanup) // Eski buyurtmalarni kuniga bir marta arxivlash
 
 	// HTTP serverni sozlash
-	router := routes.SetupRoutes(foodHandler, userHandler, basketOrderHandler, orderHandler)
+	router := routes.SetupRoutes(foodHandler, userHandler, basketOrderHandler, orderHandler, tableHandler, deliveryZoneHandler)
 	server := &http.Server{
 		Addr:         ":" + cfg.ServerPort,
 		Handler:      router,
@@ -137,6 +257,10 @@ func main() {
 	// Asosiy loop (Telegram bot update'larini qayta ishlash)
 	go func() {
 		for update := range updates {
+			if update.CallbackQuery != nil {
+				botHandler.HandleCallback(update.CallbackQuery)
+				continue
+			}
 			if update.Message != nil {
 				chatID := update.Message.Chat.ID
 
@@ -145,6 +269,10 @@ func main() {
 					switch update.Message.Command() {
 					case "start":
 						botHandler.HandleStart(chatID)
+					case "code":
+						botHandler.HandleCode(chatID, update.Message.From.ID)
+					case "setrole":
+						botHandler.HandleSetRole(chatID, update.Message.From.ID, update.Message.CommandArguments())
 					case "stats":
 						botHandler.HandleStats(chatID)
 					default:
@@ -6,20 +6,37 @@ import (
 	"net/http"
 	"strings"
 
+	"amur/models"
 	"amur/pkg/jwt_auth" // jwt_auth paketi to'g'ri yo'lni ko'rsatishini tekshiring
 )
 
 // ContextKey o'zgaruvchisi context ichiga ma'lumot saqlash uchun
 type ContextKey string
 
-const (
-	TelegramIDContextKey ContextKey = "telegram_id"
-	RoleContextKey       ContextKey = "role"
-)
+const PrincipalContextKey ContextKey = "principal"
 
-// AuthMiddleware JWT tokenini tekshiradi va foydalanuvchi ma'lumotlarini contextga qo'shadi
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+// SessionChecker sessiya hali faol (bekor qilinmagan) ekanligini tekshiradi
+type SessionChecker interface {
+	IsSessionActive(sessionID string) (bool, error)
+}
+
+var sessionChecker SessionChecker
+
+// SetSessionChecker AuthMiddleware ishlatadigan sessiya tekshiruvchisini o'rnatadi
+func SetSessionChecker(checker SessionChecker) {
+	sessionChecker = checker
+}
+
+// PrincipalFromContext AuthMiddleware kontekstga qo'ygan foydalanuvchi ma'lumotini oladi
+func PrincipalFromContext(ctx context.Context) (*models.Principal, bool) {
+	principal, ok := ctx.Value(PrincipalContextKey).(*models.Principal)
+	return principal, ok && principal != nil
+}
+
+// AuthMiddleware JWT tokenini tekshiradi va foydalanuvchi ma'lumotlarini (Principal) contextga qo'shadi.
+// mux.Router.Use bilan ishlatiladi.
+func AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
 			log.Println("AuthMiddleware: Avtorizatsiya tokeni topilmadi")
@@ -42,7 +59,6 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		// --- Asosiy o'zgarishlar shu yerda ---
 		// jwt.MapClaims dan qiymatlarni olish
 		telegramIDFloat, ok := claims["telegram_id"].(float64) // JWT claimlarida raqamlar float64 bo'lishi mumkin
 		if !ok {
@@ -50,35 +66,66 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			http.Error(w, "Foydalanuvchi Telegram IDsi topilmadi", http.StatusUnauthorized)
 			return
 		}
-		telegramID := int64(telegramIDFloat) // int64 ga o'girish
 
 		role, ok := claims["role"].(string)
 		if !ok {
 			log.Println("AuthMiddleware: 'role' claim topilmadi yoki turi noto'g'ri")
-			// Rol majburiy bo'lmasa, bu xatoni qaytarmasligingiz mumkin, balki default qiymat berasiz.
-			// Bu misolda, topilmasa ham davom etamiz, lekin loglaymiz.
-			role = "" // Default bo'sh string
+			role = "" // Ruxsatlar rolga emas, "permissions" claimiga qarab tekshiriladi
 		}
 
-		// Foydalanuvchi Telegram ID va Rolini contextga qo'shish
-		ctx := context.WithValue(r.Context(), TelegramIDContextKey, telegramID)
-		ctx = context.WithValue(ctx, RoleContextKey, role) // role string bo'ladi
+		sessionID, _ := claims["sid"].(string)
+		if sessionID == "" {
+			log.Println("AuthMiddleware: 'sid' claim topilmadi")
+			http.Error(w, "Sessiya topilmadi, qaytadan tizimga kiring", http.StatusUnauthorized)
+			return
+		}
+		if sessionChecker != nil {
+			active, err := sessionChecker.IsSessionActive(sessionID)
+			if err != nil {
+				log.Printf("AuthMiddleware: Sessiyani tekshirishda xatolik: %v", err)
+				http.Error(w, "Sessiyani tekshirishda xatolik", http.StatusInternalServerError)
+				return
+			}
+			if !active {
+				log.Printf("AuthMiddleware: Sessiya bekor qilingan yoki muddati o'tgan: %s", sessionID)
+				http.Error(w, "Sessiya bekor qilingan, qaytadan tizimga kiring", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		var permissions []string
+		if rawPermissions, ok := claims["permissions"].([]interface{}); ok {
+			for _, raw := range rawPermissions {
+				if perm, ok := raw.(string); ok {
+					permissions = append(permissions, perm)
+				}
+			}
+		}
+
+		principal := &models.Principal{
+			TelegramID:  int64(telegramIDFloat), // int64 ga o'girish
+			Role:        role,
+			SessionID:   sessionID,
+			Permissions: permissions,
+		}
+		ctx := context.WithValue(r.Context(), PrincipalContextKey, principal)
 		next.ServeHTTP(w, r.WithContext(ctx))
-	}
+	})
 }
 
-// RoleMiddleware faqat ma'lum bir rolga ega foydalanuvchilarga ruxsat beradi
-func RoleMiddleware(requiredRole string, next http.HandlerFunc) http.HandlerFunc {
+// PermissionMiddleware faqat berilgan ruxsatga (permission) ega foydalanuvchilarga ruxsat beradi.
+// Ruxsatlar JWT claimlaridan olinadi, ular rol bo'yicha role_permissions jadvalidan yig'iladi.
+func PermissionMiddleware(next http.HandlerFunc, permission string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		role, ok := r.Context().Value(RoleContextKey).(string)
+		principal, ok := PrincipalFromContext(r.Context())
 		if !ok {
-			log.Println("RoleMiddleware: Foydalanuvchi roli kontekstda topilmadi")
-			http.Error(w, "Foydalanuvchi roli topilmadi (AuthMiddleware avval ishlashi kerak)", http.StatusInternalServerError)
+			log.Println("PermissionMiddleware: Foydalanuvchi kontekstda topilmadi")
+			http.Error(w, "Foydalanuvchi ruxsatlari topilmadi (AuthMiddleware avval ishlashi kerak)", http.StatusInternalServerError)
 			return
 		}
 
-		if role != requiredRole {
-			log.Printf("RoleMiddleware: Ruxsat berilmagan harakat. Talab qilingan rol: %s, Foydalanuvchi roli: %s", requiredRole, role)
+		if !principal.HasPermission(permission) {
+			log.Printf("PermissionMiddleware: Ruxsat berilmagan harakat. Talab qilingan ruxsat: %s, Foydalanuvchi roli: %s", permission, principal.Role)
 			http.Error(w, "Sizda bu operatsiya uchun ruxsat yo'q", http.StatusForbidden)
 			return
 		}
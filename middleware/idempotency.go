@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"amur/models"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+)
+
+// IdempotencyKeyHeader mijoz qayta yuboriladigan so'rovlar uchun beradigan sarlavha
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyStore Idempotency-Key bo'yicha so'rov natijalarini saqlaydi
+type IdempotencyStore interface {
+	Begin(telegramID int64, key, method, path, requestHash string) (*models.IdempotencyRecord, bool, error)
+	Complete(telegramID int64, key string, statusCode int, body []byte) error
+	Abort(telegramID int64, key string) error
+}
+
+var idempotencyStore IdempotencyStore
+
+// SetIdempotencyStore IdempotencyMiddleware ishlatadigan omborni o'rnatadi
+func SetIdempotencyStore(store IdempotencyStore) {
+	idempotencyStore = store
+}
+
+// IdempotencyMiddleware Idempotency-Key sarlavhasi bor so'rovni bir marta bajaradi.
+// Xuddi shu foydalanuvchi va kalit bilan qayta yuborilgan so'rovga saqlangan javob qaytariladi;
+// so'rov hali bajarilayotgan bo'lsa 409, kalit boshqa so'rov tanasi bilan ishlatilgan bo'lsa 422 qaytadi.
+// Server xatosi (5xx) bilan tugagan so'rov saqlanmaydi, mijoz qayta urinishi mumkin.
+// AuthMiddleware dan keyin ishlatilishi kerak.
+func IdempotencyMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || idempotencyStore == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeIdempotencyError(w, http.StatusBadRequest, "Idempotency-Key juda uzun", "Kalit 255 belgidan oshmasligi kerak.")
+			return
+		}
+
+		principal, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Autentifikatsiya talab qilinadi", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeIdempotencyError(w, http.StatusBadRequest, "So'rov tanasini o'qishda xatolik", err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		record, started, err := idempotencyStore.Begin(principal.TelegramID, key, r.Method, r.URL.Path, requestHash)
+		if err != nil {
+			log.Printf("IdempotencyMiddleware: %v", err)
+			writeIdempotencyError(w, http.StatusInternalServerError, "Idempotency kalitini tekshirishda xatolik", err.Error())
+			return
+		}
+
+		if !started {
+			switch {
+			case record.RequestHash != requestHash:
+				writeIdempotencyError(w, http.StatusUnprocessableEntity, "Idempotency-Key boshqa so'rov uchun ishlatilgan", "Har bir yangi so'rov uchun yangi kalit yuboring.")
+			case !record.Completed():
+				writeIdempotencyError(w, http.StatusConflict, "So'rov hali bajarilmoqda", "Xuddi shu Idempotency-Key bilan so'rov hozir bajarilmoqda, birozdan keyin qayta urinib ko'ring.")
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(record.StatusCode)
+				w.Write(record.ResponseBody)
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		completed := false
+		defer func() {
+			// Handler panic bo'lsa ham kalit "bajarilmoqda" holatida qolib ketmasligi kerak
+			if !completed {
+				idempotencyStore.Abort(principal.TelegramID, key)
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.statusCode >= http.StatusInternalServerError {
+			return
+		}
+		// So'rov bajarildi: javobni saqlay olmasak ham kalitni bo'shatmaymiz, aks holda qayta urinish uni ikkinchi marta bajaradi
+		completed = true
+		if err := idempotencyStore.Complete(principal.TelegramID, key, recorder.statusCode, recorder.body.Bytes()); err != nil {
+			log.Printf("IdempotencyMiddleware: %v", err)
+		}
+	}
+}
+
+// responseRecorder javobni mijozga yuborish bilan birga uning statusi va tanasini yozib oladi
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	if !rr.wroteHeader {
+		rr.statusCode = statusCode
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
+
+// writeIdempotencyError handlerlardagi bilan bir xil formatda xato javobini yuboradi
+func writeIdempotencyError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":   message,
+		"details": details,
+	})
+}
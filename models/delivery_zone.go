@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DeliveryZone yetkazib berish hududi. Manzil bir nechta hududga tushsa eng arzon yetkazib beriladigani tanlanadi.
+type DeliveryZone struct {
+	ZoneID         int             `json:"zone_id" db:"zone_id"`
+	ZoneName       string          `json:"zone_name" db:"zone_name"`
+	Polygon        json.RawMessage `json:"polygon,omitempty" db:"polygon"` // GeoJSON MultiPolygon ([longitude, latitude])
+	DeliveryFee    float64         `json:"delivery_fee" db:"delivery_fee"`
+	MinOrderAmount float64         `json:"min_order_amount" db:"min_order_amount"` // Subtotal shundan kam bo'lsa buyurtma qabul qilinmaydi
+	EtaMinutes     int             `json:"eta_minutes" db:"eta_minutes"`
+	IsActive       bool            `json:"is_active" db:"is_active"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// CreateDeliveryZoneRequest POST /api/admin/delivery-zones so'rov formati.
+// Polygon - GeoJSON Polygon, MultiPolygon, Feature yoki FeatureCollection.
+type CreateDeliveryZoneRequest struct {
+	ZoneName       string          `json:"zone_name"`
+	Polygon        json.RawMessage `json:"polygon"`
+	DeliveryFee    float64         `json:"delivery_fee"`
+	MinOrderAmount float64         `json:"min_order_amount"`
+	EtaMinutes     int             `json:"eta_minutes"`
+	IsActive       *bool           `json:"is_active,omitempty"` // Ko'rsatilmasa hudud faol bo'ladi
+}
+
+// UpdateDeliveryZoneRequest PUT /api/admin/delivery-zones/{zoneID} so'rov formati. Faqat yuborilgan maydonlar o'zgaradi.
+type UpdateDeliveryZoneRequest struct {
+	ZoneName       *string         `json:"zone_name,omitempty"`
+	Polygon        json.RawMessage `json:"polygon,omitempty"`
+	DeliveryFee    *float64        `json:"delivery_fee,omitempty"`
+	MinOrderAmount *float64        `json:"min_order_amount,omitempty"`
+	EtaMinutes     *int            `json:"eta_minutes,omitempty"`
+	IsActive       *bool           `json:"is_active,omitempty"`
+}
+
+// DeliveryQuote manzil uchun yetkazib berish shartlari (GET /api/delivery-zones/check)
+type DeliveryQuote struct {
+	ZoneID         int     `json:"zone_id"`
+	ZoneName       string  `json:"zone_name"`
+	DeliveryFee    float64 `json:"delivery_fee"`
+	MinOrderAmount float64 `json:"min_order_amount"`
+	EtaMinutes     int     `json:"eta_minutes"`
+}
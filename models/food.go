@@ -10,6 +10,8 @@ type Food struct {
 	FoodImage    string    `json:"food_image" db:"food_image"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+
+	ModifierGroups []ModifierGroup `json:"modifier_groups"` // Variantlar (o'lcham, qo'shimchalar va h.k.)
 }
 
 type CreateFoodRequest struct {
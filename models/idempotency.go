@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// IdempotencyRecord Idempotency-Key bo'yicha saqlangan so'rov va uning javobi
+type IdempotencyRecord struct {
+	TelegramID   int64     `json:"telegram_id"`
+	Key          string    `json:"idempotency_key"`
+	Method       string    `json:"request_method"`
+	Path         string    `json:"request_path"`
+	RequestHash  string    `json:"request_hash"`
+	StatusCode   int       `json:"status_code"` // 0 bo'lsa so'rov hali bajarilmoqda
+	ResponseBody []byte    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Completed so'rov bajarilib, javobi saqlanganligini bildiradi
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ModifierGroup ovqat uchun variantlar guruhi (masalan, "O'lcham", "Achchiqlik", "Qo'shimchalar")
+type ModifierGroup struct {
+	GroupID   int              `json:"group_id"`
+	FoodID    int              `json:"food_id"`
+	GroupName string           `json:"group_name"`
+	MinSelect int              `json:"min_select"` // 1 va undan ko'p bo'lsa guruhdan tanlash majburiy
+	MaxSelect int              `json:"max_select"`
+	SortOrder int              `json:"sort_order"`
+	Options   []ModifierOption `json:"options"`
+}
+
+// ModifierOption guruhdagi bitta variant va uning narxga qo'shimchasi
+type ModifierOption struct {
+	OptionID   int     `json:"option_id"`
+	GroupID    int     `json:"group_id"`
+	OptionName string  `json:"option_name"`
+	PriceDelta float64 `json:"price_delta"`
+	SortOrder  int     `json:"sort_order"`
+}
+
+// OrderItemModifier buyurtma qatorida saqlanadigan tanlangan variant nusxasi.
+// Menyu keyinchalik o'zgarsa ham buyurtma tarixi o'zgarmaydi.
+type OrderItemModifier struct {
+	OptionID   int     `json:"option_id"`
+	GroupName  string  `json:"group_name"`
+	OptionName string  `json:"option_name"`
+	PriceDelta float64 `json:"price_delta"`
+}
+
+// ModifierGroupInput PUT /foods/{id}/modifiers so'rovidagi bitta guruh
+type ModifierGroupInput struct {
+	GroupName string                `json:"group_name"`
+	MinSelect int                   `json:"min_select"`
+	MaxSelect int                   `json:"max_select"`
+	Options   []ModifierOptionInput `json:"options"`
+}
+
+// ModifierOptionInput guruhdagi bitta variant
+type ModifierOptionInput struct {
+	OptionName string  `json:"option_name"`
+	PriceDelta float64 `json:"price_delta"`
+}
+
+// SetFoodModifiersRequest ovqatning barcha modifikator guruhlarini almashtirish uchun so'rov formati
+type SetFoodModifiersRequest struct {
+	Groups []ModifierGroupInput `json:"groups"`
+}
+
+// ModifiersKey tanlangan variantlar ID'laridan tartiblangan kalit yasaydi ("3,7").
+// Bir xil tanlov har doim bir xil kalit beradi, shuning uchun savatchada bir xil qator birlashadi.
+func ModifiersKey(optionIDs []int) string {
+	ids := append([]int(nil), optionIDs...)
+	sort.Ints(ids)
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseModifiersKey ModifiersKey kalitidan variant ID'larini qaytaradi
+func ParseModifiersKey(key string) []int {
+	if key == "" {
+		return nil
+	}
+	var ids []int
+	for _, part := range strings.Split(key, ",") {
+		if id, err := strconv.Atoi(part); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
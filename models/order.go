@@ -5,17 +5,102 @@ import "time"
 
 // BasketOrder savatchadagi bitta mahsulotni ifodalaydi (unchanged)
 type BasketOrder struct {
-	BasketOrderID int       `json:"basket_order_id" db:"basket_order_id"`
-	TelegramID    int64     `json:"telegram_id" db:"user_tg_id"` // db tagi to'g'rilandi
-	FoodID        int       `json:"food_id" db:"food_id"`
-	Quantity      int       `json:"quantity" db:"quantity"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	BasketOrderID  int       `json:"basket_order_id" db:"basket_order_id"`
+	TelegramID     int64     `json:"telegram_id" db:"user_tg_id"` // db tagi to'g'rilandi
+	FoodID         int       `json:"food_id" db:"food_id"`
+	Quantity       int       `json:"quantity" db:"quantity"`
+	ModifiersKey   string    `json:"-" db:"modifiers_key"`                               // Tartiblangan option_id lar ("3,7")
+	ModifierIDs    []int     `json:"modifier_option_ids" db:"-"`                         // ModifiersKey dan olinadi
+	Note           string    `json:"note" db:"note"`                                     // Qator izohi ("piyozsiz")
+	UnitPriceAtAdd *float64  `json:"unit_price_at_add,omitempty" db:"unit_price_at_add"` // Qo'shilgan paytdagi bitta dona narxi (modifikatorlar bilan)
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
 
-// AddToBasketRequest savatchaga mahsulot qo'shish uchun so'rov formati (unchanged)
+// AddToBasketRequest savatchaga mahsulot qo'shish uchun so'rov formati
 type AddToBasketRequest struct {
-	FoodID int `json:"food_id" validate:"required,gt=0"`
+	FoodID            int    `json:"food_id" validate:"required,gt=0"`
+	ModifierOptionIDs []int  `json:"modifier_option_ids,omitempty"` // Tanlangan variantlar
+	Note              string `json:"note,omitempty"`                // Qator izohi
+}
+
+// UpdateBasketQuantityRequest savatchadagi mahsulot miqdorini to'g'ridan-to'g'ri o'rnatish uchun (0 - o'chirish)
+type UpdateBasketQuantityRequest struct {
+	Quantity *int `json:"quantity" validate:"required,gte=0"`
+}
+
+// BasketSyncItem mijoz savatchasidagi bitta qator
+type BasketSyncItem struct {
+	FoodID            int    `json:"food_id" validate:"required,gt=0"`
+	Quantity          int    `json:"quantity" validate:"gte=0"`
+	ModifierOptionIDs []int  `json:"modifier_option_ids,omitempty"`
+	Note              string `json:"note,omitempty"`
+}
+
+// SyncBasketRequest butun savatchani mijozdagi holat bilan almashtirish uchun so'rov formati
+type SyncBasketRequest struct {
+	Items []BasketSyncItem `json:"items"`
+}
+
+// Yetkazib berish turlari
+const (
+	DeliveryTypeDelivery = "yetkazib berish"
+	DeliveryTypePickup   = "o'zi olib ketish"
+	DeliveryTypeDineIn   = "zalga"
+)
+
+// PriceBreakdown savatcha yoki buyurtma narxining tarkibi.
+// GrandTotal = Subtotal + DeliveryFee + ServiceCharge - Discount
+type PriceBreakdown struct {
+	Subtotal      float64 `json:"subtotal"`
+	DeliveryFee   float64 `json:"delivery_fee"`
+	ServiceCharge float64 `json:"service_charge"`
+	Discount      float64 `json:"discount"`
+	GrandTotal    float64 `json:"grand_total"`
+}
+
+// BasketLine savatchadagi qator: ovqat ma'lumotlari, joriy narx va qo'shilgan paytdagi narx bilan
+type BasketLine struct {
+	BasketOrderID     int                 `json:"basket_order_id"`
+	FoodID            int                 `json:"food_id"`
+	FoodName          string              `json:"food_name"`
+	FoodCategory      string              `json:"food_category"`
+	FoodImage         string              `json:"food_image"`
+	FoodPrice         float64             `json:"food_price"`
+	Quantity          int                 `json:"quantity"`
+	Modifiers         []OrderItemModifier `json:"modifiers"`
+	Note              string              `json:"note"`
+	UnitPrice         float64             `json:"unit_price"`                  // Joriy narx (modifikatorlar bilan)
+	UnitPriceAtAdd    *float64            `json:"unit_price_at_add,omitempty"` // Savatchaga qo'shilgan paytdagi narx
+	PriceChanged      bool                `json:"price_changed"`               // Qo'shilgandan keyin narx o'zgargan
+	TotalPrice        float64             `json:"total_price"`
+	Available         bool                `json:"available"` // false bo'lsa buyurtma berishda rad etiladi
+	UnavailableReason string              `json:"unavailable_reason,omitempty"`
+	CreatedAt         time.Time           `json:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at"`
+}
+
+// BasketPriceQuery savatcha xulosasidagi to'lovlarni hisoblash shartlari.
+// Yetkazib berish narxi hududga bog'liq, shuning uchun u faqat manzil koordinatalari bilan hisoblanadi.
+type BasketPriceQuery struct {
+	DeliveryType string
+	Latitude     *float64
+	Longitude    *float64
+}
+
+// BasketSummary savatcha qatorlari va umumiy narx tarkibi.
+// Yetkazib berishda manzil ko'rsatilmagan bo'lsa DeliveryFee va GrandTotal noma'lum (null) bo'ladi.
+type BasketSummary struct {
+	Items               []BasketLine   `json:"items"`
+	DeliveryType        string         `json:"delivery_type,omitempty"` // To'lovlar shu tur bo'yicha hisoblangan
+	Subtotal            float64        `json:"subtotal"`
+	DeliveryFee         *float64       `json:"delivery_fee"`
+	ServiceCharge       float64        `json:"service_charge"`
+	Discount            float64        `json:"discount"`
+	GrandTotal          *float64       `json:"grand_total"`
+	Delivery            *DeliveryQuote `json:"delivery,omitempty"`    // Manzil tushgan hudud: narx, minimal summa, taxminiy vaqt
+	HasPriceChanges     bool           `json:"has_price_changes"`     // Biror qator narxi o'zgargan, buyurtmadan oldin ogohlantirish kerak
+	HasUnavailableItems bool           `json:"has_unavailable_items"` // Biror qatorni buyurtma qilib bo'lmaydi
 }
 
 // Order buyurtmaning asosiy ma'lumotlarini ifodalaydi
@@ -23,26 +108,57 @@ type Order struct {
 	OrderID           int       `json:"order_id" db:"order_id"`
 	TelegramID        int64     `json:"telegram_id" db:"telegram_id"`
 	OrderTime         time.Time `json:"order_time" db:"order_time"`
-	OrderStatus       string    `json:"order_status" db:"order_status"`
+	OrderStatus       string    `json:"order_status" db:"order_status"` // Holat kodi (OrderStatusAccepted va h.k.)
+	OrderStatusLabel  string    `json:"order_status_label"`             // Holatning o'zbekcha nomi
+	StatusChangedAt   time.Time `json:"status_changed_at" db:"status_changed_at"`
 	DeliveryType      string    `json:"delivery_type" db:"delivery_type"`
-	TotalPrice        float64   `json:"total_price" db:"total_price"`
+	TotalPrice        float64   `json:"total_price" db:"total_price"` // Yakuniy summa (grand total)
+	Subtotal          float64   `json:"subtotal" db:"subtotal"`
+	DeliveryFee       float64   `json:"delivery_fee" db:"delivery_fee"`
+	ServiceCharge     float64   `json:"service_charge" db:"service_charge"`
+	DiscountAmount    float64   `json:"discount_amount" db:"discount_amount"`
 	DeliveryLatitude  *float64  `json:"delivery_latitude,omitempty" db:"delivery_latitude"`
 	DeliveryLongitude *float64  `json:"delivery_longitude,omitempty" db:"delivery_longitude"`
+	DeliveryZoneID    *int      `json:"delivery_zone_id,omitempty" db:"delivery_zone_id"` // Faqat "yetkazib berish" buyurtmalarda
+	DeliveryZoneName  *string   `json:"delivery_zone_name,omitempty"`
+	DeliveryETA       *int      `json:"delivery_eta_minutes,omitempty" db:"delivery_eta_minutes"` // Buyurtma paytidagi taxminiy vaqt (daqiqa)
 	Comment           *string   `json:"comment,omitempty" db:"comment"`
-	TableID           *string   `json:"table_id,omitempty"`
+	CourierTelegramID *int64    `json:"courier_telegram_id,omitempty" db:"courier_telegram_id"` // Biriktirilgan kuryer
+	TableID           *int      `json:"table_id,omitempty" db:"table_id"`                       // Faqat "zalga" buyurtmalarda
+	TableName         *string   `json:"table_name,omitempty"`
+	TableSessionID    *int      `json:"table_session_id,omitempty" db:"table_session_id"` // Stolning ochiq hisobi
+	HallName          *string   `json:"hall_name,omitempty"`
 	CreatedAt         time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+
+	// O'chirilgan (soft delete) buyurtmalar uchun; mijozlarga ko'rsatilmaydi
+	DeletedAt    *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	DeletedBy    *int64     `json:"deleted_by,omitempty" db:"deleted_by"`
+	DeleteReason *string    `json:"delete_reason,omitempty" db:"delete_reason"`
+}
+
+// AssignCourierRequest PUT /api/admin/orders/{orderID}/courier so'rov formati (null - kuryerni olib tashlash)
+type AssignCourierRequest struct {
+	CourierTelegramID *int64 `json:"courier_telegram_id"`
+}
+
+// DeleteOrderRequest DELETE /api/admin/orders/{orderID} so'rov formati (tana ixtiyoriy)
+type DeleteOrderRequest struct {
+	Reason string `json:"reason"`
 }
 
 // OrderItem buyurtmadagi har bir alohida mahsulotni ifodalaydi (unchanged)
 type OrderItem struct {
-	OrderItemID int       `json:"order_item_id" db:"order_item_id"`
-	OrderID     int       `json:"order_id" db:"order_id"`
-	FoodID      int       `json:"food_id" db:"food_id"`
-	Quantity    int       `json:"quantity" db:"quantity"`
-	ItemPrice   float64   `json:"item_price" db:"item_price"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	OrderItemID int     `json:"order_item_id" db:"order_item_id"`
+	OrderID     int     `json:"order_id" db:"order_id"`
+	FoodID      int     `json:"food_id" db:"food_id"`
+	Quantity    int     `json:"quantity" db:"quantity"`
+	ItemPrice   float64 `json:"item_price" db:"item_price"` // Modifikatorlar bilan birga bitta dona narxi
+
+	Modifiers []OrderItemModifier `json:"modifiers" db:"modifiers"` // Tanlangan variantlar nusxasi
+	Note      *string             `json:"note,omitempty" db:"note"`
+	CreatedAt time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at" db:"updated_at"`
 }
 
 // CreateOrderRequest buyurtma yaratish uchun keladigan so'rov formati
@@ -52,7 +168,7 @@ type CreateOrderRequest struct {
 	DeliveryLatitude  *float64 `json:"delivery_latitude,omitempty"`
 	DeliveryLongitude *float64 `json:"delivery_longitude,omitempty"`
 	Comment           *string  `json:"comment,omitempty"`
-	TableID           *string  `json:"table_id,omitempty"` // NEW: For "zalga" orders QR code token
+	TableToken        *string  `json:"table_id,omitempty"` // "zalga" buyurtmalar uchun stolning QR kod tokeni
 }
 
 // OrderDetailsResponse buyurtma va uning ichidagi mahsulotlar bilan birgalikda to'liq javob (unchanged)
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Buyurtmalar ro'yxatini saralash maydonlari
+const (
+	OrderSortByTime  = "time"
+	OrderSortByPrice = "price"
+)
+
+// OrderListCursor sahifalash kursori: oldingi sahifaning oxirgi buyurtmasi.
+// Saralash maydoniga qarab OrderTime yoki TotalPrice ishlatiladi, OrderID esa tenglikda tartibni belgilaydi.
+type OrderListCursor struct {
+	OrderTime  *time.Time `json:"t,omitempty"`
+	TotalPrice *float64   `json:"p,omitempty"`
+	OrderID    int        `json:"id"`
+}
+
+// OrderListFilter GET /api/admin/orders filtrlari. Bo'sh (nil) maydonlar filtrlanmaydi.
+type OrderListFilter struct {
+	Status         string
+	DeliveryType   string
+	TableID        *int
+	TelegramID     *int64
+	From           *time.Time // order_time >= From
+	To             *time.Time // order_time < To
+	MinTotal       *float64
+	SortBy         string // OrderSortByTime (sukut bo'yicha) yoki OrderSortByPrice
+	Ascending      bool   // Sukut bo'yicha kamayish tartibida (eng yangi/qimmat birinchi)
+	IncludeDeleted bool
+	Limit          int
+	Cursor         *OrderListCursor
+}
+
+// OrderListPage buyurtmalar ro'yxatining bitta sahifasi
+type OrderListPage struct {
+	Orders     []OrderDetailsResponse `json:"orders"`
+	NextCursor string                 `json:"next_cursor,omitempty"` // Bo'sh bo'lsa keyingi sahifa yo'q
+	HasMore    bool                   `json:"has_more"`
+}
@@ -0,0 +1,133 @@
+package models
+
+import "time"
+
+// Buyurtma holatlari kodlari. Bazada va API'da faqat shu kodlar ishlatiladi, foydalanuvchiga esa tarjima qilingan nomi ko'rsatiladi.
+const (
+	OrderStatusAccepted  = "accepted"
+	OrderStatusPreparing = "preparing"
+	OrderStatusReady     = "ready"
+	OrderStatusOnTheWay  = "on_the_way" // Faqat yetkazib berishda
+	OrderStatusDelivered = "delivered"  // Yetkazib berish yakuni
+	OrderStatusPickedUp  = "picked_up"  // Olib ketish yakuni
+	OrderStatusServed    = "served"     // Zalga buyurtma yakuni
+	OrderStatusCancelled = "cancelled"
+)
+
+// DefaultLanguage holat nomi so'ralgan tilda bo'lmasa ishlatiladigan til
+const DefaultLanguage = "uz"
+
+// OrderStatusInfo holat kodi, uning turli tillardagi nomlari va yakuniy ekanligi
+type OrderStatusInfo struct {
+	Code   string            `json:"code"`
+	Labels map[string]string `json:"labels"`
+	Final  bool              `json:"final"` // Yakuniy holatdan boshqa holatga o'tib bo'lmaydi
+}
+
+// orderStatuses barcha holatlar ko'rsatish tartibida
+var orderStatuses = []OrderStatusInfo{
+	{Code: OrderStatusAccepted, Labels: map[string]string{"uz": "buyurtma qabul qilindi", "ru": "заказ принят", "en": "order accepted"}},
+	{Code: OrderStatusPreparing, Labels: map[string]string{"uz": "buyurtma tayyorlanmoqda", "ru": "заказ готовится", "en": "preparing"}},
+	{Code: OrderStatusReady, Labels: map[string]string{"uz": "buyurtma tayyor", "ru": "заказ готов", "en": "ready"}},
+	{Code: OrderStatusOnTheWay, Labels: map[string]string{"uz": "buyurtma yo'lda", "ru": "заказ в пути", "en": "on the way"}},
+	{Code: OrderStatusDelivered, Labels: map[string]string{"uz": "buyurtma yetkazildi", "ru": "заказ доставлен", "en": "delivered"}, Final: true},
+	{Code: OrderStatusPickedUp, Labels: map[string]string{"uz": "buyurtma olib ketildi", "ru": "заказ выдан", "en": "picked up"}, Final: true},
+	{Code: OrderStatusServed, Labels: map[string]string{"uz": "buyurtma stolga berildi", "ru": "заказ подан", "en": "served"}, Final: true},
+	{Code: OrderStatusCancelled, Labels: map[string]string{"uz": "bekor qilindi", "ru": "отменён", "en": "cancelled"}, Final: true},
+}
+
+// legacyOrderStatuses eski mijozlar yuboradigan o'zbekcha holat matnlari.
+// "buyurtma yetkazildi" yetkazib berish turiga qarab yakuniy holatga aylantiriladi.
+var legacyOrderStatuses = map[string]string{
+	"pending":                 OrderStatusAccepted,
+	"buyurtma qabul qilindi":  OrderStatusAccepted,
+	"buyurtma tayyorlanmoqda": OrderStatusPreparing,
+	"buyurtma tayyor":         OrderStatusReady,
+	"bekor qilindi":           OrderStatusCancelled,
+}
+
+// OrderStatuses barcha holatlar ro'yxatini qaytaradi
+func OrderStatuses() []OrderStatusInfo {
+	return orderStatuses
+}
+
+// LookupOrderStatus holat kodi bo'yicha ma'lumotni qaytaradi
+func LookupOrderStatus(code string) (OrderStatusInfo, bool) {
+	for _, status := range orderStatuses {
+		if status.Code == code {
+			return status, true
+		}
+	}
+	return OrderStatusInfo{}, false
+}
+
+// OrderStatusLabel holatning berilgan tildagi nomini qaytaradi (til bo'lmasa o'zbekcha, noma'lum kod bo'lsa kodning o'zi)
+func OrderStatusLabel(code, lang string) string {
+	status, ok := LookupOrderStatus(code)
+	if !ok {
+		return code
+	}
+	if label, ok := status.Labels[lang]; ok {
+		return label
+	}
+	return status.Labels[DefaultLanguage]
+}
+
+// ParseOrderStatus holat kodini yoki eski o'zbekcha matnni kodga aylantiradi
+func ParseOrderStatus(value, deliveryType string) (string, bool) {
+	if _, ok := LookupOrderStatus(value); ok {
+		return value, true
+	}
+	if code, ok := legacyOrderStatuses[value]; ok {
+		return code, true
+	}
+	if value == "buyurtma yetkazildi" {
+		switch deliveryType {
+		case DeliveryTypePickup:
+			return OrderStatusPickedUp, true
+		case DeliveryTypeDineIn:
+			return OrderStatusServed, true
+		default:
+			return OrderStatusDelivered, true
+		}
+	}
+	return "", false
+}
+
+// OrderStatusHistory buyurtma holati o'zgarishining bitta yozuvi
+type OrderStatusHistory struct {
+	HistoryID       int       `json:"history_id" db:"history_id"`
+	OrderID         int       `json:"order_id" db:"order_id"`
+	FromStatus      *string   `json:"from_status" db:"from_status"` // Birinchi yozuvda nil
+	FromStatusLabel string    `json:"from_status_label,omitempty"`
+	ToStatus        string    `json:"to_status" db:"to_status"`
+	ToStatusLabel   string    `json:"to_status_label"`
+	ActorTelegramID *int64    `json:"actor_telegram_id" db:"actor_telegram_id"` // Tizim yoki noma'lum bo'lsa nil
+	Reason          *string   `json:"reason,omitempty" db:"reason"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// UpdateOrderStatusRequest PUT /api/orders/{orderID}/status so'rov formati.
+// Status holat kodi yoki eski o'zbekcha matn bo'lishi mumkin.
+type UpdateOrderStatusRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// CancelOrderRequest POST /api/orders/{orderID}/cancel so'rov formati
+type CancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+// OrderStatusFlow yetkazib berish turi uchun boshlang'ich holat va ruxsat etilgan o'tishlar
+type OrderStatusFlow struct {
+	DeliveryType string              `json:"delivery_type"`
+	Initial      string              `json:"initial"`
+	Transitions  map[string][]string `json:"transitions"`
+}
+
+// OrderStatusesResponse GET /api/order-statuses javobi
+type OrderStatusesResponse struct {
+	Statuses []OrderStatusInfo `json:"statuses"`
+	Flows    []OrderStatusFlow `json:"flows"`
+}
@@ -0,0 +1,40 @@
+package models
+
+// Rollar. Har bir rolning ruxsatlari role_permissions jadvalida saqlanadi.
+const (
+	RoleCustomer   = "customer"
+	RoleWaiter     = "waiter"
+	RoleCook       = "cook"
+	RoleCourier    = "courier"
+	RoleManager    = "manager"
+	RoleSuperAdmin = "superadmin"
+)
+
+// Ruxsatlar (permission kodlari). Marshrutlar rol emas, aynan shu kodlar bo'yicha himoyalanadi.
+const (
+	PermMenuWrite           = "menu.write"
+	PermOrdersReadAll       = "orders.read.all"
+	PermOrdersReadAssigned  = "orders.read.assigned" // Faqat o'ziga biriktirilgan buyurtmalar (kuryer)
+	PermOrdersAssign        = "orders.assign"
+	PermOrdersStatusUpdate  = "orders.status.update"
+	PermOrdersDelete        = "orders.delete"
+	PermUsersRead           = "users.read"
+	PermUsersRolesManage    = "users.roles.manage"
+	PermSessionsRevoke      = "sessions.revoke"
+	PermStatsRead           = "stats.read"
+	PermTablesManage        = "tables.manage"          // Zallar, stollar va QR tokenlar
+	PermTableSessionsManage = "tables.sessions.manage" // Stol hisoblarini ko'rish va yopish (ofitsiant)
+	PermDeliveryZonesManage = "delivery_zones.manage"  // Yetkazib berish hududlari va narxlari
+)
+
+// Role rol va unga biriktirilgan ruxsatlar
+type Role struct {
+	RoleName    string   `json:"role_name" db:"role_name"`
+	Description string   `json:"description" db:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateUserRoleRequest PUT /api/admin/users/{telegramID}/role so'rov formati
+type UpdateUserRoleRequest struct {
+	Role string `json:"role"`
+}
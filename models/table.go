@@ -0,0 +1,160 @@
+package models
+
+import "time"
+
+// Hall restoran zali (masalan, "Zal-1", "Terassa")
+type Hall struct {
+	HallID     int       `json:"hall_id" db:"hall_id"`
+	HallName   string    `json:"hall_name" db:"hall_name"`
+	TableCount int       `json:"table_count"` // Zaldagi stollar soni (nofaol stollar ham)
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Table zaldagi stol. QR kod tokeni orqali mijoz "zalga" buyurtma beradi.
+type Table struct {
+	TableID     int       `json:"table_id" db:"table_id"`
+	TableName   string    `json:"table_name" db:"table_name"`
+	QRCodeToken string    `json:"qr_code_token" db:"qr_code_token"`
+	HallID      *int      `json:"hall_id,omitempty" db:"hall_id"`
+	HallName    *string   `json:"hall_name,omitempty"`
+	Capacity    int       `json:"capacity" db:"capacity"`
+	IsActive    bool      `json:"is_active" db:"is_active"` // Nofaol stolga QR orqali buyurtma berilmaydi
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// HallRequest POST/PUT /api/admin/halls so'rov formati
+type HallRequest struct {
+	HallName string `json:"hall_name"`
+}
+
+// CreateTableRequest POST /api/admin/tables so'rov formati. QR token server tomonidan yaratiladi.
+type CreateTableRequest struct {
+	TableName string `json:"table_name"`
+	HallID    *int   `json:"hall_id"`
+	Capacity  int    `json:"capacity"`
+	IsActive  *bool  `json:"is_active,omitempty"` // Ko'rsatilmasa stol faol bo'ladi
+}
+
+// UpdateTableRequest PUT /api/admin/tables/{tableID} so'rov formati. Faqat yuborilgan maydonlar o'zgaradi.
+type UpdateTableRequest struct {
+	TableName  *string `json:"table_name,omitempty"`
+	HallID     *int    `json:"hall_id,omitempty"`
+	RemoveHall bool    `json:"remove_hall,omitempty"` // Stolni zaldan chiqarish
+	Capacity   *int    `json:"capacity,omitempty"`
+	IsActive   *bool   `json:"is_active,omitempty"`
+}
+
+// TableImportResult table.json formatidagi fayldan import natijasi
+type TableImportResult struct {
+	Created int `json:"created"`
+	Skipped int `json:"skipped"` // Shu nomli stol bazada allaqachon bor
+}
+
+// TableSession stolning ochiq hisobi: stol QR kodi birinchi skanerlanganda ochiladi va ofitsiant yopguncha
+// shu stoldagi barcha mehmonlarning buyurtmalari unga biriktiriladi.
+type TableSession struct {
+	SessionID int        `json:"session_id" db:"session_id"`
+	TableID   int        `json:"table_id" db:"table_id"`
+	TableName string     `json:"table_name"`
+	HallName  *string    `json:"hall_name,omitempty"`
+	OpenedBy  *int64     `json:"opened_by,omitempty" db:"opened_by"` // QR kodni birinchi skanerlagan mehmon
+	OpenedAt  time.Time  `json:"opened_at" db:"opened_at"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty" db:"closed_at"` // nil - sessiya ochiq
+	ClosedBy  *int64     `json:"closed_by,omitempty" db:"closed_by"`
+}
+
+// IsOpen sessiya hali yopilmaganligini bildiradi
+func (s *TableSession) IsOpen() bool {
+	return s.ClosedAt == nil
+}
+
+// TableSessionBill stol sessiyasining umumiy hisobi: barcha buyurtmalar va bekor qilinmaganlari bo'yicha jami summalar
+type TableSessionBill struct {
+	Session        TableSession           `json:"session"`
+	Orders         []OrderDetailsResponse `json:"orders"`
+	OrderCount     int                    `json:"order_count"` // Bekor qilinmagan buyurtmalar soni
+	Subtotal       float64                `json:"subtotal"`
+	ServiceCharge  float64                `json:"service_charge"`
+	DiscountAmount float64                `json:"discount_amount"`
+	TotalPrice     float64                `json:"total_price"`
+}
+
+// ScanTableRequest POST /api/tables/scan so'rov formati (QR koddagi stol tokeni)
+type ScanTableRequest struct {
+	Token string `json:"token"`
+}
+
+// Hisobni bo'lish usullari
+const (
+	BillSplitByGuest = "guest" // Har bir mehmon o'z buyurtmalari uchun to'laydi
+	BillSplitEven    = "even"  // Jami summa N ta teng qismga bo'linadi
+	BillSplitByItems = "items" // Har bir buyurtma qatori to'lovchilarga biriktiriladi
+)
+
+// BillSplitAssignment buyurtma qatorini to'lovchilarga biriktirish; bir nechta to'lovchi bo'lsa qator teng bo'linadi
+type BillSplitAssignment struct {
+	OrderItemID int      `json:"order_item_id"`
+	Payers      []string `json:"payers"`
+}
+
+// BillSplitRequest POST /api/table-sessions/{sessionID}/split so'rov formati
+type BillSplitRequest struct {
+	Mode        string                `json:"mode"`                  // guest, even yoki items
+	Parts       int                   `json:"parts,omitempty"`       // Faqat "even" uchun
+	Assignments []BillSplitAssignment `json:"assignments,omitempty"` // Faqat "items" uchun
+}
+
+// BillSplitPart alohida to'lanadigan qism
+type BillSplitPart struct {
+	Payer        string  `json:"payer"`
+	TelegramID   *int64  `json:"telegram_id,omitempty"`    // "guest" usulida mehmon ID'si
+	OrderIDs     []int   `json:"order_ids,omitempty"`      // "guest" usulida mehmon buyurtmalari
+	OrderItemIDs []int   `json:"order_item_ids,omitempty"` // "items" usulida biriktirilgan qatorlar
+	Amount       float64 `json:"amount"`
+}
+
+// BillSplit bo'lingan hisob: qismlar yig'indisi har doim stolning jami summasiga teng
+type BillSplit struct {
+	SessionID  int             `json:"session_id"`
+	Mode       string          `json:"mode"`
+	TotalPrice float64         `json:"total_price"`
+	Parts      []BillSplitPart `json:"parts"`
+}
+
+// Stoldan chaqiruv turlari
+const (
+	TableRequestCallWaiter = "call_waiter" // Ofitsiantni chaqirish
+	TableRequestBringBill  = "bring_bill"  // Hisobni olib kelish
+)
+
+// TableRequest mehmonning stoldan ofitsiantni yoki hisobni chaqiruvi.
+// Qabul qilinmaguncha qayta bosishlar yangi chaqiruv yaratmaydi, faqat RepeatCount oshadi.
+type TableRequest struct {
+	RequestID       int        `json:"request_id" db:"request_id"`
+	TableID         int        `json:"table_id" db:"table_id"`
+	TableName       string     `json:"table_name"`
+	HallName        *string    `json:"hall_name,omitempty"`
+	SessionID       *int       `json:"session_id,omitempty" db:"session_id"` // Stolning o'sha paytdagi ochiq hisobi
+	RequestType     string     `json:"request_type" db:"request_type"`
+	TelegramID      *int64     `json:"telegram_id,omitempty" db:"telegram_id"`
+	RepeatCount     int        `json:"repeat_count" db:"repeat_count"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	LastRequestedAt time.Time  `json:"last_requested_at" db:"last_requested_at"`
+	StaffMessageID  *int       `json:"-" db:"staff_message_id"`
+	AcknowledgedAt  *time.Time `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+	AcknowledgedBy  *int64     `json:"acknowledged_by,omitempty" db:"acknowledged_by"`
+}
+
+// PlaceTableRequest POST /api/tables/requests so'rov formati
+type PlaceTableRequest struct {
+	Token string `json:"token"` // QR koddagi stol tokeni
+	Type  string `json:"type"`  // call_waiter yoki bring_bill
+}
+
+// TableRequestResponse chaqiruv natijasi; Duplicate - chaqiruv avval yuborilgan va hali qabul qilinmagan
+type TableRequestResponse struct {
+	Request   TableRequest `json:"request"`
+	Duplicate bool         `json:"duplicate"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type User struct {
 	UserID       int       `json:"user_id" db:"userid"`
@@ -9,25 +12,99 @@ type User struct {
 	Username     string    `json:"username" db:"username"`
 	LanguageCode string    `json:"language_code" db:"language_code"`
 	PhoneNumber  string    `json:"phone_number" db:"phone"`
-	Role         string    `json:"role" db:"role"` // "user", "admin", "superadmin"
+	Role         string    `json:"role" db:"role"` // roles jadvalidagi rol nomi (customer, waiter, ..., superadmin)
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Principal autentifikatsiyadan o'tgan so'rov egasi. AuthMiddleware uni kontekstga qo'yadi.
+type Principal struct {
+	TelegramID  int64
+	Role        string
+	SessionID   string
+	Permissions []string
+}
+
+// HasPermission foydalanuvchida berilgan ruxsat borligini tekshiradi
+func (p *Principal) HasPermission(permission string) bool {
+	for _, perm := range p.Permissions {
+		if perm == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// LoginCode bot orqali foydalanuvchiga berilgan bir martalik kirish kodi
+type LoginCode struct {
+	CodeID     int        `json:"code_id" db:"code_id"`
+	TelegramID int64      `json:"telegram_id" db:"telegram_id"`
+	CodeHash   string     `json:"-" db:"code_hash"` // Kodning o'zi emas, faqat hash saqlanadi
+	Attempts   int        `json:"attempts" db:"attempts"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt     *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// LoginThrottle telefon raqami yoki IP manzil bo'yicha noto'g'ri kirish urinishlari holati
+type LoginThrottle struct {
+	Scope        string     `json:"scope" db:"scope"` // "phone" yoki "ip"
+	Key          string     `json:"key" db:"throttle_key"`
+	FailedCount  int        `json:"failed_count" db:"failed_count"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty" db:"locked_until"`
+	LastFailedAt *time.Time `json:"last_failed_at,omitempty" db:"last_failed_at"`
+}
+
 // --- Yangilangan LoginRequest ---
 type LoginRequest struct {
 	PhoneNumber string `json:"phone_number"` // Foydalanuvchi telefon raqami
-	Code        string `json:"code"`         // Telegram ID oxirgi 4 raqami
+	Code        string `json:"code"`         // Bot yuborgan bir martalik kod
 }
 
 // LoginResponse muvaffaqiyatli kirishdan keyin qaytariladigan javob
 type LoginResponse struct {
-	Token string `json:"token"` // JWT tokeni
+	Token            string    `json:"token"` // JWT access tokeni
+	TokenExpiresAt   time.Time `json:"token_expires_at"`
+	RefreshToken     string    `json:"refresh_token"` // Yangi access token olish uchun (har safar almashtiriladi)
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// TelegramAuthRequest POST /api/auth/telegram so'rov formati.
+// Mini App uchun init_data, Login Widget uchun widget maydoni yuboriladi.
+type TelegramAuthRequest struct {
+	InitData string                     `json:"init_data,omitempty"` // Telegram.WebApp.initData qatori
+	Widget   map[string]json.RawMessage `json:"widget,omitempty"`    // Login Widget qaytargan maydonlar (id, first_name, ..., hash)
+}
+
+// RefreshTokenRequest POST /api/token/refresh so'rov formati
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ClientInfo so'rov yuborgan mijoz haqidagi ma'lumot (cheklovlar va sessiyalar uchun)
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// Session foydalanuvchining tizimga kirish sessiyasi (refresh token bilan bog'langan)
+type Session struct {
+	SessionID        string     `json:"session_id" db:"session_id"`
+	TelegramID       int64      `json:"telegram_id" db:"telegram_id"`
+	RefreshTokenHash string     `json:"-" db:"refresh_token_hash"`
+	UserAgent        *string    `json:"user_agent,omitempty" db:"user_agent"`
+	IPAddress        *string    `json:"ip_address,omitempty" db:"ip_address"`
+	ExpiresAt        time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokeReason     *string    `json:"revoke_reason,omitempty" db:"revoke_reason"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt       time.Time  `json:"last_used_at" db:"last_used_at"`
 }
 
 type CreateUserRequest struct {
-	UserID    int64  `json:"user_id"` // Telegram ID
-	Username  string `json:"username"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
+	UserID      int64  `json:"user_id"` // Telegram ID
+	Username    string `json:"username"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	PhoneNumber string `json:"phone_number,omitempty"` // Ixtiyoriy, E.164 ga keltiriladi
 }
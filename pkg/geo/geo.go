@@ -0,0 +1,84 @@
+package geo
+
+import "math"
+
+// boundaryEpsilon nuqta ko'pburchak chegarasida yotganini aniqlashdagi xatolik (gradus kvadratida, ~1 sm dan ancha kichik)
+const boundaryEpsilon = 1e-12
+
+// Point geografik nuqta (WGS84, gradusda)
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Valid nuqta koordinatalari mumkin bo'lgan oraliqda ekanligini tekshiradi
+func (p Point) Valid() bool {
+	return !math.IsNaN(p.Lat) && !math.IsNaN(p.Lng) &&
+		p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// Ring yopiq chiziq (birinchi va oxirgi nuqtalar bir xil)
+type Ring []Point
+
+// Polygon ko'pburchak: birinchi halqa tashqi chegara, qolganlari ichidagi teshiklar
+type Polygon []Ring
+
+// MultiPolygon bir nechta ko'pburchakdan iborat hudud
+type MultiPolygon []Polygon
+
+// Contains nuqta hududdagi biror ko'pburchak ichida yoki chegarasida ekanligini tekshiradi
+func (m MultiPolygon) Contains(p Point) bool {
+	for _, polygon := range m {
+		if polygon.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains nuqta tashqi halqa ichida (chegara ham hisobga kiradi) va hech bir teshikning ichida emasligini tekshiradi.
+// Teshik chegarasidagi nuqta ko'pburchakka tegishli hisoblanadi.
+func (pg Polygon) Contains(p Point) bool {
+	if len(pg) == 0 {
+		return false
+	}
+	if inside, boundary := pg[0].locate(p); !inside && !boundary {
+		return false
+	}
+	for _, hole := range pg[1:] {
+		if inside, boundary := hole.locate(p); inside && !boundary {
+			return false
+		}
+	}
+	return true
+}
+
+// locate nurni tashlash (ray casting) usuli: nuqtadan sharq tomonga chiqqan nur halqa qirralarini toq marta
+// kesib o'tsa nuqta ichkarida. Koordinatalar tekislikdagi x=Lng, y=Lat sifatida olinadi, bu shahar
+// miqyosidagi hududlar uchun yetarlicha aniq. boundary - nuqta biror qirra ustida yotadi.
+func (r Ring) locate(p Point) (inside, boundary bool) {
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		a, b := r[i], r[j]
+		if onSegment(p, a, b) {
+			return true, true
+		}
+		// Qirra nurni kesib o'tadimi (yuqori uchi qirraga kiradi, pastki uchi kirmaydi - uchlar ikki marta sanalmaydi)
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) {
+			x := (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat) + a.Lng
+			if p.Lng < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside, false
+}
+
+// onSegment nuqta a-b kesma ustida yotishini tekshiradi
+func onSegment(p, a, b Point) bool {
+	cross := (b.Lng-a.Lng)*(p.Lat-a.Lat) - (b.Lat-a.Lat)*(p.Lng-a.Lng)
+	if math.Abs(cross) > boundaryEpsilon {
+		return false
+	}
+	return p.Lng >= math.Min(a.Lng, b.Lng) && p.Lng <= math.Max(a.Lng, b.Lng) &&
+		p.Lat >= math.Min(a.Lat, b.Lat) && p.Lat <= math.Max(a.Lat, b.Lat)
+}
@@ -0,0 +1,213 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// pt tekislikdagi (x=Lng, y=Lat) nuqtani yasaydi
+func pt(x, y float64) Point {
+	return Point{Lat: y, Lng: x}
+}
+
+// ring nuqtalardan yopiq halqa yasaydi
+func ring(points ...Point) Ring {
+	return append(Ring(points), points[0])
+}
+
+var (
+	// square 0..10 kvadrat, o'rtasida 4..6 teshik bilan
+	square = Polygon{
+		ring(pt(0, 0), pt(10, 0), pt(10, 10), pt(0, 10)),
+		ring(pt(4, 4), pt(6, 4), pt(6, 6), pt(4, 6)),
+	}
+	// diamond uchlari nur bilan bir chiziqda yotadigan romb
+	diamond = Polygon{ring(pt(5, 0), pt(10, 5), pt(5, 10), pt(0, 5))}
+)
+
+func TestPolygonContains(t *testing.T) {
+	tests := []struct {
+		name    string
+		polygon Polygon
+		point   Point
+		want    bool
+	}{
+		{"inside", square, pt(2, 2), true},
+		{"outside", square, pt(11, 5), false},
+		{"outside below", square, pt(5, -0.001), false},
+		{"on edge", square, pt(0, 5), true},
+		{"on top edge", square, pt(5, 10), true},
+		{"on vertex", square, pt(0, 0), true},
+		{"on opposite vertex", square, pt(10, 10), true},
+		{"inside hole", square, pt(5, 5), false},
+		{"on hole edge", square, pt(4, 5), true},
+		{"on hole vertex", square, pt(6, 6), true},
+		{"between hole and outer edge", square, pt(8, 5), true},
+		{"ray through vertices, inside", diamond, pt(2, 5), true},
+		{"ray through vertices, outside", diamond, pt(-1, 5), false},
+		{"near vertex outside", diamond, pt(9, 9), false},
+		{"empty polygon", Polygon{}, pt(0, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.polygon.Contains(tt.point); got != tt.want {
+				t.Errorf("Contains(%+v) = %v, kutilgan %v", tt.point, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingLocate(t *testing.T) {
+	outer := square[0]
+	tests := []struct {
+		name         string
+		point        Point
+		wantInside   bool
+		wantBoundary bool
+	}{
+		{"inside", pt(3, 3), true, false},
+		{"outside", pt(-3, 3), false, false},
+		{"on edge", pt(10, 3), true, true},
+		{"on vertex", pt(0, 10), true, true},
+		{"on edge line, beyond segment", pt(12, 0), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inside, boundary := outer.locate(tt.point)
+			if inside != tt.wantInside || boundary != tt.wantBoundary {
+				t.Errorf("locate(%+v) = (%v, %v), kutilgan (%v, %v)", tt.point, inside, boundary, tt.wantInside, tt.wantBoundary)
+			}
+		})
+	}
+}
+
+func TestOnSegment(t *testing.T) {
+	a, b := pt(0, 0), pt(10, 10)
+	tests := []struct {
+		point Point
+		want  bool
+	}{
+		{pt(5, 5), true},
+		{pt(0, 0), true},
+		{pt(10, 10), true},
+		{pt(5, 5.001), false},
+		{pt(11, 11), false},
+		{pt(-1, -1), false},
+	}
+	for _, tt := range tests {
+		if got := onSegment(tt.point, a, b); got != tt.want {
+			t.Errorf("onSegment(%+v) = %v, kutilgan %v", tt.point, got, tt.want)
+		}
+	}
+}
+
+func TestMultiPolygonContains(t *testing.T) {
+	// Ikkita alohida kvadrat: 0..10 (teshik bilan) va 20..30
+	area := MultiPolygon{square, Polygon{ring(pt(20, 0), pt(30, 0), pt(30, 10), pt(20, 10))}}
+	tests := []struct {
+		name  string
+		point Point
+		want  bool
+	}{
+		{"first polygon", pt(1, 1), true},
+		{"second polygon", pt(25, 5), true},
+		{"between polygons", pt(15, 5), false},
+		{"hole of first polygon", pt(5, 5), false},
+		{"edge of second polygon", pt(20, 5), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := area.Contains(tt.point); got != tt.want {
+				t.Errorf("Contains(%+v) = %v, kutilgan %v", tt.point, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGeoJSON(t *testing.T) {
+	const (
+		polygon      = `{"type":"Polygon","coordinates":[[[69.2,41.3],[69.3,41.3],[69.3,41.4],[69.2,41.4],[69.2,41.3]]]}`
+		multiPolygon = `{"type":"MultiPolygon","coordinates":[[[[69.2,41.3],[69.3,41.3],[69.3,41.4],[69.2,41.3]]],[[[70,42],[70.1,42],[70.1,42.1],[70,42]]]]}`
+	)
+	tests := []struct {
+		name         string
+		data         string
+		wantPolygons int
+		wantErr      bool
+	}{
+		{"Polygon", polygon, 1, false},
+		{"MultiPolygon", multiPolygon, 2, false},
+		{"Feature", `{"type":"Feature","properties":{"name":"Markaz"},"geometry":` + polygon + `}`, 1, false},
+		{"FeatureCollection", `{"type":"FeatureCollection","features":[` +
+			`{"type":"Feature","geometry":` + polygon + `},{"type":"Feature","geometry":` + multiPolygon + `}]}`, 3, false},
+		{"unclosed ring is closed", `{"type":"Polygon","coordinates":[[[69.2,41.3],[69.3,41.3],[69.3,41.4]]]}`, 1, false},
+		{"ring with two points", `{"type":"Polygon","coordinates":[[[69.2,41.3],[69.3,41.3]]]}`, 0, true},
+		{"latitude out of range", `{"type":"Polygon","coordinates":[[[69.2,91],[69.3,41.3],[69.3,41.4]]]}`, 0, true},
+		{"longitude out of range", `{"type":"Polygon","coordinates":[[[-181,41.3],[69.3,41.3],[69.3,41.4]]]}`, 0, true},
+		{"position without latitude", `{"type":"Polygon","coordinates":[[[69.2],[69.3,41.3],[69.3,41.4]]]}`, 0, true},
+		{"no rings", `{"type":"Polygon","coordinates":[]}`, 0, true},
+		{"Feature without geometry", `{"type":"Feature","properties":{}}`, 0, true},
+		{"empty FeatureCollection", `{"type":"FeatureCollection","features":[]}`, 0, true},
+		{"unsupported type", `{"type":"Point","coordinates":[69.2,41.3]}`, 0, true},
+		{"not JSON", `polygon`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			area, err := ParseGeoJSON([]byte(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidGeoJSON) {
+					t.Fatalf("xato = %v, kutilgan %v", err, ErrInvalidGeoJSON)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(area) != tt.wantPolygons {
+				t.Fatalf("ko'pburchaklar soni = %d, kutilgan %d", len(area), tt.wantPolygons)
+			}
+			for _, polygon := range area {
+				for _, r := range polygon {
+					if len(r) < 4 || r[0] != r[len(r)-1] {
+						t.Errorf("halqa yopilmagan: %+v", r)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseGeoJSONCoordinateOrder(t *testing.T) {
+	// GeoJSON da [longitude, latitude] tartibi: Toshkent markazi ichkarida, teskari tartibdagisi emas
+	area, err := ParseGeoJSON([]byte(`{"type":"Polygon","coordinates":[[[69.2,41.2],[69.4,41.2],[69.4,41.4],[69.2,41.4]]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !area.Contains(Point{Lat: 41.31, Lng: 69.28}) {
+		t.Error("Toshkent markazi hududga tushmadi")
+	}
+	if area.Contains(Point{Lat: 69.28, Lng: 41.31}) {
+		t.Error("teskari tartibdagi nuqta hududga tushdi")
+	}
+}
+
+func TestMultiPolygonMarshalRoundTrip(t *testing.T) {
+	area := MultiPolygon{square, diamond}
+	data, err := json.Marshal(area)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseGeoJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 2 || len(parsed[0]) != 2 || len(parsed[0][1]) != len(square[1]) {
+		t.Fatalf("qayta o'qilgan hudud mos emas: %+v", parsed)
+	}
+	for _, p := range []Point{pt(2, 2), pt(5, 5), pt(4, 5)} {
+		if parsed.Contains(p) != area.Contains(p) {
+			t.Errorf("Contains(%+v) qayta o'qilgandan keyin o'zgardi", p)
+		}
+	}
+}
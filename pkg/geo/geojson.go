@@ -0,0 +1,129 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidGeoJSON = errors.New("GeoJSON noto'g'ri")
+
+// geoJSONObject GeoJSON obyektining (geometriya, Feature yoki FeatureCollection) kerakli maydonlari
+type geoJSONObject struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSONObject  `json:"geometry"`
+	Features    []geoJSONObject `json:"features"`
+}
+
+// ParseGeoJSON Polygon, MultiPolygon, ularni o'z ichiga olgan Feature yoki FeatureCollection ni o'qiydi.
+// Koordinatalar GeoJSON tartibida ([longitude, latitude]) bo'lishi kerak; yopilmagan halqalar avtomatik yopiladi.
+// FeatureCollection dagi barcha ko'pburchaklar bitta hududga birlashtiriladi.
+func ParseGeoJSON(data []byte) (MultiPolygon, error) {
+	var object geoJSONObject
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGeoJSON, err)
+	}
+	area, err := object.multiPolygon()
+	if err != nil {
+		return nil, err
+	}
+	if len(area) == 0 {
+		return nil, fmt.Errorf("%w: ko'pburchak topilmadi", ErrInvalidGeoJSON)
+	}
+	return area, nil
+}
+
+// multiPolygon obyekt turiga qarab ko'pburchaklarni yig'adi
+func (o *geoJSONObject) multiPolygon() (MultiPolygon, error) {
+	switch o.Type {
+	case "Polygon":
+		var coordinates [][][]float64
+		if err := json.Unmarshal(o.Coordinates, &coordinates); err != nil {
+			return nil, fmt.Errorf("%w: Polygon koordinatalari: %v", ErrInvalidGeoJSON, err)
+		}
+		polygon, err := newPolygon(coordinates)
+		if err != nil {
+			return nil, err
+		}
+		return MultiPolygon{polygon}, nil
+	case "MultiPolygon":
+		var coordinates [][][][]float64
+		if err := json.Unmarshal(o.Coordinates, &coordinates); err != nil {
+			return nil, fmt.Errorf("%w: MultiPolygon koordinatalari: %v", ErrInvalidGeoJSON, err)
+		}
+		area := make(MultiPolygon, 0, len(coordinates))
+		for _, polygonCoordinates := range coordinates {
+			polygon, err := newPolygon(polygonCoordinates)
+			if err != nil {
+				return nil, err
+			}
+			area = append(area, polygon)
+		}
+		return area, nil
+	case "Feature":
+		if o.Geometry == nil {
+			return nil, fmt.Errorf("%w: Feature geometriyasi yo'q", ErrInvalidGeoJSON)
+		}
+		return o.Geometry.multiPolygon()
+	case "FeatureCollection":
+		var area MultiPolygon
+		for i := range o.Features {
+			polygons, err := o.Features[i].multiPolygon()
+			if err != nil {
+				return nil, err
+			}
+			area = append(area, polygons...)
+		}
+		return area, nil
+	default:
+		return nil, fmt.Errorf("%w: '%s' turi qo'llab-quvvatlanmaydi (Polygon, MultiPolygon, Feature, FeatureCollection)", ErrInvalidGeoJSON, o.Type)
+	}
+}
+
+// newPolygon halqalarni tekshiradi va nuqtalarga aylantiradi
+func newPolygon(coordinates [][][]float64) (Polygon, error) {
+	if len(coordinates) == 0 {
+		return nil, fmt.Errorf("%w: ko'pburchakda halqa yo'q", ErrInvalidGeoJSON)
+	}
+	polygon := make(Polygon, 0, len(coordinates))
+	for _, ringCoordinates := range coordinates {
+		ring := make(Ring, 0, len(ringCoordinates)+1)
+		for _, position := range ringCoordinates {
+			if len(position) < 2 {
+				return nil, fmt.Errorf("%w: nuqta [longitude, latitude] ko'rinishida bo'lishi kerak", ErrInvalidGeoJSON)
+			}
+			point := Point{Lng: position[0], Lat: position[1]}
+			if !point.Valid() {
+				return nil, fmt.Errorf("%w: [%g, %g] nuqtasi koordinatalar oralig'idan tashqarida", ErrInvalidGeoJSON, position[0], position[1])
+			}
+			ring = append(ring, point)
+		}
+		if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+			ring = append(ring, ring[0])
+		}
+		if len(ring) < 4 {
+			return nil, fmt.Errorf("%w: halqada kamida 3 ta turli nuqta bo'lishi kerak", ErrInvalidGeoJSON)
+		}
+		polygon = append(polygon, ring)
+	}
+	return polygon, nil
+}
+
+// MarshalJSON hududni GeoJSON MultiPolygon geometriyasi ko'rinishida yozadi (bazada shu ko'rinishda saqlanadi)
+func (m MultiPolygon) MarshalJSON() ([]byte, error) {
+	coordinates := make([][][][2]float64, len(m))
+	for i, polygon := range m {
+		coordinates[i] = make([][][2]float64, len(polygon))
+		for j, ring := range polygon {
+			coordinates[i][j] = make([][2]float64, len(ring))
+			for k, point := range ring {
+				coordinates[i][j][k] = [2]float64{point.Lng, point.Lat}
+			}
+		}
+	}
+	return json.Marshal(struct {
+		Type        string           `json:"type"`
+		Coordinates [][][][2]float64 `json:"coordinates"`
+	}{Type: "MultiPolygon", Coordinates: coordinates})
+}
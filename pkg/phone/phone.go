@@ -0,0 +1,47 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// DefaultCountryCode mamlakat kodi ko'rsatilmagan 9 xonali (mahalliy) raqamlar uchun qo'shiladi
+const DefaultCountryCode = "998"
+
+var ErrInvalidPhone = errors.New("telefon raqami noto'g'ri formatda")
+
+// Normalize telefon raqamini E.164 ko'rinishiga keltiradi: "+998 90 123-45-67", "998901234567",
+// "00998901234567" va "901234567" hammasi "+998901234567" bo'ladi.
+// Bu qoidalar bazadagi eski raqamlarni to'g'rilovchi migratsiya bilan bir xil bo'lishi kerak.
+func Normalize(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", ErrInvalidPhone
+	}
+
+	var digits strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' || r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+			// Formatlash belgilari e'tiborsiz qoldiriladi
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+
+	number := digits.String()
+	if strings.HasPrefix(raw, "00") {
+		number = strings.TrimPrefix(number, "00") // Xalqaro "00" prefiksi "+" bilan teng
+	}
+	if len(number) == 9 {
+		number = DefaultCountryCode + number
+	}
+
+	// E.164: mamlakat kodi bilan birga 8 dan 15 gacha raqam, boshida 0 bo'lmaydi
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	return "+" + number, nil
+}
@@ -0,0 +1,161 @@
+package telegram_auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxClockSkew server va Telegram soatlari orasidagi ruxsat etilgan farq: auth_date bundan ko'proq kelajakda bo'lmasligi kerak
+const maxClockSkew = time.Minute
+
+var (
+	ErrMissingHash   = errors.New("hash maydoni topilmadi")
+	ErrInvalidHash   = errors.New("imzo noto'g'ri")
+	ErrExpiredAuth   = errors.New("auth_date eskirgan")
+	ErrFutureAuth    = errors.New("auth_date kelajakdagi vaqt")
+	ErrMissingUser   = errors.New("foydalanuvchi ma'lumotlari topilmadi")
+	ErrInvalidFormat = errors.New("ma'lumotlar formati noto'g'ri")
+)
+
+// User Telegram tomonidan imzolangan foydalanuvchi ma'lumotlari
+type User struct {
+	ID           int64  `json:"id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code"`
+	PhotoURL     string `json:"photo_url"`
+}
+
+// ValidateWebAppInitData Telegram Mini App'dan kelgan initData qatorini tekshiradi.
+// Kalit: HMAC_SHA256("WebAppData", botToken). https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app
+func ValidateWebAppInitData(initData, botToken string, maxAge time.Duration) (*User, error) {
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+
+	fields := make(map[string]string, len(values))
+	for key := range values {
+		fields[key] = values.Get(key)
+	}
+
+	secret := hmacSHA256([]byte("WebAppData"), []byte(botToken))
+	if err := verify(fields, secret, maxAge); err != nil {
+		return nil, err
+	}
+
+	rawUser := fields["user"]
+	if rawUser == "" {
+		return nil, ErrMissingUser
+	}
+	var user User
+	if err := json.Unmarshal([]byte(rawUser), &user); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	if user.ID == 0 {
+		return nil, ErrMissingUser
+	}
+	return &user, nil
+}
+
+// ValidateLoginWidget Telegram Login Widget ma'lumotlarini tekshiradi.
+// Kalit: SHA256(botToken). https://core.telegram.org/widgets/login#checking-authorization
+func ValidateLoginWidget(fields map[string]string, botToken string, maxAge time.Duration) (*User, error) {
+	secret := sha256.Sum256([]byte(botToken))
+	if err := verify(fields, secret[:], maxAge); err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.ParseInt(fields["id"], 10, 64)
+	if err != nil || id == 0 {
+		return nil, ErrMissingUser
+	}
+	return &User{
+		ID:        id,
+		FirstName: fields["first_name"],
+		LastName:  fields["last_name"],
+		Username:  fields["username"],
+		PhotoURL:  fields["photo_url"],
+	}, nil
+}
+
+// WidgetFields Login Widget JSON maydonlarini imzolangan ko'rinishdagi qatorlarga aylantiradi.
+// Sonlar JSON dagi yozilishicha olinadi (float64 ga o'tkazilsa id=123456789 "1.23456789e+08" bo'lib, imzo mos kelmaydi),
+// satrlar qo'shtirnoqsiz, null maydonlar esa tashlab ketiladi (Telegram ularni imzoga qo'shmaydi).
+func WidgetFields(raw map[string]json.RawMessage) (map[string]string, error) {
+	fields := make(map[string]string, len(raw))
+	for key, value := range raw {
+		value = json.RawMessage(strings.TrimSpace(string(value)))
+		switch {
+		case len(value) == 0 || string(value) == "null":
+			continue
+		case value[0] == '"':
+			var text string
+			if err := json.Unmarshal(value, &text); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, key)
+			}
+			fields[key] = text
+		case value[0] == '{' || value[0] == '[':
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, key)
+		default:
+			fields[key] = string(value) // Son yoki true/false
+		}
+	}
+	return fields, nil
+}
+
+// verify hash maydonidan tashqari barcha maydonlardan data-check-string tuzadi, imzoni va auth_date ni tekshiradi
+func verify(fields map[string]string, secret []byte, maxAge time.Duration) error {
+	receivedHash := fields["hash"]
+	if receivedHash == "" {
+		return ErrMissingHash
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+fields[key])
+	}
+	dataCheckString := strings.Join(pairs, "\n")
+
+	expected := hex.EncodeToString(hmacSHA256(secret, []byte(dataCheckString)))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(receivedHash))) {
+		return ErrInvalidHash
+	}
+
+	authDate, err := strconv.ParseInt(fields["auth_date"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: auth_date", ErrInvalidFormat)
+	}
+	age := time.Since(time.Unix(authDate, 0))
+	if age < -maxClockSkew {
+		return ErrFutureAuth
+	}
+	if maxAge > 0 && age > maxAge {
+		return ErrExpiredAuth
+	}
+	return nil
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,165 @@
+package telegram_auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBotToken = "123456:TEST-bot-token"
+
+// sign maydonlardan data-check-string tuzib, Telegram kabi hash qaytaradi
+func sign(fields map[string]string, secret []byte) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+fields[key])
+	}
+	return hex.EncodeToString(hmacSHA256(secret, []byte(strings.Join(pairs, "\n"))))
+}
+
+// signedInitData Mini App initData qatorini bot tokeni bilan imzolaydi
+func signedInitData(authDate time.Time) url.Values {
+	fields := map[string]string{
+		"query_id":  "AAHdF6IQAAAAAN0XohDhrOrc",
+		"user":      `{"id":123456789,"first_name":"Ali","last_name":"Valiyev","username":"ali","language_code":"uz"}`,
+		"auth_date": strconv.FormatInt(authDate.Unix(), 10),
+	}
+	values := url.Values{}
+	for key, value := range fields {
+		values.Set(key, value)
+	}
+	values.Set("hash", sign(fields, hmacSHA256([]byte("WebAppData"), []byte(testBotToken))))
+	return values
+}
+
+// signedWidgetJSON Login Widget javobini Telegram yuboradigan JSON ko'rinishida (id va auth_date son sifatida) yasaydi
+func signedWidgetJSON(t *testing.T, authDate time.Time) map[string]json.RawMessage {
+	t.Helper()
+	fields := map[string]string{
+		"id":         "123456789",
+		"first_name": "Ali",
+		"username":   "ali",
+		"photo_url":  "https://t.me/i/userpic/320/ali.jpg",
+		"auth_date":  strconv.FormatInt(authDate.Unix(), 10),
+	}
+	secret := sha256.Sum256([]byte(testBotToken))
+	hash := sign(fields, secret[:])
+
+	body := `{"id":123456789,"first_name":"Ali","username":"ali","photo_url":"https://t.me/i/userpic/320/ali.jpg",` +
+		`"auth_date":` + fields["auth_date"] + `,"hash":"` + hash + `"}`
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &raw); err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestValidateWebAppInitData(t *testing.T) {
+	now := time.Now()
+
+	tampered := signedInitData(now)
+	tampered.Set("user", `{"id":987654321,"first_name":"Hacker"}`)
+
+	badHash := signedInitData(now)
+	badHash.Set("hash", strings.Repeat("0", 64))
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{"valid", signedInitData(now).Encode(), nil},
+		{"tampered field", tampered.Encode(), ErrInvalidHash},
+		{"tampered hash", badHash.Encode(), ErrInvalidHash},
+		{"stale auth_date", signedInitData(now.Add(-48 * time.Hour)).Encode(), ErrExpiredAuth},
+		{"slightly future auth_date", signedInitData(now.Add(30 * time.Second)).Encode(), nil},
+		{"future auth_date", signedInitData(now.Add(time.Hour)).Encode(), ErrFutureAuth},
+		{"missing hash", "auth_date=1700000000&user=%7B%7D", ErrMissingHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := ValidateWebAppInitData(tt.data, testBotToken, 24*time.Hour)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("xato = %v, kutilgan %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (user.ID != 123456789 || user.Username != "ali" || user.LanguageCode != "uz") {
+				t.Fatalf("noto'g'ri foydalanuvchi: %+v", user)
+			}
+		})
+	}
+}
+
+func TestValidateLoginWidget(t *testing.T) {
+	now := time.Now()
+
+	tampered := signedWidgetJSON(t, now)
+	tampered["first_name"] = json.RawMessage(`"Hacker"`)
+
+	badHash := signedWidgetJSON(t, now)
+	badHash["hash"] = json.RawMessage(`"` + strings.Repeat("0", 64) + `"`)
+
+	tests := []struct {
+		name    string
+		raw     map[string]json.RawMessage
+		wantErr error
+	}{
+		{"valid with numeric id and auth_date", signedWidgetJSON(t, now), nil},
+		{"tampered field", tampered, ErrInvalidHash},
+		{"tampered hash", badHash, ErrInvalidHash},
+		{"stale auth_date", signedWidgetJSON(t, now.Add(-48*time.Hour)), ErrExpiredAuth},
+		{"future auth_date", signedWidgetJSON(t, now.Add(time.Hour)), ErrFutureAuth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := WidgetFields(tt.raw)
+			if err != nil {
+				t.Fatalf("WidgetFields: %v", err)
+			}
+			user, err := ValidateLoginWidget(fields, testBotToken, 24*time.Hour)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("xato = %v, kutilgan %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (user.ID != 123456789 || user.FirstName != "Ali") {
+				t.Fatalf("noto'g'ri foydalanuvchi: %+v", user)
+			}
+		})
+	}
+}
+
+func TestWidgetFields(t *testing.T) {
+	raw := map[string]json.RawMessage{
+		"id":         json.RawMessage(`123456789`),
+		"auth_date":  json.RawMessage(` 1700000000 `),
+		"first_name": json.RawMessage(`"O‘tkir"`),
+		"last_name":  json.RawMessage(`null`),
+	}
+	fields, err := WidgetFields(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"id": "123456789", "auth_date": "1700000000", "first_name": "O‘tkir"}
+	if len(fields) != len(want) {
+		t.Fatalf("maydonlar = %v, kutilgan %v", fields, want)
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("%s = %q, kutilgan %q", key, fields[key], value)
+		}
+	}
+
+	if _, err := WidgetFields(map[string]json.RawMessage{"id": json.RawMessage(`{"x":1}`)}); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("obyekt qiymat uchun xato = %v, kutilgan %v", err, ErrInvalidFormat)
+	}
+}
@@ -8,35 +8,49 @@ import (
 )
 
 type BasketOrderRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewBasketOrderRepository(db *sql.DB) *BasketOrderRepository {
 	return &BasketOrderRepository{db: db}
 }
 
-// AddToBasket savatchaga mahsulot qo'shadi yoki mavjud bo'lsa miqdorini oshiradi
-func (r *BasketOrderRepository) AddToBasket(telegramID int64, foodID int) (*models.BasketOrder, error) {
-	// Avval ushbu foydalanuvchi va ovqat ID'si bo'yicha mavjud buyurtmani qidiramiz
-	var existingOrder models.BasketOrder
-	err := r.db.QueryRow(`
-        SELECT basket_order_id, telegram_id, food_id, quantity, created_at, updated_at
+// WithTx berilgan tranzaksiya ichida ishlaydigan repository nusxasini qaytaradi
+func (r *BasketOrderRepository) WithTx(tx *sql.Tx) *BasketOrderRepository {
+	return &BasketOrderRepository{db: tx}
+}
+
+// basketOrderColumns savatcha qatorini o'qish uchun umumiy ustunlar ro'yxati (scanBasketOrder bilan bir xil tartibda)
+const basketOrderColumns = `basket_order_id, telegram_id, food_id, quantity, modifiers_key, note, unit_price_at_add, created_at, updated_at`
+
+// scanBasketOrder basketOrderColumns tartibidagi qatorni o'qiydi
+func scanBasketOrder(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*models.BasketOrder, error) {
+	var order models.BasketOrder
+	if err := scanner.Scan(&order.BasketOrderID, &order.TelegramID, &order.FoodID, &order.Quantity,
+		&order.ModifiersKey, &order.Note, &order.UnitPriceAtAdd, &order.CreatedAt, &order.UpdatedAt); err != nil {
+		return nil, err
+	}
+	order.ModifierIDs = models.ParseModifiersKey(order.ModifiersKey)
+	return &order, nil
+}
+
+// AddToBasket savatchaga mahsulot qo'shadi yoki xuddi shu modifikatorlar va izohli qator bo'lsa miqdorini oshiradi.
+// unitPrice faqat yangi qatorda saqlanadi: mavjud qatorning qo'shilgan paytdagi narxi o'zgarmaydi.
+func (r *BasketOrderRepository) AddToBasket(telegramID int64, foodID int, modifiersKey, note string, unitPrice float64) (*models.BasketOrder, error) {
+	// Avval ushbu foydalanuvchi, ovqat, modifikatorlar va izoh bo'yicha mavjud qatorni qidiramiz
+	existingOrder, err := scanBasketOrder(r.db.QueryRow(`
+        SELECT `+basketOrderColumns+`
         FROM basket_orders
-        WHERE telegram_id = $1 AND food_id = $2
-    `, telegramID, foodID).Scan(
-		&existingOrder.BasketOrderID,
-		&existingOrder.TelegramID,
-		&existingOrder.FoodID,
-		&existingOrder.Quantity,
-		&existingOrder.CreatedAt,
-		&existingOrder.UpdatedAt,
-	)
+        WHERE telegram_id = $1 AND food_id = $2 AND modifiers_key = $3 AND note = $4
+    `, telegramID, foodID, modifiersKey, note))
 
 	if err == sql.ErrNoRows {
 		// Agar mavjud bo'lmasa, yangi yozuv yaratamiz
 		stmt, err := r.db.Prepare(`
-            INSERT INTO basket_orders(telegram_id, food_id, quantity)
-            VALUES ($1, $2, 1)
+            INSERT INTO basket_orders(telegram_id, food_id, quantity, modifiers_key, note, unit_price_at_add)
+            VALUES ($1, $2, 1, $3, $4, $5)
             RETURNING basket_order_id
         `)
 		if err != nil {
@@ -46,19 +60,23 @@ func (r *BasketOrderRepository) AddToBasket(telegramID int64, foodID int) (*mode
 		defer stmt.Close()
 
 		var newID int
-		err = stmt.QueryRow(telegramID, foodID).Scan(&newID)
+		err = stmt.QueryRow(telegramID, foodID, modifiersKey, note, unitPrice).Scan(&newID)
 		if err != nil {
 			log.Printf("BasketOrder AddToBasket (insert) exec xatolik: %v", err)
 			return nil, err
 		}
 
 		newOrder := &models.BasketOrder{
-			BasketOrderID: newID,
-			TelegramID:    telegramID,
-			FoodID:        foodID,
-			Quantity:      1,
-			CreatedAt:     time.Now(),
-			UpdatedAt:     time.Now(),
+			BasketOrderID:  newID,
+			TelegramID:     telegramID,
+			FoodID:         foodID,
+			Quantity:       1,
+			ModifiersKey:   modifiersKey,
+			ModifierIDs:    models.ParseModifiersKey(modifiersKey),
+			Note:           note,
+			UnitPriceAtAdd: &unitPrice,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
 		}
 		log.Printf("✅ Yangi savatcha buyurtmasi qo'shildi: TelegramID=%d, FoodID=%d", telegramID, foodID)
 		return newOrder, nil
@@ -88,13 +106,13 @@ func (r *BasketOrderRepository) AddToBasket(telegramID int64, foodID int) (*mode
 	existingOrder.Quantity++ // Miqdorni lokal obyektda ham yangilaymiz
 	existingOrder.UpdatedAt = time.Now()
 	log.Printf("🔄 Savatcha buyurtmasi miqdori yangilandi: TelegramID=%d, FoodID=%d, Yangi miqdor=%d", telegramID, foodID, existingOrder.Quantity)
-	return &existingOrder, nil
+	return existingOrder, nil
 }
 
 // GetBasketOrdersByTelegramID berilgan Telegram ID bo'yicha savatchadagi barcha buyurtmalarni oladi
 func (r *BasketOrderRepository) GetBasketOrdersByTelegramID(telegramID int64) ([]*models.BasketOrder, error) {
 	rows, err := r.db.Query(`
-        SELECT basket_order_id, telegram_id, food_id, quantity, created_at, updated_at
+        SELECT `+basketOrderColumns+`
         FROM basket_orders
         WHERE telegram_id = $1
         ORDER BY created_at DESC
@@ -107,19 +125,146 @@ func (r *BasketOrderRepository) GetBasketOrdersByTelegramID(telegramID int64) ([
 
 	var orders []*models.BasketOrder
 	for rows.Next() {
-		var order models.BasketOrder
-		err := rows.Scan(&order.BasketOrderID, &order.TelegramID, &order.FoodID,
-			&order.Quantity, &order.CreatedAt, &order.UpdatedAt)
+		order, err := scanBasketOrder(rows)
 		if err != nil {
 			log.Printf("BasketOrder GetBasketOrdersByTelegramID scan xatolik: %v", err)
 			continue
 		}
-		orders = append(orders, &order)
+		orders = append(orders, order)
 	}
 	return orders, nil
 }
 
-// RemoveFromBasket savatchadan mahsulotni olib tashlaydi
+// LockBasket foydalanuvchi qatorini SELECT ... FOR UPDATE bilan qulflaydi (faqat WithTx orqali chaqiriladi).
+// Savatchaga yozadigan parallel tranzaksiyalar shu qulf orqali navbat bilan bajariladi - savatcha bo'sh bo'lsa ham,
+// chunki bo'sh savatchada qulflanadigan basket_orders qatori yo'q.
+func (r *BasketOrderRepository) LockBasket(telegramID int64) error {
+	var lockedID int64
+	err := r.db.QueryRow(`
+        SELECT telegram_id FROM users WHERE telegram_id = $1 FOR UPDATE
+    `, telegramID).Scan(&lockedID)
+	if err != nil {
+		log.Printf("BasketOrder LockBasket xatolik: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetBasketOrdersForUpdate savatcha qatorlarini SELECT ... FOR UPDATE bilan oladi va tranzaksiya oxirigacha qulflaydi.
+// Faqat WithTx orqali olingan repository bilan chaqirilishi kerak: parallel buyurtma so'rovi shu qatorlarni
+// kutadi va birinchi tranzaksiya savatchani tozalagach, bo'sh savatchani ko'radi.
+func (r *BasketOrderRepository) GetBasketOrdersForUpdate(telegramID int64) ([]*models.BasketOrder, error) {
+	rows, err := r.db.Query(`
+        SELECT `+basketOrderColumns+`
+        FROM basket_orders
+        WHERE telegram_id = $1
+        ORDER BY created_at DESC
+        FOR UPDATE
+    `, telegramID)
+	if err != nil {
+		log.Printf("BasketOrder GetBasketOrdersForUpdate xatolik: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*models.BasketOrder
+	for rows.Next() {
+		order, err := scanBasketOrder(rows)
+		if err != nil {
+			log.Printf("BasketOrder GetBasketOrdersForUpdate scan xatolik: %v", err)
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+// SetQuantity savatcha qatori (ovqat + modifikatorlar + izoh) miqdorini o'rnatadi, qator bo'lmasa yaratadi.
+// unitPrice yangi qatorning qo'shilgan paytdagi narxi sifatida saqlanadi; mavjud qatorda eski qiymat qoladi.
+func (r *BasketOrderRepository) SetQuantity(telegramID int64, foodID int, modifiersKey, note string, quantity int, unitPrice float64) (*models.BasketOrder, error) {
+	order, err := scanBasketOrder(r.db.QueryRow(`
+        INSERT INTO basket_orders(telegram_id, food_id, quantity, modifiers_key, note, unit_price_at_add)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (telegram_id, food_id, modifiers_key, note) DO UPDATE SET
+            quantity = EXCLUDED.quantity,
+            unit_price_at_add = COALESCE(basket_orders.unit_price_at_add, EXCLUDED.unit_price_at_add),
+            updated_at = CURRENT_TIMESTAMP
+        RETURNING `+basketOrderColumns, telegramID, foodID, quantity, modifiersKey, note, unitPrice))
+	if err != nil {
+		log.Printf("BasketOrder SetQuantity xatolik: %v", err)
+		return nil, err
+	}
+	log.Printf("🔄 Savatcha miqdori o'rnatildi: TelegramID=%d, FoodID=%d, Miqdor=%d", telegramID, foodID, quantity)
+	return order, nil
+}
+
+// SetLineQuantity savatcha qatori miqdorini ID bo'yicha o'rnatadi
+func (r *BasketOrderRepository) SetLineQuantity(telegramID int64, basketOrderID, quantity int) (*models.BasketOrder, error) {
+	order, err := scanBasketOrder(r.db.QueryRow(`
+        UPDATE basket_orders
+        SET quantity = $3, updated_at = CURRENT_TIMESTAMP
+        WHERE telegram_id = $1 AND basket_order_id = $2
+        RETURNING `+basketOrderColumns, telegramID, basketOrderID, quantity))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("BasketOrder SetLineQuantity xatolik: %v", err)
+		}
+		return nil, err
+	}
+	log.Printf("🔄 Savatcha qatori yangilandi: TelegramID=%d, BasketOrderID=%d, Miqdor=%d", telegramID, basketOrderID, quantity)
+	return order, nil
+}
+
+// RemoveLine savatchadan bitta qatorni ID bo'yicha o'chiradi
+func (r *BasketOrderRepository) RemoveLine(telegramID int64, basketOrderID int) error {
+	result, err := r.db.Exec("DELETE FROM basket_orders WHERE telegram_id = $1 AND basket_order_id = $2", telegramID, basketOrderID)
+	if err != nil {
+		log.Printf("BasketOrder RemoveLine xatolik: %v", err)
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	log.Printf("🗑️ Savatcha qatori o'chirildi: TelegramID=%d, BasketOrderID=%d", telegramID, basketOrderID)
+	return nil
+}
+
+// RemoveModifiedLinesByFood ovqatning modifikatorli qatorlarini barcha savatchalardan o'chiradi
+// (modifikatorlar almashtirilganda eski option_id lar endi mavjud bo'lmaydi)
+func (r *BasketOrderRepository) RemoveModifiedLinesByFood(foodID int) error {
+	result, err := r.db.Exec("DELETE FROM basket_orders WHERE food_id = $1 AND modifiers_key <> ''", foodID)
+	if err != nil {
+		log.Printf("BasketOrder RemoveModifiedLinesByFood xatolik: %v", err)
+		return err
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+		log.Printf("🗑️ FoodID=%d uchun %d ta modifikatorli savatcha qatori o'chirildi", foodID, rowsAffected)
+	}
+	return nil
+}
+
+// RemovePlainLine mahsulotning modifikatorsiz va izohsiz qatorini o'chiradi (boshqa qatorlari qoladi).
+// Qator bo'lmasa sql.ErrNoRows qaytaradi.
+func (r *BasketOrderRepository) RemovePlainLine(telegramID int64, foodID int) error {
+	result, err := r.db.Exec(`
+        DELETE FROM basket_orders
+        WHERE telegram_id = $1 AND food_id = $2 AND modifiers_key = '' AND note = ''
+    `, telegramID, foodID)
+	if err != nil {
+		log.Printf("BasketOrder RemovePlainLine xatolik: %v", err)
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	log.Printf("🗑️ Savatchadan mahsulot qatori o'chirildi: TelegramID=%d, FoodID=%d", telegramID, foodID)
+	return nil
+}
+
+// RemoveFromBasket savatchadan mahsulotning barcha qatorlarini (har qanday modifikatorlar bilan) olib tashlaydi
 func (r *BasketOrderRepository) RemoveFromBasket(telegramID int64, foodID int) error {
 	stmt, err := r.db.Prepare("DELETE FROM basket_orders WHERE telegram_id = $1 AND food_id = $2")
 	if err != nil {
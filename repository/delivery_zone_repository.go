@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"amur/models"
+	"database/sql"
+	"log"
+)
+
+type DeliveryZoneRepository struct {
+	db DBTX
+}
+
+func NewDeliveryZoneRepository(db *sql.DB) *DeliveryZoneRepository {
+	return &DeliveryZoneRepository{db: db}
+}
+
+// WithTx berilgan tranzaksiya ichida ishlaydigan repository nusxasini qaytaradi
+func (r *DeliveryZoneRepository) WithTx(tx *sql.Tx) *DeliveryZoneRepository {
+	return &DeliveryZoneRepository{db: tx}
+}
+
+// deliveryZoneColumns hududni o'qish uchun ustunlar (scanDeliveryZone bilan bir xil tartibda)
+const deliveryZoneColumns = `zone_id, zone_name, polygon, delivery_fee, min_order_amount, eta_minutes, is_active, created_at, updated_at`
+
+// scanDeliveryZone deliveryZoneColumns tartibidagi qatorni o'qiydi
+func scanDeliveryZone(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*models.DeliveryZone, error) {
+	var zone models.DeliveryZone
+	if err := scanner.Scan(&zone.ZoneID, &zone.ZoneName, &zone.Polygon, &zone.DeliveryFee, &zone.MinOrderAmount,
+		&zone.EtaMinutes, &zone.IsActive, &zone.CreatedAt, &zone.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+// GetZones hududlarni nomi bo'yicha qaytaradi (includeInactive=false bo'lsa faqat faollari)
+func (r *DeliveryZoneRepository) GetZones(includeInactive bool) ([]models.DeliveryZone, error) {
+	rows, err := r.db.Query(`
+        SELECT `+deliveryZoneColumns+`
+        FROM delivery_zones
+        WHERE is_active OR $1
+        ORDER BY zone_name
+    `, includeInactive)
+	if err != nil {
+		log.Printf("DeliveryZone GetZones xatolik: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	zones := []models.DeliveryZone{}
+	for rows.Next() {
+		zone, err := scanDeliveryZone(rows)
+		if err != nil {
+			log.Printf("DeliveryZone GetZones (scan) xatolik: %v", err)
+			return nil, err
+		}
+		zones = append(zones, *zone)
+	}
+	return zones, rows.Err()
+}
+
+// GetZoneByID hududni ID bo'yicha oladi
+func (r *DeliveryZoneRepository) GetZoneByID(zoneID int) (*models.DeliveryZone, error) {
+	zone, err := scanDeliveryZone(r.db.QueryRow(`SELECT `+deliveryZoneColumns+` FROM delivery_zones WHERE zone_id = $1`, zoneID))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("DeliveryZone GetZoneByID xatolik: %v", err)
+	}
+	return zone, err
+}
+
+// GetZoneByName hududni nomi bo'yicha oladi (katta-kichik harf farqlanmaydi)
+func (r *DeliveryZoneRepository) GetZoneByName(zoneName string) (*models.DeliveryZone, error) {
+	zone, err := scanDeliveryZone(r.db.QueryRow(`SELECT `+deliveryZoneColumns+` FROM delivery_zones WHERE LOWER(zone_name) = LOWER($1)`, zoneName))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("DeliveryZone GetZoneByName xatolik: %v", err)
+	}
+	return zone, err
+}
+
+// CreateZone yangi hudud yaratadi
+func (r *DeliveryZoneRepository) CreateZone(zone *models.DeliveryZone) (*models.DeliveryZone, error) {
+	err := r.db.QueryRow(`
+        INSERT INTO delivery_zones(zone_name, polygon, delivery_fee, min_order_amount, eta_minutes, is_active)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING zone_id, created_at, updated_at
+    `, zone.ZoneName, []byte(zone.Polygon), zone.DeliveryFee, zone.MinOrderAmount, zone.EtaMinutes, zone.IsActive).
+		Scan(&zone.ZoneID, &zone.CreatedAt, &zone.UpdatedAt)
+	if err != nil {
+		log.Printf("DeliveryZone CreateZone xatolik: %v", err)
+		return nil, err
+	}
+	log.Printf("🗺️ Yetkazib berish hududi yaratildi: ZoneID=%d, Name=%s", zone.ZoneID, zone.ZoneName)
+	return zone, nil
+}
+
+// UpdateZone hududning barcha maydonlarini yangilaydi
+func (r *DeliveryZoneRepository) UpdateZone(zone *models.DeliveryZone) error {
+	result, err := r.db.Exec(`
+        UPDATE delivery_zones
+        SET zone_name = $1, polygon = $2, delivery_fee = $3, min_order_amount = $4, eta_minutes = $5, is_active = $6,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE zone_id = $7
+    `, zone.ZoneName, []byte(zone.Polygon), zone.DeliveryFee, zone.MinOrderAmount, zone.EtaMinutes, zone.IsActive, zone.ZoneID)
+	if err != nil {
+		log.Printf("DeliveryZone UpdateZone xatolik: %v", err)
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteZone hududni o'chiradi; unga bog'langan buyurtmalarda hudud bo'sh qoladi (narx va vaqt buyurtmada saqlangan)
+func (r *DeliveryZoneRepository) DeleteZone(zoneID int) error {
+	result, err := r.db.Exec(`DELETE FROM delivery_zones WHERE zone_id = $1`, zoneID)
+	if err != nil {
+		log.Printf("DeliveryZone DeleteZone xatolik: %v", err)
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	log.Printf("🗑️ Yetkazib berish hududi o'chirildi: ZoneID=%d", zoneID)
+	return nil
+}
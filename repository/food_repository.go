@@ -4,16 +4,23 @@ import (
 	"amur/models"
 	"database/sql"
 	"log"
+
+	"github.com/lib/pq"
 )
 
 type FoodRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewFoodRepository(db *sql.DB) *FoodRepository {
 	return &FoodRepository{db: db}
 }
 
+// WithTx berilgan tranzaksiya ichida ishlaydigan repository nusxasini qaytaradi
+func (r *FoodRepository) WithTx(tx *sql.Tx) *FoodRepository {
+	return &FoodRepository{db: tx}
+}
+
 func (r *FoodRepository) Create(food *models.Food) error {
 	stmt, err := r.db.Prepare(`
         INSERT INTO foods (food_name, food_category, food_price, food_image)
@@ -156,3 +163,94 @@ func (r *FoodRepository) Count() int {
 	}
 	return count
 }
+
+// GetModifierGroups berilgan ovqatlarning modifikator guruhlarini variantlari bilan bitta so'rovda oladi (food_id -> guruhlar)
+func (r *FoodRepository) GetModifierGroups(foodIDs []int) (map[int][]models.ModifierGroup, error) {
+	result := make(map[int][]models.ModifierGroup)
+	if len(foodIDs) == 0 {
+		return result, nil
+	}
+	ids := make([]int64, len(foodIDs))
+	for i, id := range foodIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := r.db.Query(`
+        SELECT g.group_id, g.food_id, g.group_name, g.min_select, g.max_select, g.sort_order,
+               o.option_id, o.option_name, o.price_delta, o.sort_order
+        FROM modifier_groups g
+        LEFT JOIN modifier_options o ON o.group_id = g.group_id
+        WHERE g.food_id = ANY($1)
+        ORDER BY g.food_id, g.sort_order, g.group_id, o.sort_order, o.option_id
+    `, pq.Array(ids))
+	if err != nil {
+		log.Printf("Food GetModifierGroups xatolik: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var group models.ModifierGroup
+		var optionID, optionSort sql.NullInt64
+		var optionName sql.NullString
+		var priceDelta sql.NullFloat64
+		if err := rows.Scan(&group.GroupID, &group.FoodID, &group.GroupName, &group.MinSelect, &group.MaxSelect, &group.SortOrder,
+			&optionID, &optionName, &priceDelta, &optionSort); err != nil {
+			log.Printf("Food GetModifierGroups scan xatolik: %v", err)
+			return nil, err
+		}
+
+		groups := result[group.FoodID]
+		if len(groups) == 0 || groups[len(groups)-1].GroupID != group.GroupID {
+			group.Options = []models.ModifierOption{}
+			groups = append(groups, group)
+		}
+		if optionID.Valid {
+			last := &groups[len(groups)-1]
+			last.Options = append(last.Options, models.ModifierOption{
+				OptionID:   int(optionID.Int64),
+				GroupID:    group.GroupID,
+				OptionName: optionName.String,
+				PriceDelta: priceDelta.Float64,
+				SortOrder:  int(optionSort.Int64),
+			})
+		}
+		result[group.FoodID] = groups
+	}
+	return result, rows.Err()
+}
+
+// ReplaceModifierGroups ovqatning barcha modifikator guruhlarini yangilari bilan almashtiradi.
+// Bir nechta so'rovdan iborat, shuning uchun WithTx orqali tranzaksiya ichida chaqirilishi kerak.
+func (r *FoodRepository) ReplaceModifierGroups(foodID int, groups []models.ModifierGroupInput) error {
+	if _, err := r.db.Exec("DELETE FROM modifier_groups WHERE food_id = $1", foodID); err != nil {
+		log.Printf("Food ReplaceModifierGroups (delete) xatolik: %v", err)
+		return err
+	}
+
+	for groupIndex, group := range groups {
+		var groupID int
+		err := r.db.QueryRow(`
+            INSERT INTO modifier_groups(food_id, group_name, min_select, max_select, sort_order)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING group_id
+        `, foodID, group.GroupName, group.MinSelect, group.MaxSelect, groupIndex).Scan(&groupID)
+		if err != nil {
+			log.Printf("Food ReplaceModifierGroups (group) xatolik: %v", err)
+			return err
+		}
+
+		for optionIndex, option := range group.Options {
+			if _, err := r.db.Exec(`
+                INSERT INTO modifier_options(group_id, option_name, price_delta, sort_order)
+                VALUES ($1, $2, $3, $4)
+            `, groupID, option.OptionName, option.PriceDelta, optionIndex); err != nil {
+				log.Printf("Food ReplaceModifierGroups (option) xatolik: %v", err)
+				return err
+			}
+		}
+	}
+
+	log.Printf("🔄 Ovqat modifikatorlari yangilandi: FoodID=%d, Guruhlar=%d", foodID, len(groups))
+	return nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"amur/models"
+	"database/sql"
+	"log"
+	"time"
+)
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve kalitni "bajarilmoqda" holatida band qiladi. Kalit allaqachon mavjud va ttl dan yosh bo'lsa,
+// mavjud yozuv qaytariladi va reserved=false bo'ladi. Muddati o'tgan kalit yangi so'rov uchun qayta band qilinadi.
+func (r *IdempotencyRepository) Reserve(record *models.IdempotencyRecord, ttl time.Duration) (*models.IdempotencyRecord, bool, error) {
+	err := r.db.QueryRow(`
+        INSERT INTO idempotency_keys(telegram_id, idempotency_key, request_method, request_path, request_hash)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (telegram_id, idempotency_key) DO UPDATE SET
+            request_method = EXCLUDED.request_method,
+            request_path = EXCLUDED.request_path,
+            request_hash = EXCLUDED.request_hash,
+            status_code = NULL,
+            response_body = NULL,
+            created_at = CURRENT_TIMESTAMP,
+            completed_at = NULL
+        WHERE idempotency_keys.created_at < CURRENT_TIMESTAMP - ($6 * INTERVAL '1 second')
+        RETURNING created_at
+    `, record.TelegramID, record.Key, record.Method, record.Path, record.RequestHash, int64(ttl.Seconds())).Scan(&record.CreatedAt)
+	if err == nil {
+		return record, true, nil
+	}
+	if err != sql.ErrNoRows {
+		log.Printf("Idempotency Reserve xatolik: %v", err)
+		return nil, false, err
+	}
+
+	// Kalit band: mavjud yozuvni qaytaramiz
+	existing, err := r.Get(record.TelegramID, record.Key)
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+// Get foydalanuvchi va kalit bo'yicha yozuvni qaytaradi
+func (r *IdempotencyRepository) Get(telegramID int64, key string) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	var statusCode sql.NullInt64
+	err := r.db.QueryRow(`
+        SELECT telegram_id, idempotency_key, request_method, request_path, request_hash, status_code, response_body, created_at
+        FROM idempotency_keys
+        WHERE telegram_id = $1 AND idempotency_key = $2
+    `, telegramID, key).Scan(
+		&record.TelegramID,
+		&record.Key,
+		&record.Method,
+		&record.Path,
+		&record.RequestHash,
+		&statusCode,
+		&record.ResponseBody,
+		&record.CreatedAt,
+	)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Idempotency Get xatolik: %v", err)
+		}
+		return nil, err
+	}
+	record.StatusCode = int(statusCode.Int64)
+	return &record, nil
+}
+
+// Complete so'rov javobini saqlaydi
+func (r *IdempotencyRepository) Complete(telegramID int64, key string, statusCode int, body []byte) error {
+	_, err := r.db.Exec(`
+        UPDATE idempotency_keys
+        SET status_code = $3, response_body = $4, completed_at = CURRENT_TIMESTAMP
+        WHERE telegram_id = $1 AND idempotency_key = $2
+    `, telegramID, key, statusCode, body)
+	if err != nil {
+		log.Printf("Idempotency Complete xatolik: %v", err)
+	}
+	return err
+}
+
+// Delete kalitni o'chiradi (so'rov server xatosi bilan tugaganda qayta urinishga ruxsat berish uchun)
+func (r *IdempotencyRepository) Delete(telegramID int64, key string) error {
+	_, err := r.db.Exec("DELETE FROM idempotency_keys WHERE telegram_id = $1 AND idempotency_key = $2", telegramID, key)
+	if err != nil {
+		log.Printf("Idempotency Delete xatolik: %v", err)
+	}
+	return err
+}
+
+// DeleteExpired ttl dan eski kalitlarni o'chiradi va o'chirilganlar sonini qaytaradi
+func (r *IdempotencyRepository) DeleteExpired(ttl time.Duration) (int64, error) {
+	result, err := r.db.Exec(`
+        DELETE FROM idempotency_keys
+        WHERE created_at < CURRENT_TIMESTAMP - ($1 * INTERVAL '1 second')
+    `, int64(ttl.Seconds()))
+	if err != nil {
+		log.Printf("Idempotency DeleteExpired xatolik: %v", err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"amur/models"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+type LoginCodeRepository struct {
+	db *sql.DB
+}
+
+func NewLoginCodeRepository(db *sql.DB) *LoginCodeRepository {
+	return &LoginCodeRepository{db: db}
+}
+
+// Create foydalanuvchi uchun yangi kod saqlaydi. Oldingi ishlatilmagan kodlar bekor qilinadi,
+// shuning uchun har doim faqat oxirgi berilgan kod amal qiladi.
+func (r *LoginCodeRepository) Create(telegramID int64, codeHash string, ttl time.Duration) (*models.LoginCode, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Printf("LoginCode Create begin xatolik: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+        UPDATE login_codes
+        SET used_at = CURRENT_TIMESTAMP
+        WHERE telegram_id = $1 AND used_at IS NULL
+    `, telegramID)
+	if err != nil {
+		log.Printf("LoginCode Create (eski kodlarni bekor qilish) xatolik: %v", err)
+		return nil, err
+	}
+
+	code := &models.LoginCode{TelegramID: telegramID, CodeHash: codeHash}
+	err = tx.QueryRow(`
+        INSERT INTO login_codes(telegram_id, code_hash, expires_at)
+        VALUES ($1, $2, CURRENT_TIMESTAMP + ($3 * INTERVAL '1 second'))
+        RETURNING code_id, attempts, expires_at, created_at
+    `, telegramID, codeHash, int64(ttl.Seconds())).Scan(&code.CodeID, &code.Attempts, &code.ExpiresAt, &code.CreatedAt)
+	if err != nil {
+		log.Printf("LoginCode Create (insert) xatolik: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("LoginCode Create commit xatolik: %v", err)
+		return nil, err
+	}
+
+	log.Printf("🔑 Yangi kirish kodi berildi: TelegramID=%d", telegramID)
+	return code, nil
+}
+
+// GetActiveByTelegramID foydalanuvchining amal qilayotgan (ishlatilmagan va muddati o'tmagan) kodini oladi
+func (r *LoginCodeRepository) GetActiveByTelegramID(telegramID int64) (*models.LoginCode, error) {
+	var code models.LoginCode
+	err := r.db.QueryRow(`
+        SELECT code_id, telegram_id, code_hash, attempts, expires_at, used_at, created_at
+        FROM login_codes
+        WHERE telegram_id = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+        ORDER BY created_at DESC
+        LIMIT 1
+    `, telegramID).Scan(
+		&code.CodeID,
+		&code.TelegramID,
+		&code.CodeHash,
+		&code.Attempts,
+		&code.ExpiresAt,
+		&code.UsedAt,
+		&code.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("faol kirish kodi topilmadi (Telegram ID: %d): %w", telegramID, err)
+		}
+		log.Printf("LoginCode GetActiveByTelegramID xatolik: %v", err)
+		return nil, err
+	}
+	return &code, nil
+}
+
+// IncrementAttempts noto'g'ri urinishlar sonini oshiradi va yangi qiymatni qaytaradi
+func (r *LoginCodeRepository) IncrementAttempts(codeID int) (int, error) {
+	var attempts int
+	err := r.db.QueryRow(`
+        UPDATE login_codes
+        SET attempts = attempts + 1
+        WHERE code_id = $1
+        RETURNING attempts
+    `, codeID).Scan(&attempts)
+	if err != nil {
+		log.Printf("LoginCode IncrementAttempts xatolik: %v", err)
+		return 0, err
+	}
+	return attempts, nil
+}
+
+// MarkUsed kodni ishlatilgan deb belgilaydi. Kod allaqachon ishlatilgan bo'lsa sql.ErrNoRows qaytaradi,
+// bu bir vaqtda kelgan ikki so'rov bitta kod bilan kirishining oldini oladi.
+func (r *LoginCodeRepository) MarkUsed(codeID int) error {
+	result, err := r.db.Exec(`
+        UPDATE login_codes
+        SET used_at = CURRENT_TIMESTAMP
+        WHERE code_id = $1 AND used_at IS NULL
+    `, codeID)
+	if err != nil {
+		log.Printf("LoginCode MarkUsed xatolik: %v", err)
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"amur/models"
+	"database/sql"
+	"log"
+	"time"
+)
+
+type LoginThrottleRepository struct {
+	db *sql.DB
+}
+
+func NewLoginThrottleRepository(db *sql.DB) *LoginThrottleRepository {
+	return &LoginThrottleRepository{db: db}
+}
+
+// GetLockRemaining kalit uchun bloklash tugashiga qolgan vaqtni qaytaradi (bloklanmagan bo'lsa 0)
+func (r *LoginThrottleRepository) GetLockRemaining(scope, key string) (time.Duration, error) {
+	var seconds float64
+	err := r.db.QueryRow(`
+        SELECT COALESCE(EXTRACT(EPOCH FROM (locked_until - CURRENT_TIMESTAMP)), 0)
+        FROM login_throttles
+        WHERE scope = $1 AND throttle_key = $2
+    `, scope, key).Scan(&seconds)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		log.Printf("LoginThrottle GetLockRemaining xatolik: %v", err)
+		return 0, err
+	}
+	if seconds <= 0 {
+		return 0, nil
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// RegisterFailure noto'g'ri urinishni qayd etadi va yangilangan holatni qaytaradi.
+// Oxirgi xatodan beri resetWindow o'tgan bo'lsa hisob qaytadan boshlanadi.
+func (r *LoginThrottleRepository) RegisterFailure(scope, key string, resetWindow time.Duration) (*models.LoginThrottle, error) {
+	throttle := models.LoginThrottle{Scope: scope, Key: key}
+	err := r.db.QueryRow(`
+        INSERT INTO login_throttles(scope, throttle_key, failed_count, last_failed_at)
+        VALUES ($1, $2, 1, CURRENT_TIMESTAMP)
+        ON CONFLICT (scope, throttle_key) DO UPDATE SET
+            failed_count = CASE
+                WHEN login_throttles.last_failed_at < CURRENT_TIMESTAMP - ($3 * INTERVAL '1 second') THEN 1
+                ELSE login_throttles.failed_count + 1
+            END,
+            last_failed_at = CURRENT_TIMESTAMP,
+            updated_at = CURRENT_TIMESTAMP
+        RETURNING failed_count, locked_until, last_failed_at
+    `, scope, key, int64(resetWindow.Seconds())).Scan(&throttle.FailedCount, &throttle.LockedUntil, &throttle.LastFailedAt)
+	if err != nil {
+		log.Printf("LoginThrottle RegisterFailure xatolik: %v", err)
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// Lock kalitni berilgan muddatga bloklaydi
+func (r *LoginThrottleRepository) Lock(scope, key string, duration time.Duration) error {
+	_, err := r.db.Exec(`
+        UPDATE login_throttles
+        SET locked_until = CURRENT_TIMESTAMP + ($3 * INTERVAL '1 second'), updated_at = CURRENT_TIMESTAMP
+        WHERE scope = $1 AND throttle_key = $2
+    `, scope, key, int64(duration.Seconds()))
+	if err != nil {
+		log.Printf("LoginThrottle Lock xatolik: %v", err)
+		return err
+	}
+	log.Printf("🔒 Kirish bloklandi: %s=%s, muddat=%s", scope, key, duration)
+	return nil
+}
+
+// Reset kalit bo'yicha hisobni o'chiradi (muvaffaqiyatli kirishdan keyin)
+func (r *LoginThrottleRepository) Reset(scope, key string) error {
+	_, err := r.db.Exec("DELETE FROM login_throttles WHERE scope = $1 AND throttle_key = $2", scope, key)
+	if err != nil {
+		log.Printf("LoginThrottle Reset xatolik: %v", err)
+		return err
+	}
+	return nil
+}
@@ -4,24 +4,88 @@ package repository
 import (
 	"amur/models"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"log"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type OrderRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewOrderRepository(db *sql.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
+// WithTx berilgan tranzaksiya ichida ishlaydigan repository nusxasini qaytaradi
+func (r *OrderRepository) WithTx(tx *sql.Tx) *OrderRepository {
+	return &OrderRepository{db: tx}
+}
+
+// orderColumns buyurtmani o'qish uchun umumiy ustunlar ro'yxati (scanOrder bilan bir xil tartibda)
+const orderColumns = `order_id, telegram_id, order_time, order_status, delivery_type, total_price,
+            subtotal, delivery_fee, service_charge, discount_amount,
+            delivery_latitude, delivery_longitude, comment,
+            COALESCE(status_changed_at, CURRENT_TIMESTAMP), COALESCE(created_at, CURRENT_TIMESTAMP), COALESCE(updated_at, CURRENT_TIMESTAMP),
+            deleted_at, deleted_by, delete_reason, courier_telegram_id, table_id, table_session_id,
+            (SELECT t.table_name FROM tables t WHERE t.table_id = orders.table_id),
+            (SELECT h.hall_name FROM tables t JOIN halls h ON h.hall_id = t.hall_id WHERE t.table_id = orders.table_id),
+            delivery_zone_id, delivery_eta_minutes,
+            (SELECT z.zone_name FROM delivery_zones z WHERE z.zone_id = orders.delivery_zone_id)`
+
+// scanOrder orderColumns tartibidagi qatorni o'qiydi
+func scanOrder(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*models.Order, error) {
+	var order models.Order
+	err := scanner.Scan(
+		&order.OrderID,
+		&order.TelegramID,
+		&order.OrderTime,
+		&order.OrderStatus,
+		&order.DeliveryType,
+		&order.TotalPrice,
+		&order.Subtotal,
+		&order.DeliveryFee,
+		&order.ServiceCharge,
+		&order.DiscountAmount,
+		&order.DeliveryLatitude,
+		&order.DeliveryLongitude,
+		&order.Comment,
+		&order.StatusChangedAt,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+		&order.DeletedAt,
+		&order.DeletedBy,
+		&order.DeleteReason,
+		&order.CourierTelegramID,
+		&order.TableID,
+		&order.TableSessionID,
+		&order.TableName,
+		&order.HallName,
+		&order.DeliveryZoneID,
+		&order.DeliveryETA,
+		&order.DeliveryZoneName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
 // CreateOrder buyurtmani ma'lumotlar bazasiga qo'shadi va uning ID'sini qaytaradi
 func (r *OrderRepository) CreateOrder(order *models.Order) (*models.Order, error) {
 	stmt, err := r.db.Prepare(`
-        INSERT INTO orders(telegram_id, order_time, order_status, delivery_type, total_price, delivery_latitude, delivery_longitude, comment)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-        RETURNING order_id
+        INSERT INTO orders(telegram_id, order_time, order_status, delivery_type, total_price,
+            subtotal, delivery_fee, service_charge, discount_amount,
+            delivery_latitude, delivery_longitude, comment, table_id, table_session_id,
+            delivery_zone_id, delivery_eta_minutes)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+        RETURNING order_id, status_changed_at, created_at, updated_at
     `)
 	if err != nil {
 		log.Printf("Order CreateOrder prepare xatolik: %v", err)
@@ -35,19 +99,23 @@ func (r *OrderRepository) CreateOrder(order *models.Order) (*models.Order, error
 		order.OrderStatus,
 		order.DeliveryType,
 		order.TotalPrice,
+		order.Subtotal,
+		order.DeliveryFee,
+		order.ServiceCharge,
+		order.DiscountAmount,
 		order.DeliveryLatitude,
 		order.DeliveryLongitude,
 		order.Comment,
-	).Scan(&order.OrderID)
+		order.TableID,
+		order.TableSessionID,
+		order.DeliveryZoneID,
+		order.DeliveryETA,
+	).Scan(&order.OrderID, &order.StatusChangedAt, &order.CreatedAt, &order.UpdatedAt)
 	if err != nil {
 		log.Printf("Order CreateOrder exec xatolik: %v", err)
 		return nil, err
 	}
 
-	// Set timestamps in Go since they're not in DB
-	order.CreatedAt = time.Now()
-	order.UpdatedAt = time.Now()
-
 	log.Printf("✅ Yangi buyurtma yaratildi: OrderID=%d, TelegramID=%d", order.OrderID, order.TelegramID)
 	return order, nil
 }
@@ -55,8 +123,8 @@ func (r *OrderRepository) CreateOrder(order *models.Order) (*models.Order, error
 // AddOrderItem buyurtma elementini (mahsulotni) ma'lumotlar bazasiga qo'shadi
 func (r *OrderRepository) AddOrderItem(item *models.OrderItem) error {
 	stmt, err := r.db.Prepare(`
-        INSERT INTO order_items(order_id, food_id, quantity, item_price)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO order_items(order_id, food_id, quantity, item_price, modifiers, note)
+        VALUES ($1, $2, $3, $4, $5, $6)
     `)
 	if err != nil {
 		log.Printf("Order AddOrderItem prepare xatolik: %v", err)
@@ -64,7 +132,15 @@ func (r *OrderRepository) AddOrderItem(item *models.OrderItem) error {
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(item.OrderID, item.FoodID, item.Quantity, item.ItemPrice)
+	modifiers, err := json.Marshal(item.Modifiers)
+	if err != nil {
+		return err
+	}
+	if item.Modifiers == nil {
+		modifiers = []byte("[]")
+	}
+
+	_, err = stmt.Exec(item.OrderID, item.FoodID, item.Quantity, item.ItemPrice, modifiers, item.Note)
 	if err != nil {
 		log.Printf("Order AddOrderItem exec xatolik: %v", err)
 		return err
@@ -73,24 +149,64 @@ func (r *OrderRepository) AddOrderItem(item *models.OrderItem) error {
 	return nil
 }
 
+// orderItemColumns buyurtma elementini o'qish uchun ustunlar ro'yxati (scanOrderItem bilan bir xil tartibda)
+const orderItemColumns = `order_item_id, order_id, food_id, quantity, item_price, modifiers, note`
+
+// scanOrderItem orderItemColumns tartibidagi qatorni o'qiydi
+func scanOrderItem(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*models.OrderItem, error) {
+	var item models.OrderItem
+	var modifiers []byte
+	if err := scanner.Scan(&item.OrderItemID, &item.OrderID, &item.FoodID, &item.Quantity, &item.ItemPrice, &modifiers, &item.Note); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(modifiers, &item.Modifiers); err != nil {
+		log.Printf("Order item (modifiers) xatolik: %v", err)
+	}
+	// Set default timestamps for items too
+	item.CreatedAt = time.Now()
+	item.UpdatedAt = time.Now()
+	return &item, nil
+}
+
+// GetItemsByOrderIDs bir nechta buyurtmaning elementlarini bitta so'rovda oladi (order_id -> elementlar)
+func (r *OrderRepository) GetItemsByOrderIDs(orderIDs []int) (map[int][]models.OrderItem, error) {
+	items := make(map[int][]models.OrderItem, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return items, nil
+	}
+
+	rows, err := r.db.Query(`
+        SELECT `+orderItemColumns+`
+        FROM order_items
+        WHERE order_id = ANY($1)
+        ORDER BY order_id, order_item_id
+    `, pq.Array(orderIDs))
+	if err != nil {
+		log.Printf("Order GetItemsByOrderIDs xatolik: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanOrderItem(rows)
+		if err != nil {
+			log.Printf("Order GetItemsByOrderIDs scan xatolik: %v", err)
+			return nil, err
+		}
+		items[item.OrderID] = append(items[item.OrderID], *item)
+	}
+	return items, rows.Err()
+}
+
 // GetOrderWithItemsByID buyurtmani uning elementlari bilan birga oladi
 func (r *OrderRepository) GetOrderWithItemsByID(orderID int) (*models.Order, []*models.OrderItem, error) {
-	var order models.Order
-	err := r.db.QueryRow(`
-        SELECT order_id, telegram_id, order_time, order_status, delivery_type, total_price, delivery_latitude, delivery_longitude, comment
+	order, err := scanOrder(r.db.QueryRow(`
+        SELECT `+orderColumns+`
         FROM orders
         WHERE order_id = $1
-    `, orderID).Scan(
-		&order.OrderID,
-		&order.TelegramID,
-		&order.OrderTime,
-		&order.OrderStatus,
-		&order.DeliveryType,
-		&order.TotalPrice,
-		&order.DeliveryLatitude,
-		&order.DeliveryLongitude,
-		&order.Comment,
-	)
+    `, orderID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil, sql.ErrNoRows // Buyurtma topilmadi
@@ -99,14 +215,11 @@ func (r *OrderRepository) GetOrderWithItemsByID(orderID int) (*models.Order, []*
 		return nil, nil, err
 	}
 
-	// Set default timestamps
-	order.CreatedAt = time.Now()
-	order.UpdatedAt = time.Now()
-
 	rows, err := r.db.Query(`
-        SELECT order_item_id, order_id, food_id, quantity, item_price
+        SELECT `+orderItemColumns+`
         FROM order_items
         WHERE order_id = $1
+        ORDER BY order_item_id
     `, orderID)
 	if err != nil {
 		log.Printf("Order GetOrderWithItemsByID (items) xatolik: %v", err)
@@ -116,32 +229,37 @@ func (r *OrderRepository) GetOrderWithItemsByID(orderID int) (*models.Order, []*
 
 	var orderItems []*models.OrderItem
 	for rows.Next() {
-		var item models.OrderItem
-		err := rows.Scan(
-			&item.OrderItemID,
-			&item.OrderID,
-			&item.FoodID,
-			&item.Quantity,
-			&item.ItemPrice,
-		)
+		item, err := scanOrderItem(rows)
 		if err != nil {
 			log.Printf("Order GetOrderWithItemsByID (item scan) xatolik: %v", err)
 			continue
 		}
-		// Set default timestamps for items too
-		item.CreatedAt = time.Now()
-		item.UpdatedAt = time.Now()
-		orderItems = append(orderItems, &item)
+		orderItems = append(orderItems, item)
 	}
 
-	return &order, orderItems, nil
+	return order, orderItems, nil
 }
 
-// UpdateOrderStatus buyurtma holatini yangilaydi
+// GetOrderForUpdate buyurtmani SELECT ... FOR UPDATE bilan oladi (faqat WithTx orqali chaqiriladi),
+// shunda bir vaqtdagi ikki holat o'zgarishi bir-birini ko'rmasdan o'tib ketmaydi
+func (r *OrderRepository) GetOrderForUpdate(orderID int) (*models.Order, error) {
+	order, err := scanOrder(r.db.QueryRow(`
+        SELECT `+orderColumns+`
+        FROM orders
+        WHERE order_id = $1
+        FOR UPDATE
+    `, orderID))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Order GetOrderForUpdate xatolik: %v", err)
+	}
+	return order, err
+}
+
+// UpdateOrderStatus buyurtma holatini yangilaydi va holat o'zgargan vaqtni belgilaydi
 func (r *OrderRepository) UpdateOrderStatus(orderID int, status string) error {
 	stmt, err := r.db.Prepare(`
         UPDATE orders
-        SET order_status = $1
+        SET order_status = $1, status_changed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
         WHERE order_id = $2
     `)
 	if err != nil {
@@ -163,12 +281,154 @@ func (r *OrderRepository) UpdateOrderStatus(orderID int, status string) error {
 	return nil
 }
 
+// SetCourier buyurtmaga kuryerni biriktiradi (courierTelegramID nil bo'lsa olib tashlaydi)
+func (r *OrderRepository) SetCourier(orderID int, courierTelegramID *int64) error {
+	result, err := r.db.Exec(`
+        UPDATE orders
+        SET courier_telegram_id = $2, updated_at = CURRENT_TIMESTAMP
+        WHERE order_id = $1 AND deleted_at IS NULL
+    `, orderID, courierTelegramID)
+	if err != nil {
+		log.Printf("Order SetCourier xatolik: %v", err)
+		return err
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	log.Printf("🛵 Buyurtmaga kuryer biriktirildi: OrderID=%d, Courier=%v", orderID, courierTelegramID)
+	return nil
+}
+
+// AddStatusHistory holat o'zgarishini order_status_history jadvaliga yozadi
+func (r *OrderRepository) AddStatusHistory(entry *models.OrderStatusHistory) error {
+	err := r.db.QueryRow(`
+        INSERT INTO order_status_history(order_id, from_status, to_status, actor_telegram_id, reason)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING history_id, created_at
+    `, entry.OrderID, entry.FromStatus, entry.ToStatus, entry.ActorTelegramID, entry.Reason).Scan(&entry.HistoryID, &entry.CreatedAt)
+	if err != nil {
+		log.Printf("Order AddStatusHistory xatolik: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetStatusHistory buyurtma holatlari tarixini vaqt bo'yicha tartibda oladi
+func (r *OrderRepository) GetStatusHistory(orderID int) ([]*models.OrderStatusHistory, error) {
+	rows, err := r.db.Query(`
+        SELECT history_id, order_id, from_status, to_status, actor_telegram_id, reason, created_at
+        FROM order_status_history
+        WHERE order_id = $1
+        ORDER BY created_at, history_id
+    `, orderID)
+	if err != nil {
+		log.Printf("Order GetStatusHistory query xatolik: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*models.OrderStatusHistory
+	for rows.Next() {
+		var entry models.OrderStatusHistory
+		if err := rows.Scan(&entry.HistoryID, &entry.OrderID, &entry.FromStatus, &entry.ToStatus,
+			&entry.ActorTelegramID, &entry.Reason, &entry.CreatedAt); err != nil {
+			log.Printf("Order GetStatusHistory scan xatolik: %v", err)
+			return nil, err
+		}
+		history = append(history, &entry)
+	}
+	return history, rows.Err()
+}
+
+// ListOrders filtr bo'yicha buyurtmalarni kursorli sahifalash bilan oladi (elementlarisiz).
+// Kursordan keyingi ko'pi bilan filter.Limit ta buyurtma qaytadi.
+func (r *OrderRepository) ListOrders(filter models.OrderListFilter) ([]*models.Order, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, values ...interface{}) {
+		placeholders := make([]interface{}, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = len(args)
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, placeholders...))
+	}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if filter.Status != "" {
+		addCondition("order_status = $%d", filter.Status)
+	}
+	if filter.DeliveryType != "" {
+		addCondition("delivery_type = $%d", filter.DeliveryType)
+	}
+	if filter.TableID != nil {
+		addCondition("table_id = $%d", *filter.TableID)
+	}
+	if filter.TelegramID != nil {
+		addCondition("telegram_id = $%d", *filter.TelegramID)
+	}
+	if filter.From != nil {
+		addCondition("order_time >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("order_time < $%d", *filter.To)
+	}
+	if filter.MinTotal != nil {
+		addCondition("total_price >= $%d", *filter.MinTotal)
+	}
+
+	sortColumn, direction, comparison := "order_time", "DESC", "<"
+	if filter.SortBy == models.OrderSortByPrice {
+		sortColumn = "total_price"
+	}
+	if filter.Ascending {
+		direction, comparison = "ASC", ">"
+	}
+	if cursor := filter.Cursor; cursor != nil {
+		// (saralash qiymati, order_id) juftligi bo'yicha oldingi sahifadan keyin keladiganlar
+		var cursorValue interface{}
+		if sortColumn == "total_price" && cursor.TotalPrice != nil {
+			cursorValue = *cursor.TotalPrice
+		} else if cursor.OrderTime != nil {
+			cursorValue = *cursor.OrderTime
+		}
+		addCondition("("+sortColumn+", order_id) "+comparison+" ($%d, $%d)", cursorValue, cursor.OrderID)
+	}
+
+	query := "SELECT " + orderColumns + " FROM orders"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY %s %s, order_id %s LIMIT $%d", sortColumn, direction, direction, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		log.Printf("Order ListOrders query xatolik: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*models.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			log.Printf("Order ListOrders scan xatolik: %v", err)
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
 // GetUserOrders berilgan Telegram ID bo'yicha foydalanuvchining barcha buyurtmalarini oladi
 func (r *OrderRepository) GetUserOrders(telegramID int64) ([]*models.Order, error) {
 	rows, err := r.db.Query(`
-        SELECT order_id, telegram_id, order_time, order_status, delivery_type, total_price, delivery_latitude, delivery_longitude, comment
+        SELECT `+orderColumns+`
         FROM orders
-        WHERE telegram_id = $1
+        WHERE telegram_id = $1 AND deleted_at IS NULL
         ORDER BY order_time DESC
     `, telegramID)
 	if err != nil {
@@ -179,34 +439,46 @@ func (r *OrderRepository) GetUserOrders(telegramID int64) ([]*models.Order, erro
 
 	var orders []*models.Order
 	for rows.Next() {
-		var order models.Order
-		err := rows.Scan(
-			&order.OrderID,
-			&order.TelegramID,
-			&order.OrderTime,
-			&order.OrderStatus,
-			&order.DeliveryType,
-			&order.TotalPrice,
-			&order.DeliveryLatitude,
-			&order.DeliveryLongitude,
-			&order.Comment,
-		)
+		order, err := scanOrder(rows)
 		if err != nil {
 			log.Printf("Order GetUserOrders scan xatolik: %v", err)
 			continue
 		}
-		// Set default timestamps
-		order.CreatedAt = time.Now()
-		order.UpdatedAt = time.Now()
-		orders = append(orders, &order)
+		orders = append(orders, order)
 	}
 	return orders, nil
 }
 
-// GetOrderStats buyurtma statistikasini oladi
-func (r *OrderRepository) GetOrderStats() (int, error) {
+// GetSessionOrders stol sessiyasiga biriktirilgan (o'chirilmagan) buyurtmalarni buyurtma vaqti tartibida oladi
+func (r *OrderRepository) GetSessionOrders(sessionID int) ([]*models.Order, error) {
+	rows, err := r.db.Query(`
+        SELECT `+orderColumns+`
+        FROM orders
+        WHERE table_session_id = $1 AND deleted_at IS NULL
+        ORDER BY order_time, order_id
+    `, sessionID)
+	if err != nil {
+		log.Printf("Order GetSessionOrders query xatolik: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*models.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			log.Printf("Order GetSessionOrders scan xatolik: %v", err)
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+// GetOrderStats buyurtmalar sonini oladi; o'chirilgan buyurtmalar faqat includeDeleted bo'lsa hisoblanadi
+func (r *OrderRepository) GetOrderStats(includeDeleted bool) (int, error) {
 	var count int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM orders").Scan(&count)
+	err := r.db.QueryRow("SELECT COUNT(*) FROM orders WHERE $1 OR deleted_at IS NULL", includeDeleted).Scan(&count)
 	if err != nil {
 		log.Printf("Order GetOrderStats xatolik: %v", err)
 		return 0, err
@@ -214,26 +486,91 @@ func (r *OrderRepository) GetOrderStats() (int, error) {
 	return count, nil
 }
 
-// DeleteOrder buyurtmani ID bo'yicha o'chiradi (FAQAT ADMIN UCHUN)
-func (r *OrderRepository) DeleteOrder(orderID int) error {
-	stmt, err := r.db.Prepare("DELETE FROM orders WHERE order_id = $1")
-	if err != nil {
-		log.Printf("Order DeleteOrder prepare xatolik: %v", err)
-		return err
-	}
-	defer stmt.Close()
-
-	result, err := stmt.Exec(orderID)
+// SoftDeleteOrder buyurtmani o'chirilgan deb belgilaydi (FAQAT ADMIN UCHUN).
+// Buyurtma va uning elementlari bazada qoladi; allaqachon o'chirilgan buyurtma uchun sql.ErrNoRows qaytadi.
+func (r *OrderRepository) SoftDeleteOrder(orderID int, deletedBy int64, reason *string) error {
+	result, err := r.db.Exec(`
+        UPDATE orders
+        SET deleted_at = CURRENT_TIMESTAMP, deleted_by = $2, delete_reason = $3, updated_at = CURRENT_TIMESTAMP
+        WHERE order_id = $1 AND deleted_at IS NULL
+    `, orderID, deletedBy, reason)
 	if err != nil {
-		log.Printf("Order DeleteOrder exec xatolik: %v", err)
+		log.Printf("Order SoftDeleteOrder xatolik: %v", err)
 		return err
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return sql.ErrNoRows // Agar buyurtma topilmadi
+		return sql.ErrNoRows // Buyurtma topilmadi yoki allaqachon o'chirilgan
 	}
 
-	log.Printf("🗑️ Buyurtma o'chirildi: OrderID=%d", orderID)
+	log.Printf("🗑️ Buyurtma o'chirilgan deb belgilandi: OrderID=%d, DeletedBy=%d", orderID, deletedBy)
 	return nil
 }
+
+// ArchiveOrders cutoff dan eski yakunlangan (finalStatuses) yoki o'chirilgan buyurtmalardan ko'pi bilan limit tasini
+// orders_archive va order_items_archive jadvallariga ko'chiradi va asosiy jadvaldan o'chiradi.
+// Faqat WithTx orqali chaqirilishi kerak: ko'chirish va o'chirish bitta tranzaksiyada bo'lishi shart.
+func (r *OrderRepository) ArchiveOrders(cutoff time.Time, finalStatuses []string, limit int) (int, error) {
+	rows, err := r.db.Query(`
+        SELECT order_id FROM orders
+        WHERE order_time < $1 AND (deleted_at IS NOT NULL OR order_status = ANY($2))
+        ORDER BY order_id
+        LIMIT $3
+        FOR UPDATE SKIP LOCKED
+    `, cutoff, pq.Array(finalStatuses), limit)
+	if err != nil {
+		log.Printf("Order ArchiveOrders (select) xatolik: %v", err)
+		return 0, err
+	}
+	var orderIDs []int64
+	for rows.Next() {
+		var orderID int64
+		if err := rows.Scan(&orderID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(orderIDs) == 0 {
+		return 0, nil
+	}
+
+	if _, err := r.db.Exec(`
+        INSERT INTO orders_archive(order_id, telegram_id, order_time, order_status, delivery_type, total_price,
+            subtotal, delivery_fee, service_charge, discount_amount, deleted_at, deleted_by, delete_reason,
+            order_data, status_history)
+        SELECT o.order_id, o.telegram_id, o.order_time, o.order_status, o.delivery_type, o.total_price,
+            o.subtotal, o.delivery_fee, o.service_charge, o.discount_amount, o.deleted_at, o.deleted_by, o.delete_reason,
+            to_jsonb(o),
+            COALESCE((SELECT jsonb_agg(to_jsonb(h) ORDER BY h.history_id) FROM order_status_history h WHERE h.order_id = o.order_id), '[]')
+        FROM orders o
+        WHERE o.order_id = ANY($1)
+        ON CONFLICT (order_id) DO NOTHING
+    `, pq.Array(orderIDs)); err != nil {
+		log.Printf("Order ArchiveOrders (orders) xatolik: %v", err)
+		return 0, err
+	}
+
+	if _, err := r.db.Exec(`
+        INSERT INTO order_items_archive(order_item_id, order_id, food_id, quantity, item_price, modifiers, note)
+        SELECT order_item_id, order_id, food_id, quantity, item_price, COALESCE(modifiers, '[]'), note
+        FROM order_items
+        WHERE order_id = ANY($1)
+        ON CONFLICT (order_item_id) DO NOTHING
+    `, pq.Array(orderIDs)); err != nil {
+		log.Printf("Order ArchiveOrders (items) xatolik: %v", err)
+		return 0, err
+	}
+
+	// order_items va order_status_history ON DELETE CASCADE bilan birga o'chadi
+	if _, err := r.db.Exec("DELETE FROM orders WHERE order_id = ANY($1)", pq.Array(orderIDs)); err != nil {
+		log.Printf("Order ArchiveOrders (delete) xatolik: %v", err)
+		return 0, err
+	}
+	return len(orderIDs), nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"amur/models"
+	"database/sql"
+	"log"
+)
+
+type PermissionRepository struct {
+	db *sql.DB
+}
+
+func NewPermissionRepository(db *sql.DB) *PermissionRepository {
+	return &PermissionRepository{db: db}
+}
+
+// GetPermissionsByRole rolga biriktirilgan ruxsat kodlarini qaytaradi
+func (r *PermissionRepository) GetPermissionsByRole(role string) ([]string, error) {
+	rows, err := r.db.Query(`
+        SELECT permission_code
+        FROM role_permissions
+        WHERE role_name = $1
+        ORDER BY permission_code
+    `, role)
+	if err != nil {
+		log.Printf("Permission GetPermissionsByRole xatolik: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []string{}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			log.Printf("Permission GetPermissionsByRole scan xatolik: %v", err)
+			return nil, err
+		}
+		permissions = append(permissions, code)
+	}
+	return permissions, rows.Err()
+}
+
+// RoleExists rol roles jadvalida mavjudligini tekshiradi
+func (r *PermissionRepository) RoleExists(role string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM roles WHERE role_name = $1)`, role).Scan(&exists)
+	if err != nil {
+		log.Printf("Permission RoleExists xatolik: %v", err)
+		return false, err
+	}
+	return exists, nil
+}
+
+// GetAllRoles barcha rollarni ularning ruxsatlari bilan qaytaradi
+func (r *PermissionRepository) GetAllRoles() ([]*models.Role, error) {
+	rows, err := r.db.Query(`
+        SELECT r.role_name, r.description, rp.permission_code
+        FROM roles r
+        LEFT JOIN role_permissions rp ON rp.role_name = r.role_name
+        ORDER BY r.role_name, rp.permission_code
+    `)
+	if err != nil {
+		log.Printf("Permission GetAllRoles xatolik: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*models.Role
+	var current *models.Role
+	for rows.Next() {
+		var roleName, description string
+		var permission sql.NullString
+		if err := rows.Scan(&roleName, &description, &permission); err != nil {
+			log.Printf("Permission GetAllRoles scan xatolik: %v", err)
+			return nil, err
+		}
+		if current == nil || current.RoleName != roleName {
+			current = &models.Role{RoleName: roleName, Description: description, Permissions: []string{}}
+			roles = append(roles, current)
+		}
+		if permission.Valid {
+			current.Permissions = append(current.Permissions, permission.String)
+		}
+	}
+	return roles, rows.Err()
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"amur/models"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create yangi sessiya yozuvini saqlaydi
+func (r *SessionRepository) Create(session *models.Session, ttl time.Duration) error {
+	err := r.db.QueryRow(`
+        INSERT INTO sessions(session_id, telegram_id, refresh_token_hash, user_agent, ip_address, expires_at)
+        VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP + ($6 * INTERVAL '1 second'))
+        RETURNING expires_at, created_at, last_used_at
+    `, session.SessionID, session.TelegramID, session.RefreshTokenHash, session.UserAgent, session.IPAddress, int64(ttl.Seconds())).
+		Scan(&session.ExpiresAt, &session.CreatedAt, &session.LastUsedAt)
+	if err != nil {
+		log.Printf("Session Create xatolik: %v", err)
+		return err
+	}
+
+	log.Printf("✅ Yangi sessiya yaratildi: TelegramID=%d", session.TelegramID)
+	return nil
+}
+
+// GetByID sessiyani ID bo'yicha oladi
+func (r *SessionRepository) GetByID(sessionID string) (*models.Session, error) {
+	var session models.Session
+	err := r.db.QueryRow(`
+        SELECT session_id, telegram_id, refresh_token_hash, user_agent, ip_address,
+               expires_at, revoked_at, revoke_reason, created_at, last_used_at
+        FROM sessions
+        WHERE session_id = $1
+    `, sessionID).Scan(
+		&session.SessionID,
+		&session.TelegramID,
+		&session.RefreshTokenHash,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+		&session.RevokeReason,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("sessiya topilmadi: %w", err)
+		}
+		log.Printf("Session GetByID xatolik: %v", err)
+		return nil, err
+	}
+	return &session, nil
+}
+
+// IsActive sessiya bekor qilinmagan va muddati o'tmaganligini tekshiradi
+func (r *SessionRepository) IsActive(sessionID string) (bool, error) {
+	var active bool
+	err := r.db.QueryRow(`
+        SELECT EXISTS(
+            SELECT 1 FROM sessions
+            WHERE session_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+        )
+    `, sessionID).Scan(&active)
+	if err != nil {
+		log.Printf("Session IsActive xatolik: %v", err)
+		return false, err
+	}
+	return active, nil
+}
+
+// Rotate refresh token hashini almashtiradi va muddatini uzaytiradi. Eski hash bitta so'rovning o'zida
+// superseded_refresh_tokens ga yoziladi, shuning uchun almashtirilgan token tarixdan tushib qolmaydi.
+// Token parallel so'rovda allaqachon almashtirilgan bo'lsa sql.ErrNoRows qaytaradi.
+func (r *SessionRepository) Rotate(sessionID, oldHash, newHash string, ttl time.Duration) (time.Time, error) {
+	var expiresAt time.Time
+	err := r.db.QueryRow(`
+        WITH rotated AS (
+            UPDATE sessions
+            SET refresh_token_hash = $3,
+                expires_at = CURRENT_TIMESTAMP + ($4 * INTERVAL '1 second'),
+                last_used_at = CURRENT_TIMESTAMP
+            WHERE session_id = $1 AND refresh_token_hash = $2 AND revoked_at IS NULL
+            RETURNING session_id, expires_at
+        ), superseded AS (
+            INSERT INTO superseded_refresh_tokens(session_id, token_hash)
+            SELECT session_id, $2 FROM rotated
+            ON CONFLICT DO NOTHING
+        )
+        SELECT expires_at FROM rotated
+    `, sessionID, oldHash, newHash, int64(ttl.Seconds())).Scan(&expiresAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Session Rotate xatolik: %v", err)
+		}
+		return time.Time{}, err
+	}
+	return expiresAt, nil
+}
+
+// IsSupersededToken token hashi shu sessiyada avval almashtirilgan tokenlardan biri ekanligini tekshiradi
+func (r *SessionRepository) IsSupersededToken(sessionID, tokenHash string) (bool, error) {
+	var superseded bool
+	err := r.db.QueryRow(`
+        SELECT EXISTS(
+            SELECT 1 FROM superseded_refresh_tokens
+            WHERE session_id = $1 AND token_hash = $2
+        )
+    `, sessionID, tokenHash).Scan(&superseded)
+	if err != nil {
+		log.Printf("Session IsSupersededToken xatolik: %v", err)
+		return false, err
+	}
+	return superseded, nil
+}
+
+// Revoke bitta sessiyani bekor qiladi
+func (r *SessionRepository) Revoke(sessionID, reason string) error {
+	_, err := r.db.Exec(`
+        UPDATE sessions
+        SET revoked_at = CURRENT_TIMESTAMP, revoke_reason = $2
+        WHERE session_id = $1 AND revoked_at IS NULL
+    `, sessionID, reason)
+	if err != nil {
+		log.Printf("Session Revoke xatolik: %v", err)
+		return err
+	}
+	log.Printf("🚫 Sessiya bekor qilindi: SessionID=%s, Sabab='%s'", sessionID, reason)
+	return nil
+}
+
+// RevokeAllByTelegramID foydalanuvchining barcha faol sessiyalarini bekor qiladi va ularning sonini qaytaradi
+func (r *SessionRepository) RevokeAllByTelegramID(telegramID int64, reason string) (int, error) {
+	result, err := r.db.Exec(`
+        UPDATE sessions
+        SET revoked_at = CURRENT_TIMESTAMP, revoke_reason = $2
+        WHERE telegram_id = $1 AND revoked_at IS NULL
+    `, telegramID, reason)
+	if err != nil {
+		log.Printf("Session RevokeAllByTelegramID xatolik: %v", err)
+		return 0, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	log.Printf("🚫 Foydalanuvchining %d ta sessiyasi bekor qilindi: TelegramID=%d, Sabab='%s'", rowsAffected, telegramID, reason)
+	return int(rowsAffected), nil
+}
@@ -0,0 +1,538 @@
+package repository
+
+import (
+	"amur/models"
+	"database/sql"
+	"log"
+)
+
+type TableRepository struct {
+	db DBTX
+}
+
+func NewTableRepository(db *sql.DB) *TableRepository {
+	return &TableRepository{db: db}
+}
+
+// WithTx berilgan tranzaksiya ichida ishlaydigan repository nusxasini qaytaradi
+func (r *TableRepository) WithTx(tx *sql.Tx) *TableRepository {
+	return &TableRepository{db: tx}
+}
+
+// hallColumns zalni stollar soni bilan o'qish uchun ustunlar (halls h; scanHall bilan bir xil tartibda)
+const hallColumns = `h.hall_id, h.hall_name, (SELECT COUNT(*) FROM tables t WHERE t.hall_id = h.hall_id),
+            COALESCE(h.created_at, CURRENT_TIMESTAMP), COALESCE(h.updated_at, CURRENT_TIMESTAMP)`
+
+// scanHall hallColumns tartibidagi qatorni o'qiydi
+func scanHall(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*models.Hall, error) {
+	var hall models.Hall
+	if err := scanner.Scan(&hall.HallID, &hall.HallName, &hall.TableCount, &hall.CreatedAt, &hall.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &hall, nil
+}
+
+// tableColumns stolni zal nomi bilan o'qish uchun ustunlar (tables t LEFT JOIN halls h; scanTable bilan bir xil tartibda)
+const tableColumns = `t.table_id, t.table_name, t.qr_code_token, t.hall_id, h.hall_name, t.capacity, t.is_active,
+            COALESCE(t.created_at, CURRENT_TIMESTAMP), COALESCE(t.updated_at, CURRENT_TIMESTAMP)`
+
+// scanTable tableColumns tartibidagi qatorni o'qiydi
+func scanTable(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*models.Table, error) {
+	var table models.Table
+	if err := scanner.Scan(&table.TableID, &table.TableName, &table.QRCodeToken, &table.HallID, &table.HallName,
+		&table.Capacity, &table.IsActive, &table.CreatedAt, &table.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+// --- Zallar ---
+
+// GetHalls barcha zallarni nomi bo'yicha tartiblab qaytaradi
+func (r *TableRepository) GetHalls() ([]models.Hall, error) {
+	rows, err := r.db.Query(`SELECT ` + hallColumns + ` FROM halls h ORDER BY h.hall_name`)
+	if err != nil {
+		log.Printf("Table GetHalls xatolik: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	halls := []models.Hall{}
+	for rows.Next() {
+		hall, err := scanHall(rows)
+		if err != nil {
+			log.Printf("Table GetHalls (scan) xatolik: %v", err)
+			return nil, err
+		}
+		halls = append(halls, *hall)
+	}
+	return halls, rows.Err()
+}
+
+// GetHallByID zalni ID bo'yicha oladi
+func (r *TableRepository) GetHallByID(hallID int) (*models.Hall, error) {
+	hall, err := scanHall(r.db.QueryRow(`SELECT `+hallColumns+` FROM halls h WHERE h.hall_id = $1`, hallID))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Table GetHallByID xatolik: %v", err)
+	}
+	return hall, err
+}
+
+// GetHallByName zalni nomi bo'yicha oladi (katta-kichik harf farqlanmaydi)
+func (r *TableRepository) GetHallByName(hallName string) (*models.Hall, error) {
+	hall, err := scanHall(r.db.QueryRow(`SELECT `+hallColumns+` FROM halls h WHERE LOWER(h.hall_name) = LOWER($1)`, hallName))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Table GetHallByName xatolik: %v", err)
+	}
+	return hall, err
+}
+
+// CreateHall yangi zal yaratadi
+func (r *TableRepository) CreateHall(hallName string) (*models.Hall, error) {
+	hall := &models.Hall{HallName: hallName}
+	err := r.db.QueryRow(`
+        INSERT INTO halls(hall_name) VALUES ($1)
+        RETURNING hall_id, created_at, updated_at
+    `, hallName).Scan(&hall.HallID, &hall.CreatedAt, &hall.UpdatedAt)
+	if err != nil {
+		log.Printf("Table CreateHall xatolik: %v", err)
+		return nil, err
+	}
+	return hall, nil
+}
+
+// RenameHall zal nomini o'zgartiradi
+func (r *TableRepository) RenameHall(hallID int, hallName string) error {
+	result, err := r.db.Exec(`UPDATE halls SET hall_name = $1, updated_at = CURRENT_TIMESTAMP WHERE hall_id = $2`, hallName, hallID)
+	if err != nil {
+		log.Printf("Table RenameHall xatolik: %v", err)
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteHall zalni o'chiradi; uning stollari zalsiz qoladi (hall_id NULL)
+func (r *TableRepository) DeleteHall(hallID int) error {
+	result, err := r.db.Exec(`DELETE FROM halls WHERE hall_id = $1`, hallID)
+	if err != nil {
+		log.Printf("Table DeleteHall xatolik: %v", err)
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// EnsureHall zalni nomi bo'yicha topadi, bo'lmasa yaratadi va ID'sini qaytaradi
+func (r *TableRepository) EnsureHall(hallName string) (int, error) {
+	var hallID int
+	err := r.db.QueryRow(`
+        INSERT INTO halls(hall_name) VALUES ($1)
+        ON CONFLICT (hall_name) DO UPDATE SET hall_name = EXCLUDED.hall_name
+        RETURNING hall_id
+    `, hallName).Scan(&hallID)
+	if err != nil {
+		log.Printf("Table EnsureHall xatolik: %v", err)
+		return 0, err
+	}
+	return hallID, nil
+}
+
+// --- Stollar ---
+
+// GetTables stollarni zal va nom bo'yicha tartiblab qaytaradi.
+// hallID berilsa faqat shu zal stollari, includeInactive false bo'lsa faqat faol stollar.
+func (r *TableRepository) GetTables(hallID *int, includeInactive bool) ([]models.Table, error) {
+	rows, err := r.db.Query(`
+        SELECT `+tableColumns+`
+        FROM tables t
+        LEFT JOIN halls h ON h.hall_id = t.hall_id
+        WHERE ($1::INTEGER IS NULL OR t.hall_id = $1) AND ($2 OR t.is_active)
+        ORDER BY h.hall_name NULLS LAST, t.table_name
+    `, hallID, includeInactive)
+	if err != nil {
+		log.Printf("Table GetTables xatolik: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := []models.Table{}
+	for rows.Next() {
+		table, err := scanTable(rows)
+		if err != nil {
+			log.Printf("Table GetTables (scan) xatolik: %v", err)
+			return nil, err
+		}
+		tables = append(tables, *table)
+	}
+	return tables, rows.Err()
+}
+
+// GetTableByID stolni ID bo'yicha oladi
+func (r *TableRepository) GetTableByID(tableID int) (*models.Table, error) {
+	table, err := scanTable(r.db.QueryRow(`
+        SELECT `+tableColumns+`
+        FROM tables t
+        LEFT JOIN halls h ON h.hall_id = t.hall_id
+        WHERE t.table_id = $1
+    `, tableID))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Table GetTableByID xatolik: %v", err)
+	}
+	return table, err
+}
+
+// GetTableByName stolni nomi bo'yicha oladi (katta-kichik harf farqlanmaydi)
+func (r *TableRepository) GetTableByName(tableName string) (*models.Table, error) {
+	table, err := scanTable(r.db.QueryRow(`
+        SELECT `+tableColumns+`
+        FROM tables t
+        LEFT JOIN halls h ON h.hall_id = t.hall_id
+        WHERE LOWER(t.table_name) = LOWER($1)
+    `, tableName))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Table GetTableByName xatolik: %v", err)
+	}
+	return table, err
+}
+
+// GetByToken stolni QR kod tokeni bo'yicha oladi
+func (r *TableRepository) GetByToken(token string) (*models.Table, error) {
+	table, err := scanTable(r.db.QueryRow(`
+        SELECT `+tableColumns+`
+        FROM tables t
+        LEFT JOIN halls h ON h.hall_id = t.hall_id
+        WHERE t.qr_code_token = $1
+    `, token))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Table GetByToken xatolik: %v", err)
+	}
+	return table, err
+}
+
+// CreateTable yangi stol yaratadi
+func (r *TableRepository) CreateTable(table *models.Table) (*models.Table, error) {
+	err := r.db.QueryRow(`
+        INSERT INTO tables(table_name, qr_code_token, hall_id, capacity, is_active)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING table_id, created_at, updated_at
+    `, table.TableName, table.QRCodeToken, table.HallID, table.Capacity, table.IsActive).Scan(&table.TableID, &table.CreatedAt, &table.UpdatedAt)
+	if err != nil {
+		log.Printf("Table CreateTable xatolik: %v", err)
+		return nil, err
+	}
+	return table, nil
+}
+
+// UpdateTable stol nomi, zali, sig'imi va faolligini yangilaydi (QR token o'zgarmaydi)
+func (r *TableRepository) UpdateTable(table *models.Table) error {
+	result, err := r.db.Exec(`
+        UPDATE tables
+        SET table_name = $1, hall_id = $2, capacity = $3, is_active = $4, updated_at = CURRENT_TIMESTAMP
+        WHERE table_id = $5
+    `, table.TableName, table.HallID, table.Capacity, table.IsActive, table.TableID)
+	if err != nil {
+		log.Printf("Table UpdateTable xatolik: %v", err)
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateToken stolning QR tokenini almashtiradi; eski token bilan buyurtma berib bo'lmaydi
+func (r *TableRepository) UpdateToken(tableID int, token string) error {
+	result, err := r.db.Exec(`
+        UPDATE tables SET qr_code_token = $1, updated_at = CURRENT_TIMESTAMP WHERE table_id = $2
+    `, token, tableID)
+	if err != nil {
+		log.Printf("Table UpdateToken xatolik: %v", err)
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CountTableOrders stolga bog'langan buyurtmalar sonini qaytaradi (o'chirilganlari ham)
+func (r *TableRepository) CountTableOrders(tableID int) (int, error) {
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM orders WHERE table_id = $1`, tableID).Scan(&count); err != nil {
+		log.Printf("Table CountTableOrders xatolik: %v", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// DeleteTable stolni o'chiradi
+func (r *TableRepository) DeleteTable(tableID int) error {
+	result, err := r.db.Exec(`DELETE FROM tables WHERE table_id = $1`, tableID)
+	if err != nil {
+		log.Printf("Table DeleteTable xatolik: %v", err)
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// --- Stol sessiyalari ---
+
+// tableSessionColumns sessiyani stol va zal nomi bilan o'qish uchun ustunlar
+// (table_sessions s JOIN tables t LEFT JOIN halls h; scanTableSession bilan bir xil tartibda)
+const tableSessionColumns = `s.session_id, s.table_id, t.table_name, h.hall_name, s.opened_by, s.opened_at, s.closed_at, s.closed_by`
+
+const tableSessionFrom = `
+        FROM table_sessions s
+        JOIN tables t ON t.table_id = s.table_id
+        LEFT JOIN halls h ON h.hall_id = t.hall_id`
+
+// scanTableSession tableSessionColumns tartibidagi qatorni o'qiydi
+func scanTableSession(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*models.TableSession, error) {
+	var session models.TableSession
+	if err := scanner.Scan(&session.SessionID, &session.TableID, &session.TableName, &session.HallName,
+		&session.OpenedBy, &session.OpenedAt, &session.ClosedAt, &session.ClosedBy); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// OpenSession stolning ochiq sessiyasini qulflab qaytaradi, bo'lmasa yangisini ochadi (ikkinchi qiymat - yangi ochildimi).
+// Bir vaqtda ikki mehmon skanerlasa ham table_sessions_one_open_idx tufayli bitta sessiya ochiladi.
+func (r *TableRepository) OpenSession(tableID int, openedBy int64) (*models.TableSession, bool, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		session, err := scanTableSession(r.db.QueryRow(`
+        SELECT `+tableSessionColumns+tableSessionFrom+`
+        WHERE s.table_id = $1 AND s.closed_at IS NULL
+        FOR UPDATE OF s
+    `, tableID))
+		if err == nil {
+			return session, false, nil
+		}
+		if err != sql.ErrNoRows {
+			log.Printf("Table OpenSession (select) xatolik: %v", err)
+			return nil, false, err
+		}
+
+		var sessionID int
+		err = r.db.QueryRow(`
+            INSERT INTO table_sessions(table_id, opened_by) VALUES ($1, $2)
+            ON CONFLICT (table_id) WHERE closed_at IS NULL DO NOTHING
+            RETURNING session_id
+        `, tableID, openedBy).Scan(&sessionID)
+		if err == sql.ErrNoRows {
+			continue // Parallel so'rov sessiyani hozirgina ochdi, uni qayta o'qiymiz
+		}
+		if err != nil {
+			log.Printf("Table OpenSession (insert) xatolik: %v", err)
+			return nil, false, err
+		}
+		session, err = r.GetSessionByID(sessionID)
+		if err != nil {
+			return nil, false, err
+		}
+		log.Printf("🍽️ Stol sessiyasi ochildi: SessionID=%d, TableID=%d", sessionID, tableID)
+		return session, true, nil
+	}
+	return nil, false, sql.ErrNoRows
+}
+
+// GetSessionByID sessiyani ID bo'yicha oladi
+func (r *TableRepository) GetSessionByID(sessionID int) (*models.TableSession, error) {
+	session, err := scanTableSession(r.db.QueryRow(`
+        SELECT `+tableSessionColumns+tableSessionFrom+`
+        WHERE s.session_id = $1
+    `, sessionID))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Table GetSessionByID xatolik: %v", err)
+	}
+	return session, err
+}
+
+// GetSessionForUpdate sessiyani tranzaksiya oxirigacha qulflab oladi (WithTx bilan chaqirilishi kerak)
+func (r *TableRepository) GetSessionForUpdate(sessionID int) (*models.TableSession, error) {
+	session, err := scanTableSession(r.db.QueryRow(`
+        SELECT `+tableSessionColumns+tableSessionFrom+`
+        WHERE s.session_id = $1
+        FOR UPDATE OF s
+    `, sessionID))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Table GetSessionForUpdate xatolik: %v", err)
+	}
+	return session, err
+}
+
+// GetOpenSessions barcha ochiq sessiyalarni ochilgan vaqti bo'yicha qaytaradi
+func (r *TableRepository) GetOpenSessions() ([]models.TableSession, error) {
+	rows, err := r.db.Query(`
+        SELECT ` + tableSessionColumns + tableSessionFrom + `
+        WHERE s.closed_at IS NULL
+        ORDER BY s.opened_at
+    `)
+	if err != nil {
+		log.Printf("Table GetOpenSessions xatolik: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.TableSession{}
+	for rows.Next() {
+		session, err := scanTableSession(rows)
+		if err != nil {
+			log.Printf("Table GetOpenSessions (scan) xatolik: %v", err)
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+// CloseSession ochiq sessiyani yopadi
+func (r *TableRepository) CloseSession(sessionID int, closedBy int64) error {
+	result, err := r.db.Exec(`
+        UPDATE table_sessions SET closed_at = CURRENT_TIMESTAMP, closed_by = $1
+        WHERE session_id = $2 AND closed_at IS NULL
+    `, closedBy, sessionID)
+	if err != nil {
+		log.Printf("Table CloseSession xatolik: %v", err)
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	log.Printf("✅ Stol sessiyasi yopildi: SessionID=%d", sessionID)
+	return nil
+}
+
+// tableRequestColumns chaqiruvni stol va zal nomi bilan o'qish uchun ustunlar
+// (table_requests q JOIN tables t LEFT JOIN halls h; scanTableRequest bilan bir xil tartibda)
+const tableRequestColumns = `q.request_id, q.table_id, t.table_name, h.hall_name, q.session_id, q.request_type, q.telegram_id,
+        q.repeat_count, q.created_at, q.last_requested_at, q.staff_message_id, q.acknowledged_at, q.acknowledged_by`
+
+const tableRequestFrom = `
+        FROM table_requests q
+        JOIN tables t ON t.table_id = q.table_id
+        LEFT JOIN halls h ON h.hall_id = t.hall_id`
+
+// scanTableRequest tableRequestColumns tartibidagi qatorni o'qiydi
+func scanTableRequest(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*models.TableRequest, error) {
+	var request models.TableRequest
+	if err := scanner.Scan(&request.RequestID, &request.TableID, &request.TableName, &request.HallName,
+		&request.SessionID, &request.RequestType, &request.TelegramID, &request.RepeatCount, &request.CreatedAt,
+		&request.LastRequestedAt, &request.StaffMessageID, &request.AcknowledgedAt, &request.AcknowledgedBy); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// CreateOrTouchRequest stol uchun chaqiruv yaratadi. Shu turdagi qabul qilinmagan chaqiruv bo'lsa yangisi yaratilmaydi,
+// faqat uning repeat_count va last_requested_at qiymatlari yangilanadi (ikkinchi qiymat - yangi yaratildimi).
+// Chaqiruv stolning o'sha paytdagi ochiq hisobiga bog'lanadi.
+func (r *TableRepository) CreateOrTouchRequest(tableID int, requestType string, telegramID int64) (*models.TableRequest, bool, error) {
+	var requestID int
+	var created bool
+	err := r.db.QueryRow(`
+        INSERT INTO table_requests(table_id, session_id, request_type, telegram_id)
+        VALUES ($1, (SELECT session_id FROM table_sessions WHERE table_id = $1 AND closed_at IS NULL), $2, $3)
+        ON CONFLICT (table_id, request_type) WHERE acknowledged_at IS NULL
+        DO UPDATE SET repeat_count = table_requests.repeat_count + 1, last_requested_at = CURRENT_TIMESTAMP
+        RETURNING request_id, (xmax = 0)
+    `, tableID, requestType, telegramID).Scan(&requestID, &created)
+	if err != nil {
+		log.Printf("Table CreateOrTouchRequest xatolik: %v", err)
+		return nil, false, err
+	}
+	request, err := r.GetRequestByID(requestID)
+	if err != nil {
+		return nil, false, err
+	}
+	if created {
+		log.Printf("🔔 Stoldan chaqiruv: RequestID=%d, TableID=%d, Type=%s", requestID, tableID, requestType)
+	}
+	return request, created, nil
+}
+
+// GetRequestByID chaqiruvni ID bo'yicha oladi
+func (r *TableRepository) GetRequestByID(requestID int) (*models.TableRequest, error) {
+	request, err := scanTableRequest(r.db.QueryRow(`
+        SELECT `+tableRequestColumns+tableRequestFrom+`
+        WHERE q.request_id = $1
+    `, requestID))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Table GetRequestByID xatolik: %v", err)
+	}
+	return request, err
+}
+
+// GetPendingRequests qabul qilinmagan barcha chaqiruvlarni eng eskisidan boshlab qaytaradi
+func (r *TableRepository) GetPendingRequests() ([]models.TableRequest, error) {
+	rows, err := r.db.Query(`
+        SELECT ` + tableRequestColumns + tableRequestFrom + `
+        WHERE q.acknowledged_at IS NULL
+        ORDER BY q.created_at
+    `)
+	if err != nil {
+		log.Printf("Table GetPendingRequests xatolik: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []models.TableRequest{}
+	for rows.Next() {
+		request, err := scanTableRequest(rows)
+		if err != nil {
+			log.Printf("Table GetPendingRequests (scan) xatolik: %v", err)
+			return nil, err
+		}
+		requests = append(requests, *request)
+	}
+	return requests, rows.Err()
+}
+
+// SetRequestMessage chaqiruv haqida xodimlar chatiga yuborilgan xabar ID sini saqlaydi
+func (r *TableRepository) SetRequestMessage(requestID int, messageID int) error {
+	_, err := r.db.Exec(`UPDATE table_requests SET staff_message_id = $1 WHERE request_id = $2`, messageID, requestID)
+	if err != nil {
+		log.Printf("Table SetRequestMessage xatolik: %v", err)
+	}
+	return err
+}
+
+// AcknowledgeRequest chaqiruvni qabul qilingan deb belgilaydi; allaqachon qabul qilingan bo'lsa sql.ErrNoRows qaytaradi
+func (r *TableRepository) AcknowledgeRequest(requestID int, acknowledgedBy int64) error {
+	result, err := r.db.Exec(`
+        UPDATE table_requests SET acknowledged_at = CURRENT_TIMESTAMP, acknowledged_by = $1
+        WHERE request_id = $2 AND acknowledged_at IS NULL
+    `, acknowledgedBy, requestID)
+	if err != nil {
+		log.Printf("Table AcknowledgeRequest xatolik: %v", err)
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	log.Printf("🏃 Stol chaqiruvi qabul qilindi: RequestID=%d, By=%d", requestID, acknowledgedBy)
+	return nil
+}
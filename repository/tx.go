@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// DBTX *sql.DB va *sql.Tx uchun umumiy interfeys.
+// Repository metodlari shu interfeys orqali ishlaydi, shuning uchun ular tranzaksiya ichida ham chaqirilishi mumkin.
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Prepare(query string) (*sql.Stmt, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// UnitOfWork bir nechta repository amallarini bitta tranzaksiyada bajarish uchun
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do fn ni tranzaksiya ichida bajaradi: fn xato qaytarsa yoki panic bo'lsa rollback, aks holda commit qilinadi.
+// fn ichidagi repositorylar WithTx(tx) orqali olinishi kerak.
+func (u *UnitOfWork) Do(fn func(tx *sql.Tx) error) (err error) {
+	tx, err := u.db.Begin()
+	if err != nil {
+		log.Printf("UnitOfWork: tranzaksiyani boshlashda xatolik: %v", err)
+		return fmt.Errorf("tranzaksiyani boshlashda xatolik: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("UnitOfWork: rollback xatolik: %v", rbErr)
+			}
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("UnitOfWork: commit xatolik: %v", err)
+		return fmt.Errorf("tranzaksiyani yakunlashda xatolik: %w", err)
+	}
+	return nil
+}
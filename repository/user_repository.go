@@ -9,13 +9,18 @@ import (
 )
 
 type UserRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// WithTx berilgan tranzaksiya ichida ishlaydigan repository nusxasini qaytaradi
+func (r *UserRepository) WithTx(tx *sql.Tx) *UserRepository {
+	return &UserRepository{db: tx}
+}
+
 func (r *UserRepository) Create(user *models.User) error {
 	// 'role' maydonini ham INSERT so'roviga qo'shamiz
 	query := `
@@ -116,6 +121,63 @@ func (r *UserRepository) GetByPhoneNumber(phoneNumber string) (*models.User, err
 	return &user, nil
 }
 
+// ReleasePhone telefon raqamini berilgan foydalanuvchidan boshqa barcha foydalanuvchilardan olib tashlaydi
+// va raqami olib tashlangan foydalanuvchilarning Telegram ID'larini qaytaradi.
+func (r *UserRepository) ReleasePhone(phoneNumber string, exceptTgID int64) ([]int64, error) {
+	rows, err := r.db.Query(`
+        UPDATE users SET phone = '', updated_at = CURRENT_TIMESTAMP
+        WHERE phone = $1 AND telegram_id <> $2
+        RETURNING telegram_id
+    `, phoneNumber, exceptTgID)
+	if err != nil {
+		log.Printf("UserRepository.ReleasePhone: xatolik: %v", err)
+		return nil, fmt.Errorf("telefon raqamini bo'shatishda xatolik: %w", err)
+	}
+	defer rows.Close()
+
+	var released []int64
+	for rows.Next() {
+		var tgID int64
+		if err := rows.Scan(&tgID); err != nil {
+			return nil, fmt.Errorf("telefon raqamini bo'shatishda xatolik: %w", err)
+		}
+		released = append(released, tgID)
+		log.Printf("📱 Telefon raqami eski hisobdan olib tashlandi: TelegramID=%d", tgID)
+	}
+	return released, rows.Err()
+}
+
+// UpdateRole foydalanuvchi rolini o'zgartiradi
+func (r *UserRepository) UpdateRole(tgID int64, role string) error {
+	result, err := r.db.Exec(`
+        UPDATE users SET role = $1, updated_at = CURRENT_TIMESTAMP
+        WHERE telegram_id = $2
+    `, role, tgID)
+	if err != nil {
+		log.Printf("UserRepository.UpdateRole: exec xatolik: %v", err)
+		return fmt.Errorf("foydalanuvchi rolini yangilashda xatolik: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("foydalanuvchi topilmadi (Telegram ID: %d): %w", tgID, sql.ErrNoRows)
+	}
+
+	log.Printf("🔄 Foydalanuvchi roli yangilandi: TelegramID=%d, Yangi rol='%s'", tgID, role)
+	return nil
+}
+
+// CountByRole berilgan roldagi foydalanuvchilar sonini qaytaradi
+func (r *UserRepository) CountByRole(role string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM users WHERE role = $1`, role).Scan(&count)
+	if err != nil {
+		log.Printf("UserRepository.CountByRole: xatolik: %v", err)
+		return 0, err
+	}
+	return count, nil
+}
+
 func (r *UserRepository) Exists(tgID int64) bool {
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE telegram_id = $1)` // 'telegram_id' ni ishlatamiz
 	var exists bool
@@ -2,7 +2,8 @@ package routes
 
 import (
 	"amur/handlers"
-	"amur/pkg/jwt_auth" // jwt_auth paketini import qilamiz
+	"amur/middleware"
+	"amur/models"
 	"net/http"
 
 	gorillaHandlers "github.com/gorilla/handlers"
@@ -10,7 +11,7 @@ import (
 )
 
 // SetupRoutes funksiyasi barcha API marshrutlarini sozlaydi
-func SetupRoutes(foodHandler *handlers.FoodHandler, userHandler *handlers.UserHandler, basketOrderHandler *handlers.BasketOrderHandler, orderHandler *handlers.OrderHandler) http.Handler {
+func SetupRoutes(foodHandler *handlers.FoodHandler, userHandler *handlers.UserHandler, basketOrderHandler *handlers.BasketOrderHandler, orderHandler *handlers.OrderHandler, tableHandler *handlers.TableHandler, deliveryZoneHandler *handlers.DeliveryZoneHandler) http.Handler {
 	r := mux.NewRouter()
 
 	// API prefix
@@ -18,8 +19,10 @@ func SetupRoutes(foodHandler *handlers.FoodHandler, userHandler *handlers.UserHa
 
 	// --- Public (autentifikatsiya talab qilinmaydigan) marshrutlar ---
 	// Bu endpointlarga har kim token bo'lmasa ham murojaat qila oladi.
-	api.HandleFunc("/register", userHandler.RegisterUser).Methods("POST") // Ro'yxatdan o'tish
-	api.HandleFunc("/login", userHandler.Login).Methods("POST")           // Tizimga kirish
+	api.HandleFunc("/register", userHandler.RegisterUser).Methods("POST")       // Ro'yxatdan o'tish
+	api.HandleFunc("/login", userHandler.Login).Methods("POST")                 // Tizimga kirish
+	api.HandleFunc("/token/refresh", userHandler.RefreshToken).Methods("POST")  // Access tokenni yangilash
+	api.HandleFunc("/auth/telegram", userHandler.TelegramLogin).Methods("POST") // Mini App / Login Widget orqali kirish
 
 	// Health check (server holatini tekshirish uchun)
 	api.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -36,51 +39,98 @@ func SetupRoutes(foodHandler *handlers.FoodHandler, userHandler *handlers.UserHa
 	// Bu subrouterga 'AuthMiddleware' qo'llaniladi.
 	// Barcha marshrutlar ushbu subrouter orqali o'tadi va token tekshiriladi.
 	authRequired := api.PathPrefix("/").Subrouter()
-	authRequired.Use(jwt_auth.AuthMiddleware) // Bu yerda AuthMiddleware qo'llaniladi
+	authRequired.Use(middleware.AuthMiddleware) // Bu yerda AuthMiddleware qo'llaniladi
+
+	// requirePermission marshrutni faqat berilgan ruxsatga ega rollar uchun ochadi
+	requirePermission := func(permission string, next http.HandlerFunc) http.HandlerFunc {
+		return middleware.PermissionMiddleware(next, permission)
+	}
+
+	// idempotent qayta yuborilgan so'rovni (Idempotency-Key) ikkinchi marta bajarmaydi
+	idempotent := middleware.IdempotencyMiddleware
 
 	// --- AuthMiddleware orqali himoyalangan marshrutlar ---
 
 	// Food routes
-	// Bular endi himoyalangan. Faqat to'g'ri JWT tokeni bilan kirish mumkin.
+	// Menyuni ko'rish har bir foydalanuvchiga, o'zgartirish esa faqat menu.write ruxsati borlarga.
 	authRequired.HandleFunc("/foods", foodHandler.GetAllFoods).Methods("GET")
-	authRequired.HandleFunc("/foods", foodHandler.CreateFood).Methods("POST")
+	authRequired.HandleFunc("/foods", requirePermission(models.PermMenuWrite, foodHandler.CreateFood)).Methods("POST")
 	authRequired.HandleFunc("/foods/{id:[0-9]+}", foodHandler.GetFoodByID).Methods("GET")
-	authRequired.HandleFunc("/foods/{id:[0-9]+}", foodHandler.UpdateFood).Methods("PUT")
-	authRequired.HandleFunc("/foods/{id:[0-9]+}", foodHandler.DeleteFood).Methods("DELETE")
+	authRequired.HandleFunc("/foods/{id:[0-9]+}", requirePermission(models.PermMenuWrite, foodHandler.UpdateFood)).Methods("PUT")
+	authRequired.HandleFunc("/foods/{id:[0-9]+}", requirePermission(models.PermMenuWrite, foodHandler.DeleteFood)).Methods("DELETE")
+	authRequired.HandleFunc("/foods/{id:[0-9]+}/modifiers", requirePermission(models.PermMenuWrite, foodHandler.SetFoodModifiers)).Methods("PUT")
 	authRequired.HandleFunc("/foods/category/{category}", foodHandler.GetFoodsByCategory).Methods("GET")
-	authRequired.HandleFunc("/foods/stats", foodHandler.GetFoodStats).Methods("GET")
+	authRequired.HandleFunc("/foods/stats", requirePermission(models.PermStatsRead, foodHandler.GetFoodStats)).Methods("GET")
 
 	// User routes
-	// Foydalanuvchilar ro'yxati va statistikasi ham himoyalangan.
-	// Keyinchalik, RoleMiddleware yordamida faqat administratorlarga ruxsat berishingiz mumkin.
-	authRequired.HandleFunc("/users", userHandler.GetAllUsers).Methods("GET")
-	authRequired.HandleFunc("/users/stats", userHandler.GetUserStats).Methods("GET")
+	// Foydalanuvchilar ro'yxati va statistikasi faqat xodimlar uchun.
+	authRequired.HandleFunc("/users", requirePermission(models.PermUsersRead, userHandler.GetAllUsers)).Methods("GET")
+	authRequired.HandleFunc("/users/stats", requirePermission(models.PermStatsRead, userHandler.GetUserStats)).Methods("GET")
+	authRequired.HandleFunc("/logout", userHandler.Logout).Methods("POST") // Joriy sessiyani bekor qilish
 
 	// Basket Order Routes
 	// Endi `telegramID` URLdan emas, JWT tokendan olinadi.
-	authRequired.HandleFunc("/basket-order", basketOrderHandler.AddToBasket).Methods("POST")
+	authRequired.HandleFunc("/basket-order", idempotent(basketOrderHandler.AddToBasket)).Methods("POST")
 	authRequired.HandleFunc("/basket-order", basketOrderHandler.GetBasketOrders).Methods("GET")
+	authRequired.HandleFunc("/basket-order", basketOrderHandler.SyncBasket).Methods("PUT")                             // Butun savatchani almashtirish
+	authRequired.HandleFunc("/basket-order/{foodID:[0-9]+}", basketOrderHandler.UpdateBasketQuantity).Methods("PATCH") // Miqdorni o'rnatish (0 - o'chirish)
 	authRequired.HandleFunc("/basket-order/{foodID:[0-9]+}", basketOrderHandler.RemoveFromBasket).Methods("DELETE")
+	authRequired.HandleFunc("/basket-order/lines/{basketOrderID:[0-9]+}", basketOrderHandler.UpdateBasketLine).Methods("PATCH") // Modifikatorli qator miqdori
+	authRequired.HandleFunc("/basket-order/lines/{basketOrderID:[0-9]+}", basketOrderHandler.RemoveBasketLine).Methods("DELETE")
 	authRequired.HandleFunc("/basket-order", basketOrderHandler.ClearBasket).Methods("DELETE")
 
 	// Order Routes
 	// Buyurtmalar yaratish va ko'rish uchun ham `telegramID` tokendan olinadi.
-	authRequired.HandleFunc("/orders", orderHandler.CreateOrder).Methods("POST")
+	authRequired.HandleFunc("/orders", idempotent(orderHandler.CreateOrder)).Methods("POST")
 	authRequired.HandleFunc("/orders", orderHandler.GetUserOrders).Methods("GET")
 	authRequired.HandleFunc("/orders/{orderID:[0-9]+}", orderHandler.GetOrderDetails).Methods("GET")
-	authRequired.HandleFunc("/orders/{orderID:[0-9]+}/status", orderHandler.UpdateOrderStatus).Methods("PUT") // Admin roli bilan himoyalash kerak
-	authRequired.HandleFunc("/orders/stats", orderHandler.GetOrderStats).Methods("GET")                       // Admin roli bilan himoyalash kerak
+	authRequired.HandleFunc("/orders/{orderID:[0-9]+}/status", requirePermission(models.PermOrdersStatusUpdate, orderHandler.UpdateOrderStatus)).Methods("PUT")
+	authRequired.HandleFunc("/orders/{orderID:[0-9]+}/cancel", orderHandler.CancelOrder).Methods("POST")           // Faqat buyurtma egasi
+	authRequired.HandleFunc("/orders/{orderID:[0-9]+}/history", orderHandler.GetOrderStatusHistory).Methods("GET") // Egasi yoki orders.read.all
+	authRequired.HandleFunc("/order-statuses", orderHandler.GetOrderStatuses).Methods("GET")
+	authRequired.HandleFunc("/tables/scan", orderHandler.ScanTable).Methods("POST")                                     // Stol QR kodi: ochiq hisobni ochish yoki unga qo'shilish
+	authRequired.HandleFunc("/table-sessions/{sessionID:[0-9]+}/bill", orderHandler.GetTableSessionBill).Methods("GET") // Ishtirokchilar yoki ofitsiant
+	authRequired.HandleFunc("/table-sessions/{sessionID:[0-9]+}/split", orderHandler.SplitTableSessionBill).Methods("POST")
+	authRequired.HandleFunc("/tables/requests", tableHandler.CreateTableRequest).Methods("POST")       // Ofitsiantni yoki hisobni chaqirish
+	authRequired.HandleFunc("/delivery-zones/check", deliveryZoneHandler.CheckDelivery).Methods("GET") // Manzilga yetkazib berish narxi va vaqti
+	authRequired.HandleFunc("/orders/stats", requirePermission(models.PermStatsRead, orderHandler.GetOrderStats)).Methods("GET")
 
 	// Admin-only routes
-	// Bu marshrutlar AuthMiddleware orqali himoyalangan. Rol bo'yicha cheklovlarni qo'shishni unutmang.
-	authRequired.HandleFunc("/admin/orders/{orderID:[0-9]+}", orderHandler.DeleteOrderAdmin).Methods("DELETE")
+	// /admin/ ostidagi barcha marshrutlar ruxsatlar bo'yicha himoyalangan.
+	authRequired.HandleFunc("/admin/orders", requirePermission(models.PermOrdersReadAll, orderHandler.ListOrdersAdmin)).Methods("GET")
+	authRequired.HandleFunc("/admin/orders/{orderID:[0-9]+}", requirePermission(models.PermOrdersDelete, orderHandler.DeleteOrderAdmin)).Methods("DELETE")
+	authRequired.HandleFunc("/admin/orders/{orderID:[0-9]+}/courier", requirePermission(models.PermOrdersAssign, orderHandler.AssignCourier)).Methods("PUT")
+	authRequired.HandleFunc("/admin/users/{telegramID:[0-9]+}/sessions", requirePermission(models.PermSessionsRevoke, userHandler.RevokeUserSessions)).Methods("DELETE")
+	authRequired.HandleFunc("/admin/users/{telegramID:[0-9]+}/role", requirePermission(models.PermUsersRolesManage, userHandler.UpdateUserRole)).Methods("PUT")
+	authRequired.HandleFunc("/admin/table-sessions", requirePermission(models.PermTableSessionsManage, orderHandler.GetOpenTableSessions)).Methods("GET")
+	authRequired.HandleFunc("/admin/table-sessions/{sessionID:[0-9]+}/close", requirePermission(models.PermTableSessionsManage, orderHandler.CloseTableSession)).Methods("POST")
+	authRequired.HandleFunc("/admin/table-requests", requirePermission(models.PermTableSessionsManage, tableHandler.GetPendingTableRequests)).Methods("GET")
+	authRequired.HandleFunc("/admin/table-requests/{requestID:[0-9]+}/acknowledge", requirePermission(models.PermTableSessionsManage, tableHandler.AcknowledgeTableRequest)).Methods("POST")
+	authRequired.HandleFunc("/admin/delivery-zones", requirePermission(models.PermDeliveryZonesManage, deliveryZoneHandler.GetZones)).Methods("GET")
+	authRequired.HandleFunc("/admin/delivery-zones", requirePermission(models.PermDeliveryZonesManage, deliveryZoneHandler.CreateZone)).Methods("POST")
+	authRequired.HandleFunc("/admin/delivery-zones/{zoneID:[0-9]+}", requirePermission(models.PermDeliveryZonesManage, deliveryZoneHandler.GetZone)).Methods("GET")
+	authRequired.HandleFunc("/admin/delivery-zones/{zoneID:[0-9]+}", requirePermission(models.PermDeliveryZonesManage, deliveryZoneHandler.UpdateZone)).Methods("PUT")
+	authRequired.HandleFunc("/admin/delivery-zones/{zoneID:[0-9]+}", requirePermission(models.PermDeliveryZonesManage, deliveryZoneHandler.DeleteZone)).Methods("DELETE")
+	authRequired.HandleFunc("/admin/halls", requirePermission(models.PermTablesManage, tableHandler.GetHalls)).Methods("GET")
+	authRequired.HandleFunc("/admin/halls", requirePermission(models.PermTablesManage, tableHandler.CreateHall)).Methods("POST")
+	authRequired.HandleFunc("/admin/halls/{hallID:[0-9]+}", requirePermission(models.PermTablesManage, tableHandler.UpdateHall)).Methods("PUT")
+	authRequired.HandleFunc("/admin/halls/{hallID:[0-9]+}", requirePermission(models.PermTablesManage, tableHandler.DeleteHall)).Methods("DELETE")
+	authRequired.HandleFunc("/admin/halls/{hallID:[0-9]+}/qr", requirePermission(models.PermTablesManage, tableHandler.GetHallQRSheet)).Methods("GET") // PDF varaq yoki ZIP
+	authRequired.HandleFunc("/admin/tables", requirePermission(models.PermTablesManage, tableHandler.GetTables)).Methods("GET")
+	authRequired.HandleFunc("/admin/tables", requirePermission(models.PermTablesManage, tableHandler.CreateTable)).Methods("POST")
+	authRequired.HandleFunc("/admin/tables/{tableID:[0-9]+}", requirePermission(models.PermTablesManage, tableHandler.GetTable)).Methods("GET")
+	authRequired.HandleFunc("/admin/tables/{tableID:[0-9]+}", requirePermission(models.PermTablesManage, tableHandler.UpdateTable)).Methods("PUT")
+	authRequired.HandleFunc("/admin/tables/{tableID:[0-9]+}", requirePermission(models.PermTablesManage, tableHandler.DeleteTable)).Methods("DELETE")
+	authRequired.HandleFunc("/admin/tables/{tableID:[0-9]+}/token", requirePermission(models.PermTablesManage, tableHandler.RegenerateTableToken)).Methods("POST") // Yangi QR token
+	authRequired.HandleFunc("/admin/tables/{tableID:[0-9]+}/qr", requirePermission(models.PermTablesManage, tableHandler.GetTableQRCode)).Methods("GET")           // PNG yoki SVG
+	authRequired.HandleFunc("/admin/roles", requirePermission(models.PermUsersRolesManage, userHandler.GetRoles)).Methods("GET")
 
 	// --- CORS middleware ---
 	// Bu barcha so'rovlar uchun CORS sozlamalarini o'rnatadi.
 	corsHandler := gorillaHandlers.CORS(
 		gorillaHandlers.AllowedOrigins([]string{"*"}), // Diqqat: Productionda faqat kerakli originlarni ko'rsating!
-		gorillaHandlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-		gorillaHandlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type", "Authorization"}),
+		gorillaHandlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+		gorillaHandlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type", "Authorization", middleware.IdempotencyKeyHeader}),
 	)(r)
 
 	return corsHandler
@@ -7,47 +7,195 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+)
+
+const (
+	MaxBasketItemQuantity = 50  // Bitta mahsulotning savatchadagi eng ko'p miqdori
+	MaxBasketLines        = 100 // Savatchadagi turli mahsulotlarning eng ko'p soni
+)
+
+var (
+	ErrBasketFoodNotFound  = errors.New("ovqat topilmadi")
+	ErrBasketItemNotFound  = errors.New("savatcha elementi topilmadi")
+	ErrInvalidBasketItem   = errors.New("savatcha elementi noto'g'ri")
+	ErrBasketQuantityLimit = fmt.Errorf("bitta mahsulot miqdori %d dan oshmasligi kerak", MaxBasketItemQuantity)
 )
 
 type BasketOrderService struct {
+	uow        *repository.UnitOfWork
 	basketRepo *repository.BasketOrderRepository
 	foodRepo   *repository.FoodRepository // Oziq-ovqat ma'lumotlarini olish uchun
+	zones      *DeliveryZoneService       // Yetkazib berish narxi manzil tushgan hududdan olinadi
+	pricing    PricingPolicy
 }
 
-func NewBasketOrderService(basketRepo *repository.BasketOrderRepository, foodRepo *repository.FoodRepository) *BasketOrderService {
+func NewBasketOrderService(uow *repository.UnitOfWork, basketRepo *repository.BasketOrderRepository, foodRepo *repository.FoodRepository, zones *DeliveryZoneService, pricing PricingPolicy) *BasketOrderService {
 	return &BasketOrderService{
+		uow:        uow,
 		basketRepo: basketRepo,
 		foodRepo:   foodRepo,
+		zones:      zones,
+		pricing:    pricing,
 	}
 }
 
-// AddToBasket savatchaga mahsulot qo'shadi yoki miqdorini yangilaydi
-func (s *BasketOrderService) AddToBasket(telegramID int64, req *models.AddToBasketRequest) (*models.BasketOrder, error) {
-	// Mahsulot mavjudligini tekshirish
-	food, err := s.foodRepo.GetByID(req.FoodID)
+// basketLine savatcha qatorining kalitlari va qo'shilayotgan paytdagi bitta dona narxi
+type basketLine struct {
+	modifiersKey string
+	note         string
+	unitPrice    float64
+}
+
+// prepareLine ovqat mavjudligini, tanlangan modifikatorlarni va izohni tekshiradi hamda savatcha qatori kalitini va joriy narxini qaytaradi
+func prepareLine(foodRepo *repository.FoodRepository, foodID int, optionIDs []int, note string) (*basketLine, error) {
+	food, err := foodRepo.GetByID(foodID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("id=%d bo'lgan ovqat topilmadi", req.FoodID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: id=%d", ErrBasketFoodNotFound, foodID)
 		}
 		return nil, fmt.Errorf("ovqatni olishda xatolik: %w", err)
 	}
 
-	// Savatchaga qo'shish yoki miqdorini oshirish
-	order, err := s.basketRepo.AddToBasket(telegramID, food.FoodID)
+	groups, err := foodRepo.GetModifierGroups([]int{foodID})
+	if err != nil {
+		return nil, fmt.Errorf("modifikatorlarni olishda xatolik: %w", err)
+	}
+	_, delta, err := resolveModifiers(groups[foodID], optionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	cleanNote, err := normalizeLineNote(note)
+	if err != nil {
+		return nil, err
+	}
+	return &basketLine{
+		modifiersKey: models.ModifiersKey(optionIDs),
+		note:         cleanNote,
+		unitPrice:    roundMoney(food.FoodPrice + delta),
+	}, nil
+}
+
+// basketLineKey savatcha qatorini (ovqat + modifikatorlar + izoh) bir qiymatli aniqlaydigan kalit
+func basketLineKey(foodID int, modifiersKey, note string) string {
+	return fmt.Sprintf("%d|%s|%s", foodID, modifiersKey, note)
+}
+
+// validateDeliveryType savatcha xulosasi uchun berilgan yetkazib berish turini tekshiradi (bo'sh qiymat - to'lovlarsiz)
+func validateDeliveryType(deliveryType string) error {
+	switch deliveryType {
+	case "", models.DeliveryTypeDelivery, models.DeliveryTypePickup, models.DeliveryTypeDineIn:
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidDeliveryType, deliveryType)
+}
+
+// validatePriceQuery savatcha xulosasi uchun yetkazib berish turini va manzil koordinatalarini tekshiradi
+func validatePriceQuery(query models.BasketPriceQuery) error {
+	if err := validateDeliveryType(query.DeliveryType); err != nil {
+		return err
+	}
+	if (query.Latitude == nil) != (query.Longitude == nil) {
+		return fmt.Errorf("%w: latitude va longitude birga berilishi kerak", ErrInvalidDeliveryLocation)
+	}
+	return nil
+}
+
+// AddToBasket savatchaga mahsulot (tanlangan modifikatorlar va izoh bilan) qo'shadi yoki miqdorini yangilaydi
+func (s *BasketOrderService) AddToBasket(telegramID int64, req *models.AddToBasketRequest) (*models.BasketOrder, error) {
+	// Mahsulot, modifikatorlar va izohni tekshirish
+	line, err := prepareLine(s.foodRepo, req.FoodID, req.ModifierOptionIDs, req.Note)
+	if err != nil {
+		return nil, err
+	}
+
+	// Limitlarni tekshirish va yozish bitta tranzaksiyada, savatcha qulflangan holda: parallel qo'shishlar
+	// navbat bilan bajariladi va limitdan oshib ketmaydi
+	var order *models.BasketOrder
+	err = s.uow.Do(func(tx *sql.Tx) error {
+		basketRepo := s.basketRepo.WithTx(tx)
+
+		if err := basketRepo.LockBasket(telegramID); err != nil {
+			return fmt.Errorf("savatchani qulflashda xatolik: %w", err)
+		}
+		basketItems, err := basketRepo.GetBasketOrdersByTelegramID(telegramID)
+		if err != nil {
+			return fmt.Errorf("savatchani olishda xatolik: %w", err)
+		}
+		if err := checkBasketLimits(basketItems, req.FoodID, line); err != nil {
+			return err
+		}
+
+		// Savatchaga qo'shish yoki miqdorini oshirish
+		if order, err = basketRepo.AddToBasket(telegramID, req.FoodID, line.modifiersKey, line.note, line.unitPrice); err != nil {
+			return fmt.Errorf("savatchaga qo'shishda xatolik: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("savatchaga qo'shishda xatolik: %w", err)
+		return nil, err
 	}
 	return order, nil
 }
 
-// GetBasketOrders savatchadagi barcha buyurtmalarni Food ma'lumotlari bilan qaytaradi
-func (s *BasketOrderService) GetBasketOrders(telegramID int64) ([]map[string]interface{}, error) {
+// checkBasketLimits savatchaga yana bitta dona qo'shish limitlarni buzmasligini tekshiradi:
+// mavjud qator miqdori MaxBasketItemQuantity dan, yangi qator bilan qatorlar soni MaxBasketLines dan oshmasligi kerak
+func checkBasketLimits(basketItems []*models.BasketOrder, foodID int, line *basketLine) error {
+	if existing := findBasketLine(basketItems, foodID, line); existing != nil {
+		if existing.Quantity >= MaxBasketItemQuantity {
+			return ErrBasketQuantityLimit
+		}
+		return nil
+	}
+	return checkBasketLineCap(basketItems)
+}
+
+// findBasketLine savatchadan xuddi shu ovqat, modifikatorlar va izohli qatorni qidiradi (yo'q bo'lsa nil)
+func findBasketLine(basketItems []*models.BasketOrder, foodID int, line *basketLine) *models.BasketOrder {
+	for _, item := range basketItems {
+		if item.FoodID == foodID && item.ModifiersKey == line.modifiersKey && item.Note == line.note {
+			return item
+		}
+	}
+	return nil
+}
+
+// checkBasketLineCap savatchaga yangi qator qo'shish MaxBasketLines dan oshmasligini tekshiradi
+func checkBasketLineCap(basketItems []*models.BasketOrder) error {
+	if len(basketItems) >= MaxBasketLines {
+		return fmt.Errorf("%w: savatchada %d tadan ortiq mahsulot bo'lmasligi kerak", ErrInvalidBasketItem, MaxBasketLines)
+	}
+	return nil
+}
+
+// GetBasketOrders savatcha qatorlarini Food ma'lumotlari va umumiy narx tarkibi bilan qaytaradi.
+// Yetkazib berish turi berilsa yetkazib berish va xizmat haqi shu tur bo'yicha hisoblanadi (buyurtma yaratishdagi bilan bir xil qoidalar).
+// Qo'shilgan paytdagi narxi joriy narxdan farq qiladigan qatorlar PriceChanged bilan belgilanadi.
+func (s *BasketOrderService) GetBasketOrders(telegramID int64, query models.BasketPriceQuery) (*models.BasketSummary, error) {
+	if err := validatePriceQuery(query); err != nil {
+		return nil, err
+	}
+
 	basketItems, err := s.basketRepo.GetBasketOrdersByTelegramID(telegramID)
 	if err != nil {
 		return nil, err
 	}
 
-	var detailedBasket []map[string]interface{}
+	foodIDs := make([]int, 0, len(basketItems))
+	for _, item := range basketItems {
+		foodIDs = append(foodIDs, item.FoodID)
+	}
+	modifierGroups, err := s.foodRepo.GetModifierGroups(foodIDs)
+	if err != nil {
+		return nil, fmt.Errorf("modifikatorlarni olishda xatolik: %w", err)
+	}
+
+	summary := &models.BasketSummary{
+		Items:        []models.BasketLine{},
+		DeliveryType: query.DeliveryType,
+	}
+	var subtotal float64
 	for _, item := range basketItems {
 		food, err := s.foodRepo.GetByID(item.FoodID)
 		if err != nil {
@@ -58,23 +206,256 @@ func (s *BasketOrderService) GetBasketOrders(telegramID int64) ([]map[string]int
 			continue
 		}
 
-		detailedItem := map[string]interface{}{
-			"basket_order_id": item.BasketOrderID,
-			"tg_id":           item.TelegramID,
-			"food_id":         item.FoodID,
-			"quantity":        item.Quantity,
-			"food_name":       food.FoodName,
-			"food_category":   food.FoodCategory,
-			"food_price":      food.FoodPrice,
-			"food_image":      food.FoodImage,
-			"total_price":     float64(item.Quantity) * food.FoodPrice,
-			"created_at":      item.CreatedAt,
-			"updated_at":      item.UpdatedAt,
+		// Menyu o'zgargan bo'lsa qator buyurtma berishda rad etiladi, mijozga oldindan ko'rsatamiz
+		modifiers, delta, modErr := resolveModifiers(modifierGroups[item.FoodID], item.ModifierIDs)
+		unitPrice := roundMoney(food.FoodPrice + delta)
+
+		line := models.BasketLine{
+			BasketOrderID:  item.BasketOrderID,
+			FoodID:         item.FoodID,
+			FoodName:       food.FoodName,
+			FoodCategory:   food.FoodCategory,
+			FoodImage:      food.FoodImage,
+			FoodPrice:      food.FoodPrice,
+			Quantity:       item.Quantity,
+			Modifiers:      modifiers,
+			Note:           item.Note,
+			UnitPrice:      unitPrice,
+			UnitPriceAtAdd: item.UnitPriceAtAdd,
+			TotalPrice:     roundMoney(float64(item.Quantity) * unitPrice),
+			Available:      modErr == nil,
+			CreatedAt:      item.CreatedAt,
+			UpdatedAt:      item.UpdatedAt,
+		}
+		// Eski qatorlarda (narx saqlanmagan) o'zgarishni aniqlab bo'lmaydi
+		if item.UnitPriceAtAdd != nil && roundMoney(*item.UnitPriceAtAdd) != unitPrice {
+			line.PriceChanged = true
+			summary.HasPriceChanges = true
+		}
+		if modErr != nil {
+			line.UnavailableReason = modErr.Error()
+			summary.HasUnavailableItems = true
+		} else {
+			// Buyurtma qilib bo'lmaydigan qatorlar summaga qo'shilmaydi
+			subtotal += line.TotalPrice
+		}
+		summary.Items = append(summary.Items, line)
+	}
+
+	if len(summary.Items) == 0 {
+		// Bo'sh savatcha uchun yetkazib berish yoki xizmat haqi ko'rsatilmaydi
+		setSummaryPrices(summary, s.pricing.Breakdown(0, "", 0), true)
+		return summary, nil
+	}
+	if err := s.priceBasket(summary, subtotal, query); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// priceBasket savatcha xulosasiga to'lovlarni yozadi. Yetkazib berish narxi buyurtma yaratishdagi kabi manzil tushgan
+// hududdan olinadi; manzil berilmagan bo'lsa narx va yakuniy summa noma'lum qoladi.
+func (s *BasketOrderService) priceBasket(summary *models.BasketSummary, subtotal float64, query models.BasketPriceQuery) error {
+	if query.DeliveryType != models.DeliveryTypeDelivery {
+		setSummaryPrices(summary, s.pricing.Breakdown(subtotal, query.DeliveryType, 0), true)
+		return nil
+	}
+	if query.Latitude == nil {
+		setSummaryPrices(summary, s.pricing.Breakdown(subtotal, query.DeliveryType, 0), false)
+		return nil
+	}
+
+	quote, err := s.zones.QuoteDelivery(*query.Latitude, *query.Longitude)
+	if err != nil {
+		return err
+	}
+	summary.Delivery = quote
+	setSummaryPrices(summary, s.pricing.Breakdown(subtotal, query.DeliveryType, quote.DeliveryFee), true)
+	return nil
+}
+
+// setSummaryPrices narx tarkibini xulosaga ko'chiradi; deliveryKnown=false bo'lsa yetkazib berish narxi va yakuniy summa null bo'ladi
+func setSummaryPrices(summary *models.BasketSummary, breakdown models.PriceBreakdown, deliveryKnown bool) {
+	summary.Subtotal = breakdown.Subtotal
+	summary.ServiceCharge = breakdown.ServiceCharge
+	summary.Discount = breakdown.Discount
+	summary.DeliveryFee, summary.GrandTotal = nil, nil
+	if deliveryKnown {
+		summary.DeliveryFee = &breakdown.DeliveryFee
+		summary.GrandTotal = &breakdown.GrandTotal
+	}
+}
+
+// SetQuantity savatchadagi modifikatorsiz va izohsiz mahsulot qatori miqdorini to'g'ridan-to'g'ri o'rnatadi.
+// quantity 0 bo'lsa faqat shu qator o'chiriladi (modifikatorli va izohli qatorlar qoladi) va nil qaytariladi.
+func (s *BasketOrderService) SetQuantity(telegramID int64, foodID, quantity int) (*models.BasketOrder, error) {
+	if quantity < 0 {
+		return nil, fmt.Errorf("%w: miqdor manfiy bo'lmasligi kerak", ErrInvalidBasketItem)
+	}
+	if quantity > MaxBasketItemQuantity {
+		return nil, ErrBasketQuantityLimit
+	}
+
+	if quantity == 0 {
+		err := s.uow.Do(func(tx *sql.Tx) error {
+			basketRepo := s.basketRepo.WithTx(tx)
+			if err := basketRepo.LockBasket(telegramID); err != nil {
+				return fmt.Errorf("savatchani qulflashda xatolik: %w", err)
+			}
+			if err := basketRepo.RemovePlainLine(telegramID, foodID); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return fmt.Errorf("%w: food_id=%d", ErrBasketItemNotFound, foodID)
+				}
+				return fmt.Errorf("savatchadan o'chirishda xatolik: %w", err)
+			}
+			return nil
+		})
+		return nil, err
+	}
+
+	// Majburiy modifikator guruhlari bo'lgan ovqat modifikatorsiz qo'shilmaydi
+	line, err := prepareLine(s.foodRepo, foodID, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// AddToBasket dagi kabi: savatcha qulflangan holda qatorlar sonini tekshirish va yozish bitta tranzaksiyada
+	var order *models.BasketOrder
+	err = s.uow.Do(func(tx *sql.Tx) error {
+		basketRepo := s.basketRepo.WithTx(tx)
+
+		if err := basketRepo.LockBasket(telegramID); err != nil {
+			return fmt.Errorf("savatchani qulflashda xatolik: %w", err)
+		}
+		basketItems, err := basketRepo.GetBasketOrdersByTelegramID(telegramID)
+		if err != nil {
+			return fmt.Errorf("savatchani olishda xatolik: %w", err)
+		}
+		if findBasketLine(basketItems, foodID, line) == nil {
+			if err := checkBasketLineCap(basketItems); err != nil {
+				return err
+			}
+		}
+
+		if order, err = basketRepo.SetQuantity(telegramID, foodID, line.modifiersKey, line.note, quantity, line.unitPrice); err != nil {
+			return fmt.Errorf("savatcha miqdorini o'rnatishda xatolik: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// SetLineQuantity savatcha qatori miqdorini qator ID'si bo'yicha o'rnatadi (0 - qatorni o'chirish)
+func (s *BasketOrderService) SetLineQuantity(telegramID int64, basketOrderID, quantity int) (*models.BasketOrder, error) {
+	if quantity < 0 {
+		return nil, fmt.Errorf("%w: miqdor manfiy bo'lmasligi kerak", ErrInvalidBasketItem)
+	}
+	if quantity > MaxBasketItemQuantity {
+		return nil, ErrBasketQuantityLimit
+	}
+
+	if quantity == 0 {
+		return nil, s.RemoveLine(telegramID, basketOrderID)
+	}
+
+	order, err := s.basketRepo.SetLineQuantity(telegramID, basketOrderID, quantity)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: basket_order_id=%d", ErrBasketItemNotFound, basketOrderID)
+		}
+		return nil, fmt.Errorf("savatcha qatorini yangilashda xatolik: %w", err)
+	}
+	return order, nil
+}
+
+// RemoveLine savatchadan bitta qatorni qator ID'si bo'yicha olib tashlaydi
+func (s *BasketOrderService) RemoveLine(telegramID int64, basketOrderID int) error {
+	if err := s.basketRepo.RemoveLine(telegramID, basketOrderID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: basket_order_id=%d", ErrBasketItemNotFound, basketOrderID)
 		}
-		detailedBasket = append(detailedBasket, detailedItem)
+		return fmt.Errorf("savatcha qatorini o'chirishda xatolik: %w", err)
+	}
+	return nil
+}
+
+// ReplaceBasket butun savatchani mijozdagi holat bilan bitta tranzaksiyada almashtiradi.
+// Miqdori 0 bo'lgan qatorlar e'tiborsiz qoldiriladi; bir xil qator (ovqat + modifikatorlar + izoh) ikki marta kelsa so'rov rad etiladi.
+// Avval savatchada bo'lgan qatorlarning qo'shilgan paytdagi narxi saqlanib qoladi.
+func (s *BasketOrderService) ReplaceBasket(telegramID int64, items []models.BasketSyncItem, query models.BasketPriceQuery) (*models.BasketSummary, error) {
+	if err := validatePriceQuery(query); err != nil {
+		return nil, err
+	}
+
+	if len(items) > MaxBasketLines {
+		return nil, fmt.Errorf("%w: savatchada %d tadan ortiq mahsulot bo'lmasligi kerak", ErrInvalidBasketItem, MaxBasketLines)
+	}
+
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		if item.FoodID <= 0 {
+			return nil, fmt.Errorf("%w: food_id musbat son bo'lishi kerak", ErrInvalidBasketItem)
+		}
+		if item.Quantity < 0 {
+			return nil, fmt.Errorf("%w: food_id=%d uchun miqdor manfiy", ErrInvalidBasketItem, item.FoodID)
+		}
+		if item.Quantity > MaxBasketItemQuantity {
+			return nil, fmt.Errorf("%w (food_id=%d)", ErrBasketQuantityLimit, item.FoodID)
+		}
+		lineKey := basketLineKey(item.FoodID, models.ModifiersKey(item.ModifierOptionIDs), strings.TrimSpace(item.Note))
+		if seen[lineKey] {
+			return nil, fmt.Errorf("%w: food_id=%d qatori takrorlangan", ErrInvalidBasketItem, item.FoodID)
+		}
+		seen[lineKey] = true
+	}
+
+	err := s.uow.Do(func(tx *sql.Tx) error {
+		basketRepo := s.basketRepo.WithTx(tx)
+		foodRepo := s.foodRepo.WithTx(tx)
+
+		if err := basketRepo.LockBasket(telegramID); err != nil {
+			return fmt.Errorf("savatchani qulflashda xatolik: %w", err)
+		}
+		existing, err := basketRepo.GetBasketOrdersForUpdate(telegramID)
+		if err != nil {
+			return fmt.Errorf("savatchani olishda xatolik: %w", err)
+		}
+		previousPrices := make(map[string]float64, len(existing))
+		for _, item := range existing {
+			if item.UnitPriceAtAdd != nil {
+				previousPrices[basketLineKey(item.FoodID, item.ModifiersKey, item.Note)] = *item.UnitPriceAtAdd
+			}
+		}
+
+		if err := basketRepo.ClearBasket(telegramID); err != nil {
+			return fmt.Errorf("savatchani tozalashda xatolik: %w", err)
+		}
+		for _, item := range items {
+			if item.Quantity == 0 {
+				continue
+			}
+			line, err := prepareLine(foodRepo, item.FoodID, item.ModifierOptionIDs, item.Note)
+			if err != nil {
+				return err
+			}
+			unitPrice := line.unitPrice
+			if previous, ok := previousPrices[basketLineKey(item.FoodID, line.modifiersKey, line.note)]; ok {
+				unitPrice = previous
+			}
+			if _, err := basketRepo.SetQuantity(telegramID, item.FoodID, line.modifiersKey, line.note, item.Quantity, unitPrice); err != nil {
+				return fmt.Errorf("savatchaga yozishda xatolik: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return detailedBasket, nil
+	return s.GetBasketOrders(telegramID, query)
 }
 
 // RemoveFromBasket savatchadan mahsulotni olib tashlaydi
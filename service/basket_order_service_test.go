@@ -0,0 +1,116 @@
+package service
+
+import (
+	"amur/models"
+	"errors"
+	"testing"
+)
+
+func TestPriceBasketWithoutLocation(t *testing.T) {
+	// Manzilsiz hisoblashda hududlar servisiga murojaat qilinmaydi
+	s := &BasketOrderService{pricing: PricingPolicy{ServiceChargePercent: 10}}
+
+	tests := []struct {
+		deliveryType string
+		wantFee      *float64 // nil - noma'lum
+		wantService  float64
+		wantTotal    *float64
+	}{
+		{models.DeliveryTypeDelivery, nil, 0, nil},
+		{models.DeliveryTypePickup, new(float64), 0, floatPtr(100.5)},
+		{models.DeliveryTypeDineIn, new(float64), 10.05, floatPtr(110.55)},
+		{"", new(float64), 0, floatPtr(100.5)},
+	}
+	for _, tt := range tests {
+		summary := &models.BasketSummary{}
+		if err := s.priceBasket(summary, 100.5, models.BasketPriceQuery{DeliveryType: tt.deliveryType}); err != nil {
+			t.Fatalf("%q: %v", tt.deliveryType, err)
+		}
+		if summary.Subtotal != 100.5 || summary.ServiceCharge != tt.wantService {
+			t.Errorf("%q: subtotal=%v, xizmat haqi=%v", tt.deliveryType, summary.Subtotal, summary.ServiceCharge)
+		}
+		if tt.wantFee == nil {
+			if summary.DeliveryFee != nil || summary.GrandTotal != nil {
+				t.Errorf("%q: manzilsiz yetkazib berish narxi va yakuniy summa null bo'lishi kerak: %v, %v",
+					tt.deliveryType, summary.DeliveryFee, summary.GrandTotal)
+			}
+			continue
+		}
+		if summary.DeliveryFee == nil || *summary.DeliveryFee != *tt.wantFee ||
+			summary.GrandTotal == nil || *summary.GrandTotal != *tt.wantTotal {
+			t.Errorf("%q: delivery_fee=%v, grand_total=%v; kutilgan %v, %v",
+				tt.deliveryType, summary.DeliveryFee, summary.GrandTotal, *tt.wantFee, *tt.wantTotal)
+		}
+	}
+}
+
+func TestValidatePriceQuery(t *testing.T) {
+	lat, lng := 41.31, 69.24
+	tests := []struct {
+		name    string
+		query   models.BasketPriceQuery
+		wantErr error
+	}{
+		{"no delivery type", models.BasketPriceQuery{}, nil},
+		{"delivery with location", models.BasketPriceQuery{DeliveryType: models.DeliveryTypeDelivery, Latitude: &lat, Longitude: &lng}, nil},
+		{"unknown delivery type", models.BasketPriceQuery{DeliveryType: "kosmos"}, ErrInvalidDeliveryType},
+		{"latitude only", models.BasketPriceQuery{DeliveryType: models.DeliveryTypeDelivery, Latitude: &lat}, ErrInvalidDeliveryLocation},
+		{"longitude only", models.BasketPriceQuery{DeliveryType: models.DeliveryTypeDelivery, Longitude: &lng}, ErrInvalidDeliveryLocation},
+	}
+	for _, tt := range tests {
+		if err := validatePriceQuery(tt.query); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: xato = %v, kutilgan %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+func TestCheckBasketLimits(t *testing.T) {
+	line := &basketLine{modifiersKey: "3,7", note: "piyozsiz"}
+	fullBasket := make([]*models.BasketOrder, MaxBasketLines)
+	for i := range fullBasket {
+		fullBasket[i] = &models.BasketOrder{FoodID: 1000 + i, Quantity: 1}
+	}
+
+	tests := []struct {
+		name    string
+		items   []*models.BasketOrder
+		wantErr error
+	}{
+		{"empty basket", nil, nil},
+		{"existing line below limit", []*models.BasketOrder{{FoodID: 1, ModifiersKey: "3,7", Note: "piyozsiz", Quantity: MaxBasketItemQuantity - 1}}, nil},
+		{"existing line at limit", []*models.BasketOrder{{FoodID: 1, ModifiersKey: "3,7", Note: "piyozsiz", Quantity: MaxBasketItemQuantity}}, ErrBasketQuantityLimit},
+		{"other line at limit", []*models.BasketOrder{{FoodID: 1, ModifiersKey: "3", Note: "piyozsiz", Quantity: MaxBasketItemQuantity}}, nil},
+		{"new line in full basket", fullBasket, ErrInvalidBasketItem},
+		{"existing line in full basket", append(fullBasket[1:], &models.BasketOrder{FoodID: 1, ModifiersKey: "3,7", Note: "piyozsiz", Quantity: 2}), nil},
+	}
+	for _, tt := range tests {
+		if err := checkBasketLimits(tt.items, 1, line); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: xato = %v, kutilgan %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFindBasketLine(t *testing.T) {
+	plain := &basketLine{}
+	items := []*models.BasketOrder{
+		{BasketOrderID: 1, FoodID: 1, ModifiersKey: "3", Quantity: 2},
+		{BasketOrderID: 2, FoodID: 1, Note: "piyozsiz", Quantity: 1},
+		{BasketOrderID: 3, FoodID: 1, Quantity: 4},
+	}
+	if got := findBasketLine(items, 1, plain); got == nil || got.BasketOrderID != 3 {
+		t.Errorf("modifikatorsiz qator = %+v, kutilgan 3-qator", got)
+	}
+	if got := findBasketLine(items[:2], 1, plain); got != nil {
+		t.Errorf("modifikatorli va izohli qatorlar oddiy qator deb topildi: %+v", got)
+	}
+	if err := checkBasketLineCap(make([]*models.BasketOrder, MaxBasketLines-1)); err != nil {
+		t.Errorf("limitdan past savatcha uchun xato: %v", err)
+	}
+	if err := checkBasketLineCap(make([]*models.BasketOrder, MaxBasketLines)); !errors.Is(err, ErrInvalidBasketItem) {
+		t.Errorf("to'lgan savatcha uchun xato = %v, kutilgan %v", err, ErrInvalidBasketItem)
+	}
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"amur/models"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxBillSplitParts hisobni teng bo'lishda qismlarning eng ko'p soni
+const MaxBillSplitParts = 50
+
+var ErrInvalidBillSplit = errors.New("hisobni bo'lib bo'lmaydi")
+
+// toCents so'mdagi summani tiyinga (butun songa) aylantiradi; bo'lish faqat butun sonlarda bajariladi
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// fromCents tiyinni so'mga qaytaradi
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+// allocateCents total tiyinni weights ulushlariga mutanosib taqsimlaydi (eng katta qoldiq usuli).
+// Natija yig'indisi har doim total ga teng; qoldiq tiyinlar eng katta kasr qismiga ega ulushlarga, teng bo'lsa oldingilariga beriladi.
+// Barcha ulushlar nol bo'lsa summa teng bo'linadi.
+func allocateCents(total int64, weights []int64) []int64 {
+	shares := make([]int64, len(weights))
+	if len(weights) == 0 {
+		return shares
+	}
+
+	var sum uint64
+	for _, weight := range weights {
+		sum += uint64(weight)
+	}
+	if sum == 0 {
+		weights = make([]int64, len(weights))
+		for i := range weights {
+			weights[i] = 1
+		}
+		sum = uint64(len(weights))
+	}
+
+	remainders := make([]uint64, len(weights))
+	var allocated int64
+	for i, weight := range weights {
+		// total*weight 64 bitdan oshishi mumkin, shuning uchun 128 bitli ko'paytma bo'linadi
+		hi, lo := bits.Mul64(uint64(total), uint64(weight))
+		quotient, remainder := bits.Div64(hi, lo, sum)
+		shares[i] = int64(quotient)
+		remainders[i] = remainder
+		allocated += shares[i]
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for i := int64(0); i < total-allocated; i++ {
+		shares[order[i]]++
+	}
+	return shares
+}
+
+// SplitTableSessionBill stol hisobini mehmonlar bo'yicha, N ta teng qismga yoki buyurtma qatorlari bo'yicha bo'ladi.
+// Hisobni ko'rish huquqi GetTableSessionBill bilan bir xil; bekor qilingan buyurtmalar hisobga kirmaydi.
+func (s *OrderService) SplitTableSessionBill(sessionID int, viewer *models.Principal, req *models.BillSplitRequest) (*models.BillSplit, error) {
+	bill, err := s.GetTableSessionBill(sessionID, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []models.OrderDetailsResponse
+	var total int64
+	for _, details := range bill.Orders {
+		if details.Order.OrderStatus == models.OrderStatusCancelled {
+			continue
+		}
+		orders = append(orders, details)
+		total += toCents(details.Order.TotalPrice)
+	}
+	if len(orders) == 0 {
+		return nil, fmt.Errorf("%w: stol hisobida buyurtmalar yo'q", ErrInvalidBillSplit)
+	}
+
+	var parts []models.BillSplitPart
+	switch req.Mode {
+	case models.BillSplitByGuest:
+		parts = splitByGuest(orders)
+	case models.BillSplitEven:
+		parts, err = splitEven(total, req.Parts)
+	case models.BillSplitByItems:
+		parts, err = splitByItems(orders, req.Assignments)
+	default:
+		return nil, fmt.Errorf("%w: usul '%s', '%s' yoki '%s' bo'lishi kerak", ErrInvalidBillSplit,
+			models.BillSplitByGuest, models.BillSplitEven, models.BillSplitByItems)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BillSplit{
+		SessionID:  sessionID,
+		Mode:       req.Mode,
+		TotalPrice: fromCents(total),
+		Parts:      parts,
+	}, nil
+}
+
+// splitByGuest har bir mehmonga o'z buyurtmalari summasini beradi (mehmonlar birinchi buyurtmasi tartibida)
+func splitByGuest(orders []models.OrderDetailsResponse) []models.BillSplitPart {
+	var parts []models.BillSplitPart
+	cents := make(map[int64]int64)
+	index := make(map[int64]int)
+	for _, details := range orders {
+		telegramID := details.Order.TelegramID
+		i, ok := index[telegramID]
+		if !ok {
+			i = len(parts)
+			index[telegramID] = i
+			id := telegramID
+			parts = append(parts, models.BillSplitPart{Payer: strconv.FormatInt(telegramID, 10), TelegramID: &id})
+		}
+		parts[i].OrderIDs = append(parts[i].OrderIDs, details.Order.OrderID)
+		cents[telegramID] += toCents(details.Order.TotalPrice)
+	}
+	for i := range parts {
+		parts[i].Amount = fromCents(cents[*parts[i].TelegramID])
+	}
+	return parts
+}
+
+// splitEven jami summani n ta qismga bo'ladi; qoldiq tiyinlar birinchi qismlarga bittadan qo'shiladi
+func splitEven(total int64, n int) ([]models.BillSplitPart, error) {
+	if n < 2 || n > MaxBillSplitParts {
+		return nil, fmt.Errorf("%w: qismlar soni 2 dan %d gacha bo'lishi kerak", ErrInvalidBillSplit, MaxBillSplitParts)
+	}
+	weights := make([]int64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+	shares := allocateCents(total, weights)
+
+	parts := make([]models.BillSplitPart, n)
+	for i, share := range shares {
+		parts[i] = models.BillSplitPart{Payer: strconv.Itoa(i + 1), Amount: fromCents(share)}
+	}
+	return parts, nil
+}
+
+// splitByItems har bir buyurtma qatorini biriktirilgan to'lovchilarga bo'ladi. Buyurtmaning xizmat haqi va chegirmasi
+// to'lovchilarga shu buyurtmadagi qatorlar ulushiga mutanosib taqsimlanadi, shuning uchun har bir buyurtma
+// yakuniy summasi to'liq va aniq tarqatiladi. Bekor qilinmagan buyurtmalarning barcha qatorlari biriktirilishi shart.
+func splitByItems(orders []models.OrderDetailsResponse, assignments []models.BillSplitAssignment) ([]models.BillSplitPart, error) {
+	payersByItem := make(map[int][]string, len(assignments))
+	for _, assignment := range assignments {
+		if _, ok := payersByItem[assignment.OrderItemID]; ok {
+			return nil, fmt.Errorf("%w: #%d qator ikki marta biriktirilgan", ErrInvalidBillSplit, assignment.OrderItemID)
+		}
+		var payers []string
+		seen := make(map[string]bool)
+		for _, payer := range assignment.Payers {
+			payer = strings.TrimSpace(payer)
+			if payer == "" || seen[payer] {
+				continue
+			}
+			seen[payer] = true
+			payers = append(payers, payer)
+		}
+		if len(payers) == 0 {
+			return nil, fmt.Errorf("%w: #%d qator uchun to'lovchi ko'rsatilmagan", ErrInvalidBillSplit, assignment.OrderItemID)
+		}
+		payersByItem[assignment.OrderItemID] = payers
+	}
+
+	var parts []models.BillSplitPart
+	index := make(map[string]int)
+	cents := make(map[string]int64)
+	partFor := func(payer string) int {
+		i, ok := index[payer]
+		if !ok {
+			i = len(parts)
+			index[payer] = i
+			parts = append(parts, models.BillSplitPart{Payer: payer})
+		}
+		return i
+	}
+
+	assigned := 0
+	var unassigned []string
+	for _, details := range orders {
+		if len(details.OrderItems) == 0 {
+			return nil, fmt.Errorf("%w: #%d buyurtmada qatorlar yo'q, uni qatorlar bo'yicha bo'lib bo'lmaydi", ErrInvalidBillSplit, details.Order.OrderID)
+		}
+		// Buyurtma ichida har bir to'lovchining qatorlar bo'yicha ulushi (tiyinda)
+		var orderPayers []string
+		weights := make(map[string]int64)
+		for _, item := range details.OrderItems {
+			payers, ok := payersByItem[item.OrderItemID]
+			if !ok {
+				unassigned = append(unassigned, "#"+strconv.Itoa(item.OrderItemID))
+				continue
+			}
+			assigned++
+			// Umumiy qator to'lovchilarga teng bo'linadi
+			equal := make([]int64, len(payers))
+			for i := range equal {
+				equal[i] = 1
+			}
+			lineShares := allocateCents(toCents(item.ItemPrice)*int64(item.Quantity), equal)
+			for i, payer := range payers {
+				if _, ok := weights[payer]; !ok {
+					orderPayers = append(orderPayers, payer)
+				}
+				weights[payer] += lineShares[i]
+				i := partFor(payer)
+				parts[i].OrderItemIDs = append(parts[i].OrderItemIDs, item.OrderItemID)
+			}
+		}
+		if len(orderPayers) == 0 {
+			continue
+		}
+
+		orderWeights := make([]int64, len(orderPayers))
+		for i, payer := range orderPayers {
+			orderWeights[i] = weights[payer]
+		}
+		for i, share := range allocateCents(toCents(details.Order.TotalPrice), orderWeights) {
+			cents[orderPayers[i]] += share
+		}
+	}
+
+	if len(unassigned) > 0 {
+		return nil, fmt.Errorf("%w: quyidagi qatorlar biriktirilmagan: %s", ErrInvalidBillSplit, strings.Join(unassigned, ", "))
+	}
+	if assigned != len(payersByItem) {
+		return nil, fmt.Errorf("%w: stol hisobida bo'lmagan yoki bekor qilingan buyurtma qatori biriktirilgan", ErrInvalidBillSplit)
+	}
+
+	for i := range parts {
+		parts[i].Amount = fromCents(cents[parts[i].Payer])
+	}
+	return parts, nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"amur/models"
+	"errors"
+	"math"
+	"testing"
+)
+
+// sumParts qismlar yig'indisini tiyinda qaytaradi
+func sumParts(parts []models.BillSplitPart) int64 {
+	var total int64
+	for _, part := range parts {
+		total += toCents(part.Amount)
+	}
+	return total
+}
+
+// testOrder buyurtma qatorlari va xizmat haqi bilan stol buyurtmasini yasaydi (TotalPrice = qatorlar + xizmat haqi)
+func testOrder(orderID int, telegramID int64, serviceCharge float64, items ...models.OrderItem) models.OrderDetailsResponse {
+	var subtotal float64
+	for _, item := range items {
+		subtotal += item.ItemPrice * float64(item.Quantity)
+	}
+	return models.OrderDetailsResponse{
+		Order: models.Order{
+			OrderID:       orderID,
+			TelegramID:    telegramID,
+			Subtotal:      roundMoney(subtotal),
+			ServiceCharge: serviceCharge,
+			TotalPrice:    roundMoney(subtotal + serviceCharge),
+		},
+		OrderItems: items,
+	}
+}
+
+func TestAllocateCents(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []int64
+		want    []int64
+	}{
+		{"odd total 3 ways", 10001, []int64{1, 1, 1}, []int64{3334, 3334, 3333}},
+		{"odd total 7 ways", 100, []int64{1, 1, 1, 1, 1, 1, 1}, []int64{15, 15, 14, 14, 14, 14, 14}},
+		{"proportional", 1000, []int64{1, 2, 3, 4}, []int64{100, 200, 300, 400}},
+		{"largest remainder wins", 100, []int64{1, 1, 2}, []int64{25, 25, 50}},
+		{"zero weights split equally", 10, []int64{0, 0, 0}, []int64{4, 3, 3}},
+		{"zero weight gets nothing", 999, []int64{0, 5, 5}, []int64{0, 500, 499}},
+		{"zero total", 0, []int64{3, 7}, []int64{0, 0}},
+		{"no overflow on large values", math.MaxInt64 / 2, []int64{math.MaxInt64 / 4, math.MaxInt64 / 4}, []int64{math.MaxInt64/4 + 1, math.MaxInt64 / 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocateCents(tt.total, tt.weights)
+			var sum int64
+			for i := range got {
+				sum += got[i]
+				if got[i] != tt.want[i] {
+					t.Errorf("ulushlar = %v, kutilgan %v", got, tt.want)
+				}
+			}
+			if sum != tt.total {
+				t.Errorf("yig'indi = %d, kutilgan %d", sum, tt.total)
+			}
+		})
+	}
+}
+
+func TestSplitEven(t *testing.T) {
+	for _, tt := range []struct {
+		total int64
+		n     int
+	}{{10001, 3}, {10001, 7}, {1, 7}, {999999, 50}} {
+		parts, err := splitEven(tt.total, tt.n)
+		if err != nil {
+			t.Fatalf("splitEven(%d, %d): %v", tt.total, tt.n, err)
+		}
+		if len(parts) != tt.n {
+			t.Fatalf("qismlar soni = %d, kutilgan %d", len(parts), tt.n)
+		}
+		if sum := sumParts(parts); sum != tt.total {
+			t.Errorf("splitEven(%d, %d) yig'indi = %d", tt.total, tt.n, sum)
+		}
+		// Teng bo'lishda qismlar orasidagi farq ko'pi bilan 1 tiyin
+		if diff := toCents(parts[0].Amount) - toCents(parts[len(parts)-1].Amount); diff < 0 || diff > 1 {
+			t.Errorf("splitEven(%d, %d) qismlar farqi = %d tiyin", tt.total, tt.n, diff)
+		}
+	}
+
+	for _, n := range []int{0, 1, MaxBillSplitParts + 1} {
+		if _, err := splitEven(100, n); !errors.Is(err, ErrInvalidBillSplit) {
+			t.Errorf("splitEven(100, %d) xatosi = %v, kutilgan %v", n, err, ErrInvalidBillSplit)
+		}
+	}
+}
+
+func TestSplitByGuest(t *testing.T) {
+	orders := []models.OrderDetailsResponse{
+		testOrder(1, 100, 1.11, models.OrderItem{OrderItemID: 11, ItemPrice: 33.33, Quantity: 1}),
+		testOrder(2, 200, 0, models.OrderItem{OrderItemID: 21, ItemPrice: 10.01, Quantity: 3}),
+		testOrder(3, 100, 0.5, models.OrderItem{OrderItemID: 31, ItemPrice: 7.77, Quantity: 1}),
+	}
+	parts := splitByGuest(orders)
+	if len(parts) != 2 || parts[0].Payer != "100" || len(parts[0].OrderIDs) != 2 {
+		t.Fatalf("noto'g'ri qismlar: %+v", parts)
+	}
+
+	var total int64
+	for _, details := range orders {
+		total += toCents(details.Order.TotalPrice)
+	}
+	if sum := sumParts(parts); sum != total {
+		t.Errorf("yig'indi = %d, kutilgan %d", sum, total)
+	}
+}
+
+func TestSplitByItems(t *testing.T) {
+	orders := []models.OrderDetailsResponse{
+		// Xizmat haqi bor buyurtma: 10.00 + 20.01 + 0 (bepul qator), xizmat haqi 3.01
+		testOrder(1, 100, 3.01,
+			models.OrderItem{OrderItemID: 11, ItemPrice: 10.00, Quantity: 1},
+			models.OrderItem{OrderItemID: 12, ItemPrice: 6.67, Quantity: 3},
+			models.OrderItem{OrderItemID: 13, ItemPrice: 0, Quantity: 2},
+		),
+		testOrder(2, 200, 0,
+			models.OrderItem{OrderItemID: 21, ItemPrice: 100.00, Quantity: 1},
+		),
+	}
+	var total int64
+	for _, details := range orders {
+		total += toCents(details.Order.TotalPrice)
+	}
+
+	t.Run("shared lines and service charge", func(t *testing.T) {
+		parts, err := splitByItems(orders, []models.BillSplitAssignment{
+			{OrderItemID: 11, Payers: []string{"Ali"}},
+			{OrderItemID: 12, Payers: []string{"Ali", "Vali", "Sami"}}, // 20.01 uchga bo'linadi
+			{OrderItemID: 13, Payers: []string{"Vali"}},                // Bepul qator
+			{OrderItemID: 21, Payers: []string{"Ali", "Vali", "Sami"}}, // 100.00 uchga bo'linadi
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum := sumParts(parts); sum != total {
+			t.Fatalf("yig'indi = %d, kutilgan %d (%+v)", sum, total, parts)
+		}
+		amounts := make(map[string]int64)
+		for _, part := range parts {
+			amounts[part.Payer] = toCents(part.Amount)
+		}
+		// 1-buyurtma: Ali 10.00+6.67, Vali 6.67, Sami 6.67 (jami 30.01) va 3.01 xizmat haqi shu ulushlarda;
+		// 2-buyurtma: 100.00 uchga (33.34, 33.33, 33.33)
+		want := map[string]int64{"Ali": 1834 + 3334, "Vali": 734 + 3333, "Sami": 734 + 3333}
+		for payer, cents := range want {
+			if amounts[payer] != cents {
+				t.Errorf("%s = %d tiyin, kutilgan %d", payer, amounts[payer], cents)
+			}
+		}
+	})
+
+	t.Run("only zero-price lines in an order", func(t *testing.T) {
+		free := []models.OrderDetailsResponse{
+			testOrder(5, 100, 0, models.OrderItem{OrderItemID: 51, ItemPrice: 0, Quantity: 1}),
+			testOrder(6, 100, 0.03, models.OrderItem{OrderItemID: 61, ItemPrice: 0, Quantity: 1}),
+		}
+		parts, err := splitByItems(free, []models.BillSplitAssignment{
+			{OrderItemID: 51, Payers: []string{"Ali"}},
+			{OrderItemID: 61, Payers: []string{"Ali", "Vali"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum := sumParts(parts); sum != 3 {
+			t.Errorf("yig'indi = %d, kutilgan 3 (%+v)", sum, parts)
+		}
+	})
+
+	errorCases := []struct {
+		name        string
+		assignments []models.BillSplitAssignment
+	}{
+		{"duplicate order_item_id", []models.BillSplitAssignment{
+			{OrderItemID: 11, Payers: []string{"Ali"}}, {OrderItemID: 11, Payers: []string{"Vali"}},
+			{OrderItemID: 12, Payers: []string{"Ali"}}, {OrderItemID: 13, Payers: []string{"Ali"}}, {OrderItemID: 21, Payers: []string{"Ali"}},
+		}},
+		{"unknown order_item_id", []models.BillSplitAssignment{
+			{OrderItemID: 11, Payers: []string{"Ali"}}, {OrderItemID: 12, Payers: []string{"Ali"}},
+			{OrderItemID: 13, Payers: []string{"Ali"}}, {OrderItemID: 21, Payers: []string{"Ali"}}, {OrderItemID: 999, Payers: []string{"Ali"}},
+		}},
+		{"unassigned line", []models.BillSplitAssignment{
+			{OrderItemID: 11, Payers: []string{"Ali"}}, {OrderItemID: 12, Payers: []string{"Ali"}}, {OrderItemID: 21, Payers: []string{"Ali"}},
+		}},
+		{"line without payers", []models.BillSplitAssignment{
+			{OrderItemID: 11, Payers: []string{" ", ""}}, {OrderItemID: 12, Payers: []string{"Ali"}},
+			{OrderItemID: 13, Payers: []string{"Ali"}}, {OrderItemID: 21, Payers: []string{"Ali"}},
+		}},
+	}
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := splitByItems(orders, tt.assignments); !errors.Is(err, ErrInvalidBillSplit) {
+				t.Errorf("xato = %v, kutilgan %v", err, ErrInvalidBillSplit)
+			}
+		})
+	}
+}
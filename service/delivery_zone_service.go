@@ -0,0 +1,219 @@
+package service
+
+import (
+	"amur/models"
+	"amur/pkg/geo"
+	"amur/repository"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// MaxDeliveryETAMinutes hudud uchun taxminiy yetkazib berish vaqtining yuqori chegarasi (daqiqa)
+const MaxDeliveryETAMinutes = 720
+
+var (
+	ErrDeliveryZoneNotFound     = errors.New("yetkazib berish hududi topilmadi")
+	ErrDeliveryZoneNameRequired = errors.New("hudud nomi majburiy")
+	ErrDeliveryZoneNameTaken    = errors.New("bu nomli hudud allaqachon mavjud")
+	ErrInvalidDeliveryZone      = errors.New("hudud ma'lumotlari noto'g'ri")
+	ErrInvalidDeliveryLocation  = errors.New("yetkazib berish manzili koordinatalari noto'g'ri")
+	ErrOutsideDeliveryZone      = errors.New("afsuski, bu manzilga yetkazib bermaymiz")
+	ErrDeliveryMinimumNotMet    = errors.New("buyurtma summasi bu hudud uchun minimal summadan kam")
+)
+
+type DeliveryZoneService struct {
+	zoneRepo *repository.DeliveryZoneRepository
+}
+
+func NewDeliveryZoneService(zoneRepo *repository.DeliveryZoneRepository) *DeliveryZoneService {
+	return &DeliveryZoneService{zoneRepo: zoneRepo}
+}
+
+// GetZones hududlarni qaytaradi (includeInactive=false bo'lsa faqat faollari)
+func (s *DeliveryZoneService) GetZones(includeInactive bool) ([]models.DeliveryZone, error) {
+	zones, err := s.zoneRepo.GetZones(includeInactive)
+	if err != nil {
+		return nil, fmt.Errorf("hududlarni olishda xatolik: %w", err)
+	}
+	return zones, nil
+}
+
+// GetZone hududni ID bo'yicha qaytaradi
+func (s *DeliveryZoneService) GetZone(zoneID int) (*models.DeliveryZone, error) {
+	zone, err := s.zoneRepo.GetZoneByID(zoneID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: id=%d", ErrDeliveryZoneNotFound, zoneID)
+		}
+		return nil, fmt.Errorf("hududni olishda xatolik: %w", err)
+	}
+	return zone, nil
+}
+
+// CreateZone yangi hudud yaratadi. Ko'pburchak tekshirilib, bazaga GeoJSON MultiPolygon ko'rinishida yoziladi.
+func (s *DeliveryZoneService) CreateZone(req *models.CreateDeliveryZoneRequest) (*models.DeliveryZone, error) {
+	zone := &models.DeliveryZone{
+		ZoneName:       strings.TrimSpace(req.ZoneName),
+		DeliveryFee:    req.DeliveryFee,
+		MinOrderAmount: req.MinOrderAmount,
+		EtaMinutes:     req.EtaMinutes,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+	}
+	polygon, err := normalizeZonePolygon(req.Polygon)
+	if err != nil {
+		return nil, err
+	}
+	zone.Polygon = polygon
+	if err := s.validateZone(zone); err != nil {
+		return nil, err
+	}
+
+	created, err := s.zoneRepo.CreateZone(zone)
+	if err != nil {
+		return nil, fmt.Errorf("hudud yaratishda xatolik: %w", err)
+	}
+	return created, nil
+}
+
+// UpdateZone hududning yuborilgan maydonlarini o'zgartiradi
+func (s *DeliveryZoneService) UpdateZone(zoneID int, req *models.UpdateDeliveryZoneRequest) (*models.DeliveryZone, error) {
+	zone, err := s.GetZone(zoneID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ZoneName != nil {
+		zone.ZoneName = strings.TrimSpace(*req.ZoneName)
+	}
+	if len(req.Polygon) > 0 {
+		if zone.Polygon, err = normalizeZonePolygon(req.Polygon); err != nil {
+			return nil, err
+		}
+	}
+	if req.DeliveryFee != nil {
+		zone.DeliveryFee = *req.DeliveryFee
+	}
+	if req.MinOrderAmount != nil {
+		zone.MinOrderAmount = *req.MinOrderAmount
+	}
+	if req.EtaMinutes != nil {
+		zone.EtaMinutes = *req.EtaMinutes
+	}
+	if req.IsActive != nil {
+		zone.IsActive = *req.IsActive
+	}
+	if err := s.validateZone(zone); err != nil {
+		return nil, err
+	}
+
+	if err := s.zoneRepo.UpdateZone(zone); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: id=%d", ErrDeliveryZoneNotFound, zoneID)
+		}
+		return nil, fmt.Errorf("hududni yangilashda xatolik: %w", err)
+	}
+	return s.GetZone(zoneID)
+}
+
+// DeleteZone hududni o'chiradi. Eski buyurtmalarda yetkazib berish narxi va vaqti saqlanib qoladi.
+func (s *DeliveryZoneService) DeleteZone(zoneID int) error {
+	if err := s.zoneRepo.DeleteZone(zoneID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: id=%d", ErrDeliveryZoneNotFound, zoneID)
+		}
+		return fmt.Errorf("hududni o'chirishda xatolik: %w", err)
+	}
+	return nil
+}
+
+// QuoteDelivery manzil qaysi hududga tushishini va u yerga yetkazib berish shartlarini qaytaradi
+func (s *DeliveryZoneService) QuoteDelivery(latitude, longitude float64) (*models.DeliveryQuote, error) {
+	zones, err := s.GetZones(false)
+	if err != nil {
+		return nil, err
+	}
+	zone, err := locateDeliveryZone(zones, latitude, longitude)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DeliveryQuote{
+		ZoneID:         zone.ZoneID,
+		ZoneName:       zone.ZoneName,
+		DeliveryFee:    zone.DeliveryFee,
+		MinOrderAmount: zone.MinOrderAmount,
+		EtaMinutes:     zone.EtaMinutes,
+	}, nil
+}
+
+// validateZone nom, narxlar va vaqtni tekshiradi; nom boshqa hududda band bo'lmasligi kerak
+func (s *DeliveryZoneService) validateZone(zone *models.DeliveryZone) error {
+	if zone.ZoneName == "" {
+		return ErrDeliveryZoneNameRequired
+	}
+	if zone.DeliveryFee < 0 || zone.MinOrderAmount < 0 {
+		return fmt.Errorf("%w: yetkazib berish narxi va minimal summa manfiy bo'lmasligi kerak", ErrInvalidDeliveryZone)
+	}
+	if zone.EtaMinutes < 1 || zone.EtaMinutes > MaxDeliveryETAMinutes {
+		return fmt.Errorf("%w: taxminiy vaqt 1 dan %d daqiqagacha bo'lishi kerak", ErrInvalidDeliveryZone, MaxDeliveryETAMinutes)
+	}
+	zone.DeliveryFee = roundMoney(zone.DeliveryFee)
+	zone.MinOrderAmount = roundMoney(zone.MinOrderAmount)
+
+	existing, err := s.zoneRepo.GetZoneByName(zone.ZoneName)
+	if err == nil && existing.ZoneID != zone.ZoneID {
+		return fmt.Errorf("%w: %s", ErrDeliveryZoneNameTaken, zone.ZoneName)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("hududni tekshirishda xatolik: %w", err)
+	}
+	return nil
+}
+
+// normalizeZonePolygon yuklangan GeoJSON ni tekshiradi va MultiPolygon geometriyasiga keltiradi
+func normalizeZonePolygon(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%w: ko'pburchak (polygon) majburiy", ErrInvalidDeliveryZone)
+	}
+	area, err := geo.ParseGeoJSON(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDeliveryZone, err)
+	}
+	polygon, err := json.Marshal(area)
+	if err != nil {
+		return nil, fmt.Errorf("ko'pburchakni saqlashda xatolik: %w", err)
+	}
+	return polygon, nil
+}
+
+// locateDeliveryZone manzil tushadigan hududni topadi. Hududlar ustma-ust tushsa eng arzon yetkazib beriladigani,
+// narx teng bo'lsa minimal summasi kichigi tanlanadi. Hech bir hududga tushmasa ErrOutsideDeliveryZone qaytadi.
+func locateDeliveryZone(zones []models.DeliveryZone, latitude, longitude float64) (*models.DeliveryZone, error) {
+	point := geo.Point{Lat: latitude, Lng: longitude}
+	if !point.Valid() {
+		return nil, ErrInvalidDeliveryLocation
+	}
+
+	var best *models.DeliveryZone
+	for i := range zones {
+		zone := &zones[i]
+		area, err := geo.ParseGeoJSON(zone.Polygon)
+		if err != nil {
+			log.Printf("⚠️ '%s' hududi ko'pburchagini o'qib bo'lmadi (ZoneID: %d): %v", zone.ZoneName, zone.ZoneID, err)
+			continue
+		}
+		if !area.Contains(point) {
+			continue
+		}
+		if best == nil || zone.DeliveryFee < best.DeliveryFee ||
+			(zone.DeliveryFee == best.DeliveryFee && zone.MinOrderAmount < best.MinOrderAmount) {
+			best = zone
+		}
+	}
+	if best == nil {
+		return nil, ErrOutsideDeliveryZone
+	}
+	return best, nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"amur/models"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// squareZone [lng1,lat1]-[lng2,lat2] to'rtburchakli hudud yasaydi
+func squareZone(id int, fee, minOrder float64, lng1, lat1, lng2, lat2 float64) models.DeliveryZone {
+	polygon, _ := json.Marshal(map[string]interface{}{
+		"type":        "Polygon",
+		"coordinates": [][][2]float64{{{lng1, lat1}, {lng2, lat1}, {lng2, lat2}, {lng1, lat2}, {lng1, lat1}}},
+	})
+	return models.DeliveryZone{ZoneID: id, ZoneName: "zone", Polygon: polygon, DeliveryFee: fee, MinOrderAmount: minOrder, EtaMinutes: 30}
+}
+
+func TestLocateDeliveryZone(t *testing.T) {
+	zones := []models.DeliveryZone{
+		squareZone(1, 15000, 50000, 69.0, 41.0, 69.5, 41.5), // Katta, qimmat hudud
+		squareZone(2, 10000, 80000, 69.2, 41.2, 69.4, 41.4), // Markaz: arzonroq
+		squareZone(3, 10000, 60000, 69.3, 41.3, 69.4, 41.4), // Markaz ichida: narx teng, minimal summa kichik
+		{ZoneID: 4, ZoneName: "broken", Polygon: json.RawMessage(`{"type":"Point"}`), DeliveryFee: 0},
+	}
+
+	tests := []struct {
+		name     string
+		lat, lng float64
+		wantZone int
+		wantErr  error
+	}{
+		{"only outer zone", 41.1, 69.1, 1, nil},
+		{"cheapest overlapping zone", 41.25, 69.25, 2, nil},
+		{"equal fee, lower minimum wins", 41.35, 69.35, 3, nil},
+		{"shared boundary", 41.3, 69.3, 3, nil},
+		{"outside every zone", 42.0, 70.0, 0, ErrOutsideDeliveryZone},
+		{"invalid coordinates", 91, 69.3, 0, ErrInvalidDeliveryLocation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone, err := locateDeliveryZone(zones, tt.lat, tt.lng)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("xato = %v, kutilgan %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && zone.ZoneID != tt.wantZone {
+				t.Errorf("hudud = %d, kutilgan %d", zone.ZoneID, tt.wantZone)
+			}
+		})
+	}
+
+	// Tartibga bog'liq emas: teskari tartibda ham eng arzon hudud tanlanadi
+	reversed := []models.DeliveryZone{zones[3], zones[2], zones[1], zones[0]}
+	if zone, err := locateDeliveryZone(reversed, 41.35, 69.35); err != nil || zone.ZoneID != 3 {
+		t.Errorf("teskari tartibda hudud = %+v, %v; kutilgan 3", zone, err)
+	}
+}
@@ -3,16 +3,41 @@ package service
 import (
 	"amur/models"
 	"amur/repository"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 )
 
+var ErrInvalidModifierGroups = errors.New("modifikator guruhlari noto'g'ri")
+
 type FoodService struct {
-	foodRepo *repository.FoodRepository
+	uow        *repository.UnitOfWork
+	foodRepo   *repository.FoodRepository
+	basketRepo *repository.BasketOrderRepository // Modifikatorlar almashtirilganda eski savatcha qatorlarini tozalash uchun
+}
+
+func NewFoodService(uow *repository.UnitOfWork, foodRepo *repository.FoodRepository, basketRepo *repository.BasketOrderRepository) *FoodService {
+	return &FoodService{uow: uow, foodRepo: foodRepo, basketRepo: basketRepo}
 }
 
-func NewFoodService(foodRepo *repository.FoodRepository) *FoodService {
-	return &FoodService{foodRepo: foodRepo}
+// attachModifiers ovqatlarga modifikator guruhlarini bitta so'rov bilan biriktiradi
+func (s *FoodService) attachModifiers(foods []*models.Food) ([]*models.Food, error) {
+	foodIDs := make([]int, 0, len(foods))
+	for _, food := range foods {
+		foodIDs = append(foodIDs, food.FoodID)
+	}
+	groups, err := s.foodRepo.GetModifierGroups(foodIDs)
+	if err != nil {
+		return nil, fmt.Errorf("modifikatorlarni olishda xatolik: %w", err)
+	}
+	for _, food := range foods {
+		food.ModifierGroups = groups[food.FoodID]
+		if food.ModifierGroups == nil {
+			food.ModifierGroups = []models.ModifierGroup{}
+		}
+	}
+	return foods, nil
 }
 
 func (s *FoodService) CreateFood(req *models.CreateFoodRequest) (*models.Food, error) {
@@ -31,19 +56,31 @@ func (s *FoodService) CreateFood(req *models.CreateFoodRequest) (*models.Food, e
 	if err != nil {
 		return nil, err
 	}
+	food.ModifierGroups = []models.ModifierGroup{}
 
 	return food, nil
 }
 
 func (s *FoodService) GetAllFoods() ([]*models.Food, error) {
-	return s.foodRepo.GetAll()
+	foods, err := s.foodRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return s.attachModifiers(foods)
 }
 
 func (s *FoodService) GetFoodByID(id int) (*models.Food, error) {
 	if id <= 0 {
 		return nil, fmt.Errorf("noto'g'ri food ID")
 	}
-	return s.foodRepo.GetByID(id)
+	food, err := s.foodRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.attachModifiers([]*models.Food{food}); err != nil {
+		return nil, err
+	}
+	return food, nil
 }
 
 func (s *FoodService) UpdateFood(id int, req *models.UpdateFoodRequest) (*models.Food, error) {
@@ -82,7 +119,7 @@ func (s *FoodService) UpdateFood(id int, req *models.UpdateFoodRequest) (*models
 		return nil, err
 	}
 
-	return s.foodRepo.GetByID(id)
+	return s.GetFoodByID(id)
 }
 
 func (s *FoodService) DeleteFood(id int) error {
@@ -102,7 +139,65 @@ func (s *FoodService) GetFoodsByCategory(category string) ([]*models.Food, error
 	if category == "" {
 		return nil, fmt.Errorf("kategoriya nomi bo'sh bo'lishi mumkin emas")
 	}
-	return s.foodRepo.GetByCategory(strings.TrimSpace(category))
+	foods, err := s.foodRepo.GetByCategory(strings.TrimSpace(category))
+	if err != nil {
+		return nil, err
+	}
+	return s.attachModifiers(foods)
+}
+
+// SetFoodModifiers ovqatning barcha modifikator guruhlarini yangilari bilan almashtiradi.
+// Eski variantlar o'chiriladi, shuning uchun ularga tayangan savatcha qatorlari ham tozalanadi.
+func (s *FoodService) SetFoodModifiers(foodID int, req *models.SetFoodModifiersRequest) (*models.Food, error) {
+	food, err := s.foodRepo.GetByID(foodID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("ovqat topilmadi: %w", err)
+		}
+		return nil, fmt.Errorf("ovqatni olishda xatolik: %w", err)
+	}
+
+	groups := make([]models.ModifierGroupInput, 0, len(req.Groups))
+	for _, group := range req.Groups {
+		group.GroupName = strings.TrimSpace(group.GroupName)
+		if group.GroupName == "" {
+			return nil, fmt.Errorf("%w: guruh nomi bo'sh bo'lmasligi kerak", ErrInvalidModifierGroups)
+		}
+		if len(group.Options) == 0 {
+			return nil, fmt.Errorf("%w: '%s' guruhida kamida bitta variant bo'lishi kerak", ErrInvalidModifierGroups, group.GroupName)
+		}
+		if group.MaxSelect == 0 {
+			group.MaxSelect = 1
+		}
+		if group.MinSelect < 0 || group.MinSelect > group.MaxSelect || group.MaxSelect > len(group.Options) {
+			return nil, fmt.Errorf("%w: '%s' guruhida min_select/max_select noto'g'ri (0 <= min <= max <= variantlar soni)", ErrInvalidModifierGroups, group.GroupName)
+		}
+		for i := range group.Options {
+			group.Options[i].OptionName = strings.TrimSpace(group.Options[i].OptionName)
+			if group.Options[i].OptionName == "" {
+				return nil, fmt.Errorf("%w: '%s' guruhidagi variant nomi bo'sh", ErrInvalidModifierGroups, group.GroupName)
+			}
+			if food.FoodPrice+group.Options[i].PriceDelta < 0 {
+				return nil, fmt.Errorf("%w: '%s' varianti bilan narx manfiy bo'lib qoladi", ErrInvalidModifierGroups, group.Options[i].OptionName)
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	err = s.uow.Do(func(tx *sql.Tx) error {
+		if err := s.foodRepo.WithTx(tx).ReplaceModifierGroups(foodID, groups); err != nil {
+			return fmt.Errorf("modifikatorlarni saqlashda xatolik: %w", err)
+		}
+		if err := s.basketRepo.WithTx(tx).RemoveModifiedLinesByFood(foodID); err != nil {
+			return fmt.Errorf("savatcha qatorlarini tozalashda xatolik: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetFoodByID(foodID)
 }
 
 func (s *FoodService) GetFoodCount() int {
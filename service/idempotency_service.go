@@ -0,0 +1,74 @@
+package service
+
+import (
+	"amur/models"
+	"amur/repository"
+	"fmt"
+	"log"
+	"time"
+)
+
+// IdempotencyKeyTTL shu muddat ichida bir xil kalit bilan qayta yuborilgan so'rovga asl javob qaytariladi
+const IdempotencyKeyTTL = 24 * time.Hour
+
+type IdempotencyService struct {
+	repo *repository.IdempotencyRepository
+}
+
+func NewIdempotencyService(repo *repository.IdempotencyRepository) *IdempotencyService {
+	return &IdempotencyService{repo: repo}
+}
+
+// Begin kalitni band qiladi. started=true bo'lsa so'rovni bajarish kerak, aks holda
+// qaytarilgan yozuv oldingi (bajarilgan yoki hali bajarilayotgan) so'rovga tegishli.
+func (s *IdempotencyService) Begin(telegramID int64, key, method, path, requestHash string) (*models.IdempotencyRecord, bool, error) {
+	record, started, err := s.repo.Reserve(&models.IdempotencyRecord{
+		TelegramID:  telegramID,
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		RequestHash: requestHash,
+	}, IdempotencyKeyTTL)
+	if err != nil {
+		return nil, false, fmt.Errorf("idempotency kalitini band qilishda xatolik: %w", err)
+	}
+	return record, started, nil
+}
+
+// Complete bajarilgan so'rov javobini saqlaydi
+func (s *IdempotencyService) Complete(telegramID int64, key string, statusCode int, body []byte) error {
+	if err := s.repo.Complete(telegramID, key, statusCode, body); err != nil {
+		return fmt.Errorf("idempotency javobini saqlashda xatolik: %w", err)
+	}
+	return nil
+}
+
+// Abort kalitni bo'shatadi, shunda mijoz xuddi shu kalit bilan qayta urinishi mumkin
+func (s *IdempotencyService) Abort(telegramID int64, key string) error {
+	if err := s.repo.Delete(telegramID, key); err != nil {
+		return fmt.Errorf("idempotency kalitini bo'shatishda xatolik: %w", err)
+	}
+	return nil
+}
+
+// StartCleanup muddati o'tgan kalitlarni har interval da o'chirib turadi
+func (s *IdempotencyService) StartCleanup(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				deleted, err := s.repo.DeleteExpired(IdempotencyKeyTTL)
+				if err != nil {
+					continue
+				}
+				if deleted > 0 {
+					log.Printf("🧹 %d ta eskirgan idempotency kaliti o'chirildi", deleted)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"amur/models"
+	"amur/repository"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	throttleScopePhone = "phone"
+	throttleScopeIP    = "ip"
+)
+
+// throttlePolicy bitta scope uchun cheklov qoidalari
+type throttlePolicy struct {
+	freeAttempts int           // Bloklashsiz ruxsat etilgan noto'g'ri urinishlar
+	baseLock     time.Duration // Birinchi bloklash muddati, keyingilari ikki baravar oshadi
+	maxLock      time.Duration // Bloklashning eng uzun muddati
+	resetWindow  time.Duration // Shuncha vaqt xato bo'lmasa hisob nolga tushadi
+}
+
+var throttlePolicies = map[string]throttlePolicy{
+	throttleScopePhone: {freeAttempts: 5, baseLock: time.Minute, maxLock: time.Hour, resetWindow: 24 * time.Hour},
+	throttleScopeIP:    {freeAttempts: 20, baseLock: time.Minute, maxLock: time.Hour, resetWindow: 24 * time.Hour},
+}
+
+// LoginLockedError kirish vaqtincha bloklanganini bildiradi
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("juda ko'p noto'g'ri urinish, %d soniyadan keyin qaytadan urinib ko'ring", int(e.RetryAfter.Seconds())+1)
+}
+
+// LoginThrottleService telefon raqami va IP manzil bo'yicha login urinishlarini cheklaydi
+type LoginThrottleService struct {
+	throttleRepo *repository.LoginThrottleRepository
+	notifier     Notifier
+}
+
+func NewLoginThrottleService(throttleRepo *repository.LoginThrottleRepository) *LoginThrottleService {
+	return &LoginThrottleService{throttleRepo: throttleRepo}
+}
+
+// SetNotifier bot ishga tushgandan keyin ogohlantirish yuboruvchini o'rnatadi
+func (s *LoginThrottleService) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// Check telefon raqami yoki IP bloklangan bo'lsa *LoginLockedError qaytaradi
+func (s *LoginThrottleService) Check(phoneNumber, ip string) error {
+	var retryAfter time.Duration
+	for scope, key := range map[string]string{throttleScopePhone: phoneNumber, throttleScopeIP: ip} {
+		if key == "" {
+			continue
+		}
+		remaining, err := s.throttleRepo.GetLockRemaining(scope, key)
+		if err != nil {
+			return fmt.Errorf("login cheklovini tekshirishda xatolik: %w", err)
+		}
+		if remaining > retryAfter {
+			retryAfter = remaining
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RegisterFailure noto'g'ri urinishni qayd etadi va kerak bo'lsa bloklaydi.
+// user nil bo'lmasa va telefon raqami bloklansa, egasi bot orqali ogohlantiriladi.
+func (s *LoginThrottleService) RegisterFailure(phoneNumber, ip string, user *models.User) {
+	if lock := s.registerFailure(throttleScopePhone, phoneNumber); lock > 0 && user != nil {
+		s.notifyOwner(user, lock)
+	}
+	s.registerFailure(throttleScopeIP, ip)
+}
+
+// RegisterSuccess muvaffaqiyatli kirishdan keyin telefon raqami hisobini tozalaydi.
+// IP hisobi tozalanmaydi, aks holda o'z hisobiga kirib turib boshqa raqamlarni terish mumkin bo'lardi.
+func (s *LoginThrottleService) RegisterSuccess(phoneNumber string) {
+	if err := s.throttleRepo.Reset(throttleScopePhone, phoneNumber); err != nil {
+		log.Printf("Login cheklovini tozalashda xatolik: %v", err)
+	}
+}
+
+// registerFailure bitta scope uchun xatoni qayd etadi va qo'yilgan bloklash muddatini qaytaradi
+func (s *LoginThrottleService) registerFailure(scope, key string) time.Duration {
+	if key == "" {
+		return 0
+	}
+	policy := throttlePolicies[scope]
+
+	throttle, err := s.throttleRepo.RegisterFailure(scope, key, policy.resetWindow)
+	if err != nil {
+		log.Printf("Noto'g'ri urinishni qayd etishda xatolik (%s=%s): %v", scope, key, err)
+		return 0
+	}
+	if throttle.FailedCount <= policy.freeAttempts {
+		return 0
+	}
+
+	lock := lockDuration(policy, throttle.FailedCount)
+	if err := s.throttleRepo.Lock(scope, key, lock); err != nil {
+		return 0
+	}
+	return lock
+}
+
+// lockDuration eksponensial bloklash muddatini hisoblaydi: base, 2*base, 4*base, ... maxLock gacha
+func lockDuration(policy throttlePolicy, failedCount int) time.Duration {
+	lock := policy.baseLock
+	for i := policy.freeAttempts + 1; i < failedCount && lock < policy.maxLock; i++ {
+		lock *= 2
+	}
+	if lock > policy.maxLock {
+		lock = policy.maxLock
+	}
+	return lock
+}
+
+func (s *LoginThrottleService) notifyOwner(user *models.User, lock time.Duration) {
+	if s.notifier == nil {
+		return
+	}
+	text := fmt.Sprintf("⚠️ Hisobingizga kirish uchun ko'p marta noto'g'ri kod kiritildi. "+
+		"Xavfsizlik uchun kirish %d daqiqaga bloklandi.\nAgar bu siz bo'lmasangiz, kodni hech kimga bermang.",
+		int(lock.Minutes()))
+	if err := s.notifier.SendMessage(user.TelegramID, text); err != nil {
+		log.Printf("Foydalanuvchini ogohlantirishda xatolik (ID: %d): %v", user.TelegramID, err)
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"amur/models"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxLineNoteLength savatcha/buyurtma qatori izohining eng ko'p uzunligi (belgilarda)
+const MaxLineNoteLength = 200
+
+var (
+	ErrInvalidModifiers = errors.New("modifikatorlar noto'g'ri tanlangan")
+	ErrLineNoteTooLong  = fmt.Errorf("qator izohi %d belgidan oshmasligi kerak", MaxLineNoteLength)
+)
+
+// resolveModifiers tanlangan variantlarni ovqatning joriy guruhlariga nisbatan tekshiradi:
+// har bir variant shu ovqatga tegishli, takrorlanmagan va har bir guruhda tanlovlar soni min/max oralig'ida bo'lishi kerak.
+// Buyurtmaga yoziladigan nusxa va narxga qo'shimchalar yig'indisi qaytariladi.
+func resolveModifiers(groups []models.ModifierGroup, optionIDs []int) ([]models.OrderItemModifier, float64, error) {
+	type optionRef struct {
+		group  *models.ModifierGroup
+		option models.ModifierOption
+	}
+	options := make(map[int]optionRef)
+	for i := range groups {
+		for _, option := range groups[i].Options {
+			options[option.OptionID] = optionRef{group: &groups[i], option: option}
+		}
+	}
+
+	selected := make(map[int]bool, len(optionIDs))
+	perGroup := make(map[int]int)
+	for _, id := range optionIDs {
+		ref, ok := options[id]
+		if !ok {
+			return nil, 0, fmt.Errorf("%w: option_id=%d bu ovqatga tegishli emas yoki endi mavjud emas", ErrInvalidModifiers, id)
+		}
+		if selected[id] {
+			return nil, 0, fmt.Errorf("%w: option_id=%d takrorlangan", ErrInvalidModifiers, id)
+		}
+		selected[id] = true
+		perGroup[ref.group.GroupID]++
+	}
+
+	for _, group := range groups {
+		count := perGroup[group.GroupID]
+		if count < group.MinSelect {
+			return nil, 0, fmt.Errorf("%w: '%s' guruhidan kamida %d ta tanlash kerak", ErrInvalidModifiers, group.GroupName, group.MinSelect)
+		}
+		if count > group.MaxSelect {
+			return nil, 0, fmt.Errorf("%w: '%s' guruhidan ko'pi bilan %d ta tanlash mumkin", ErrInvalidModifiers, group.GroupName, group.MaxSelect)
+		}
+	}
+
+	// Nusxa menyudagi tartibda saqlanadi
+	var snapshot []models.OrderItemModifier
+	var delta float64
+	for _, group := range groups {
+		for _, option := range group.Options {
+			if !selected[option.OptionID] {
+				continue
+			}
+			snapshot = append(snapshot, models.OrderItemModifier{
+				OptionID:   option.OptionID,
+				GroupName:  group.GroupName,
+				OptionName: option.OptionName,
+				PriceDelta: option.PriceDelta,
+			})
+			delta += option.PriceDelta
+		}
+	}
+	return snapshot, delta, nil
+}
+
+// normalizeLineNote qator izohini tozalaydi va uzunligini tekshiradi
+func normalizeLineNote(note string) (string, error) {
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > MaxLineNoteLength {
+		return "", ErrLineNoteTooLong
+	}
+	return note, nil
+}
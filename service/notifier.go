@@ -0,0 +1,14 @@
+package service
+
+// Notifier foydalanuvchilarga Telegram bot orqali xabar yuborish uchun interfeys.
+// Uni handlers.BotHandler amalga oshiradi, shuning uchun servislar botga bevosita bog'lanmaydi.
+type Notifier interface {
+	SendMessage(chatID int64, text string) error
+}
+
+// ActionNotifier xabarga bitta inline tugma qo'shib yuboradi va keyinchalik uni tahrirlaydi.
+// Tugma bosilganda bot callbackData ni qaytaradi (masalan, stol chaqiruvini qabul qilish uchun).
+type ActionNotifier interface {
+	SendMessageWithButton(chatID int64, text, buttonText, callbackData string) (int, error)
+	EditMessage(chatID int64, messageID int, text string) error
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"amur/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidCourier = errors.New("foydalanuvchi kuryer emas yoki topilmadi")
+
+// canReadOrder foydalanuvchi buyurtmani ko'ra olishini tekshiradi:
+// orders.read.all ruxsati bor xodimlar hammasini (o'chirilganlarini ham), kuryer faqat o'ziga biriktirilganlarini,
+// mijoz esa faqat o'zining o'chirilmagan buyurtmalarini ko'radi.
+func canReadOrder(principal *models.Principal, order *models.Order) bool {
+	if principal.HasPermission(models.PermOrdersReadAll) {
+		return true
+	}
+	if order.DeletedAt != nil {
+		return false
+	}
+	return order.TelegramID == principal.TelegramID || isAssignedCourier(principal, order)
+}
+
+// canManageOrder foydalanuvchi buyurtma holatini o'zgartira olishini tekshiradi (orders.status.update ruxsatidan tashqari):
+// kuryer faqat o'ziga biriktirilgan buyurtmalarni boshqaradi
+func canManageOrder(principal *models.Principal, order *models.Order) bool {
+	if !principal.HasPermission(models.PermOrdersStatusUpdate) {
+		return false
+	}
+	return principal.HasPermission(models.PermOrdersReadAll) || isAssignedCourier(principal, order)
+}
+
+// isAssignedCourier buyurtma shu kuryerga biriktirilganini tekshiradi
+func isAssignedCourier(principal *models.Principal, order *models.Order) bool {
+	return principal.HasPermission(models.PermOrdersReadAssigned) &&
+		order.CourierTelegramID != nil && *order.CourierTelegramID == principal.TelegramID
+}
+
+// GetOrderDetailsFor buyurtmani foydalanuvchining huquqlarini tekshirgan holda qaytaradi.
+// Ko'rishga huquqi bo'lmasa buyurtma mavjudligi oshkor qilinmaydi va ErrOrderNotFound qaytadi.
+func (s *OrderService) GetOrderDetailsFor(principal *models.Principal, orderID int) (*models.OrderDetailsResponse, error) {
+	details, err := s.GetOrderDetails(orderID)
+	if err != nil {
+		return nil, err
+	}
+	return readableOrderDetails(principal, details)
+}
+
+// readableOrderDetails ko'rishga huquqi bo'lmagan foydalanuvchi uchun buyurtmani "topilmadi" qilib yashiradi
+func readableOrderDetails(principal *models.Principal, details *models.OrderDetailsResponse) (*models.OrderDetailsResponse, error) {
+	if !canReadOrder(principal, &details.Order) {
+		return nil, fmt.Errorf("%w: id=%d", ErrOrderNotFound, details.Order.OrderID)
+	}
+	return details, nil
+}
+
+// AssignCourier buyurtmani kuryerga biriktiradi yoki (courierTelegramID nil bo'lsa) kuryerni olib tashlaydi.
+// Biriktiriladigan foydalanuvchida orders.read.assigned ruxsati bo'lishi shart.
+func (s *OrderService) AssignCourier(orderID int, courierTelegramID *int64) (*models.Order, error) {
+	if courierTelegramID != nil {
+		isCourier, err := s.permissions.HasPermission(*courierTelegramID, models.PermOrdersReadAssigned)
+		if err != nil {
+			return nil, fmt.Errorf("kuryer ruxsatini tekshirishda xatolik: %w", err)
+		}
+		if !isCourier {
+			return nil, fmt.Errorf("%w: telegram_id=%d", ErrInvalidCourier, *courierTelegramID)
+		}
+	}
+
+	if err := s.orderRepo.SetCourier(orderID, courierTelegramID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: id=%d", ErrOrderNotFound, orderID)
+		}
+		return nil, fmt.Errorf("kuryerni biriktirishda xatolik: %w", err)
+	}
+
+	details, err := s.GetOrderDetails(orderID)
+	if err != nil {
+		return nil, err
+	}
+	return &details.Order, nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"amur/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOrderAccess(t *testing.T) {
+	const (
+		customerID = int64(100)
+		otherID    = int64(200)
+		courierID  = int64(300)
+		staffID    = int64(400)
+	)
+	courier := courierID
+	otherCourier := int64(301)
+	deletedAt := time.Now()
+
+	customer := &models.Principal{TelegramID: customerID, Role: models.RoleCustomer}
+	otherCustomer := &models.Principal{TelegramID: otherID, Role: models.RoleCustomer}
+	staff := &models.Principal{TelegramID: staffID, Role: models.RoleWaiter,
+		Permissions: []string{models.PermOrdersReadAll, models.PermOrdersStatusUpdate}}
+	courierPrincipal := &models.Principal{TelegramID: courierID, Role: models.RoleCourier,
+		Permissions: []string{models.PermOrdersReadAssigned, models.PermOrdersStatusUpdate}}
+	// Ruxsatlari yo'q, lekin ID si kuryer bilan bir xil: biriktirish ruxsatsiz hech narsa bermaydi
+	noPermissions := &models.Principal{TelegramID: courierID}
+
+	ownOrder := &models.Order{OrderID: 1, TelegramID: customerID}
+	deletedOrder := &models.Order{OrderID: 2, TelegramID: customerID, DeletedAt: &deletedAt}
+	assignedOrder := &models.Order{OrderID: 3, TelegramID: customerID, CourierTelegramID: &courier}
+	otherCourierOrder := &models.Order{OrderID: 4, TelegramID: customerID, CourierTelegramID: &otherCourier}
+
+	tests := []struct {
+		name       string
+		principal  *models.Principal
+		order      *models.Order
+		wantRead   bool
+		wantManage bool
+	}{
+		{"customer own order", customer, ownOrder, true, false},
+		{"customer other customer's order", otherCustomer, ownOrder, false, false},
+		{"customer own soft-deleted order", customer, deletedOrder, false, false},
+		{"staff any order", staff, ownOrder, true, true},
+		{"staff soft-deleted order", staff, deletedOrder, true, true},
+		{"courier assigned order", courierPrincipal, assignedOrder, true, true},
+		{"courier unassigned order", courierPrincipal, ownOrder, false, false},
+		{"courier order of another courier", courierPrincipal, otherCourierOrder, false, false},
+		{"principal without permissions", noPermissions, assignedOrder, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canReadOrder(tt.principal, tt.order); got != tt.wantRead {
+				t.Errorf("canReadOrder = %v, kutilgan %v", got, tt.wantRead)
+			}
+			if got := canManageOrder(tt.principal, tt.order); got != tt.wantManage {
+				t.Errorf("canManageOrder = %v, kutilgan %v", got, tt.wantManage)
+			}
+
+			// GetOrderDetailsFor: ko'rish huquqi bo'lmasa buyurtma "topilmadi" bo'ladi
+			details, err := readableOrderDetails(tt.principal, &models.OrderDetailsResponse{Order: *tt.order})
+			if tt.wantRead {
+				if err != nil || details.Order.OrderID != tt.order.OrderID {
+					t.Errorf("readableOrderDetails = %v, %v; buyurtma kutilgan", details, err)
+				}
+			} else if !errors.Is(err, ErrOrderNotFound) || details != nil {
+				t.Errorf("readableOrderDetails xatosi = %v, kutilgan %v", err, ErrOrderNotFound)
+			}
+		})
+	}
+}
+
+func TestIsAssignedCourier(t *testing.T) {
+	courierID := int64(300)
+	courier := &models.Principal{TelegramID: courierID, Permissions: []string{models.PermOrdersReadAssigned}}
+
+	if isAssignedCourier(courier, &models.Order{}) {
+		t.Error("kuryer biriktirilmagan buyurtma uchun true qaytdi")
+	}
+	if !isAssignedCourier(courier, &models.Order{CourierTelegramID: &courierID}) {
+		t.Error("biriktirilgan kuryer uchun false qaytdi")
+	}
+	if isAssignedCourier(&models.Principal{TelegramID: courierID}, &models.Order{CourierTelegramID: &courierID}) {
+		t.Error("orders.read.assigned ruxsatisiz foydalanuvchi kuryer deb hisoblandi")
+	}
+}
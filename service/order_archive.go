@@ -0,0 +1,76 @@
+package service
+
+import (
+	"amur/models"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// orderArchiveBatchSize bitta tranzaksiyada arxivlanadigan buyurtmalar soni (uzoq qulflarning oldini olish uchun)
+const orderArchiveBatchSize = 500
+
+// finalOrderStatuses arxivlash mumkin bo'lgan yakuniy holatlar
+func finalOrderStatuses() []string {
+	var statuses []string
+	for _, status := range models.OrderStatuses() {
+		if status.Final {
+			statuses = append(statuses, status.Code)
+		}
+	}
+	return statuses
+}
+
+// ArchiveOldOrders olderThanMonths oydan eski yakunlangan yoki o'chirilgan buyurtmalarni arxiv jadvallariga ko'chiradi.
+// Har bir partiya alohida tranzaksiyada bajariladi; ko'chirilgan buyurtmalar sonini qaytaradi.
+func (s *OrderService) ArchiveOldOrders(olderThanMonths int) (int, error) {
+	if olderThanMonths <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().AddDate(0, -olderThanMonths, 0)
+	statuses := finalOrderStatuses()
+
+	total := 0
+	for {
+		var archived int
+		err := s.uow.Do(func(tx *sql.Tx) error {
+			var err error
+			archived, err = s.orderRepo.WithTx(tx).ArchiveOrders(cutoff, statuses, orderArchiveBatchSize)
+			return err
+		})
+		if err != nil {
+			return total, fmt.Errorf("buyurtmalarni arxivlashda xatolik: %w", err)
+		}
+		total += archived
+		if archived < orderArchiveBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// StartArchiver eski buyurtmalarni har interval da arxivlab turadi (olderThanMonths 0 bo'lsa o'chirilgan)
+func (s *OrderService) StartArchiver(interval time.Duration, olderThanMonths int, stop <-chan struct{}) {
+	if olderThanMonths <= 0 {
+		log.Println("ℹ️ Buyurtmalarni arxivlash o'chirilgan (ORDER_ARCHIVE_MONTHS=0)")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				archived, err := s.ArchiveOldOrders(olderThanMonths)
+				if err != nil {
+					log.Printf("Buyurtmalarni arxivlashda xatolik: %v", err)
+				}
+				if archived > 0 {
+					log.Printf("📦 %d ta eski buyurtma arxivga ko'chirildi", archived)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
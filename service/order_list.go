@@ -0,0 +1,128 @@
+package service
+
+import (
+	"amur/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	DefaultOrderListLimit = 20  // Sahifadagi buyurtmalar soni (limit ko'rsatilmaganda)
+	MaxOrderListLimit     = 100 // Bitta sahifadagi eng ko'p buyurtmalar soni
+)
+
+var ErrInvalidOrderFilter = errors.New("noto'g'ri buyurtma filtri")
+
+// ListOrders xodimlar uchun buyurtmalar ro'yxati: filtrlar, saralash va kursorli sahifalash bilan.
+// Buyurtma elementlari barcha sahifa uchun bitta so'rovda olinadi.
+func (s *OrderService) ListOrders(filter models.OrderListFilter, cursor string) (*models.OrderListPage, error) {
+	if filter.Status != "" {
+		if _, ok := models.LookupOrderStatus(filter.Status); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidOrderStatus, filter.Status)
+		}
+	}
+	if err := validateDeliveryType(filter.DeliveryType); err != nil {
+		return nil, err
+	}
+	switch filter.SortBy {
+	case "":
+		filter.SortBy = models.OrderSortByTime
+	case models.OrderSortByTime, models.OrderSortByPrice:
+	default:
+		return nil, fmt.Errorf("%w: sort faqat '%s' yoki '%s' bo'lishi mumkin", ErrInvalidOrderFilter, models.OrderSortByTime, models.OrderSortByPrice)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("%w: 'from' sanasi 'to' dan oldin bo'lishi kerak", ErrInvalidOrderFilter)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultOrderListLimit
+	}
+	if filter.Limit > MaxOrderListLimit {
+		filter.Limit = MaxOrderListLimit
+	}
+
+	if cursor != "" {
+		decoded, err := decodeOrderCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		// Kursor boshqa saralash uchun berilgan bo'lsa sahifalar aralashib ketadi
+		if (filter.SortBy == models.OrderSortByPrice && decoded.TotalPrice == nil) ||
+			(filter.SortBy == models.OrderSortByTime && decoded.OrderTime == nil) {
+			return nil, fmt.Errorf("%w: kursor boshqa saralash uchun berilgan", ErrInvalidOrderFilter)
+		}
+		filter.Cursor = decoded
+	}
+
+	// Keyingi sahifa borligini bilish uchun bitta ortiq buyurtma olinadi
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1
+	orders, err := s.orderRepo.ListOrders(filter)
+	if err != nil {
+		return nil, fmt.Errorf("buyurtmalar ro'yxatini olishda xatolik: %w", err)
+	}
+
+	page := &models.OrderListPage{}
+	if len(orders) > pageSize {
+		orders = orders[:pageSize]
+		page.HasMore = true
+	}
+	page.Orders, err = s.attachOrderItems(orders)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.HasMore {
+		last := orders[len(orders)-1]
+		next := &models.OrderListCursor{OrderID: last.OrderID}
+		if filter.SortBy == models.OrderSortByPrice {
+			next.TotalPrice = &last.TotalPrice
+		} else {
+			next.OrderTime = &last.OrderTime
+		}
+		page.NextCursor = encodeOrderCursor(next)
+	}
+	return page, nil
+}
+
+// attachOrderItems buyurtmalar elementlarini bitta so'rovda olib, to'liq javobga aylantiradi
+func (s *OrderService) attachOrderItems(orders []*models.Order) ([]models.OrderDetailsResponse, error) {
+	orderIDs := make([]int, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.OrderID)
+	}
+	items, err := s.orderRepo.GetItemsByOrderIDs(orderIDs)
+	if err != nil {
+		return nil, fmt.Errorf("buyurtma elementlarini olishda xatolik: %w", err)
+	}
+
+	details := make([]models.OrderDetailsResponse, 0, len(orders))
+	for _, order := range orders {
+		details = append(details, models.OrderDetailsResponse{
+			Order:      *withStatusLabel(order),
+			OrderItems: items[order.OrderID],
+		})
+	}
+	return details, nil
+}
+
+// encodeOrderCursor kursorni URL uchun xavfsiz matnga aylantiradi
+func encodeOrderCursor(cursor *models.OrderListCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeOrderCursor encodeOrderCursor natijasini qayta o'qiydi
+func decodeOrderCursor(raw string) (*models.OrderListCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: kursor noto'g'ri", ErrInvalidOrderFilter)
+	}
+	var cursor models.OrderListCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.OrderID <= 0 {
+		return nil, fmt.Errorf("%w: kursor noto'g'ri", ErrInvalidOrderFilter)
+	}
+	return &cursor, nil
+}
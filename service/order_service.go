@@ -2,173 +2,249 @@ package service
 
 import (
 	"amur/models"
+	"amur/pkg/geo"
 	"amur/repository"
 	"database/sql"
-	"encoding/json" // Import for JSON handling
 	"errors"
 	"fmt"
-	"io/ioutil" // Import for file reading
+	"log"
+	"strings"
 	"time"
 )
 
+var (
+	ErrEmptyBasket              = errors.New("savatcha bo'sh, buyurtma berish mumkin emas")
+	ErrDeliveryLocationRequired = errors.New("yetkazib berish uchun lokatsiya ma'lumotlari (latitude va longitude) majburiy")
+	ErrTableTokenRequired       = errors.New("zalga buyurtma berish uchun stol ID (QR kod tokeni) majburiy")
+	ErrInvalidDeliveryType      = errors.New("noto'g'ri yetkazib berish turi")
+	ErrUnknownTable             = errors.New("stol topilmadi, QR kodni qayta skanerlang")
+	ErrTableInactive            = errors.New("bu stol hozir buyurtma qabul qilmaydi, iltimos, ofitsiantga murojaat qiling")
+)
+
 type OrderService struct {
-	orderRepo  *repository.OrderRepository
-	basketRepo *repository.BasketOrderRepository
-	foodRepo   *repository.FoodRepository
-	tableMap   map[string]string // Add tableMap to store table_id (token) -> table_name
-}
+	uow         *repository.UnitOfWork
+	orderRepo   *repository.OrderRepository
+	basketRepo  *repository.BasketOrderRepository
+	foodRepo    *repository.FoodRepository
+	permissions *PermissionService // Kuryer biriktirishda foydalanuvchi ruxsatini tekshirish uchun
+	pricing     PricingPolicy
+	tableRepo   *repository.TableRepository
+	zoneRepo    *repository.DeliveryZoneRepository
 
-func NewOrderService(orderRepo *repository.OrderRepository, basketRepo *repository.BasketOrderRepository, foodRepo *repository.FoodRepository) *OrderService {
-	// Load table data when the service is initialized
-	tableMap, err := loadTableData("table.json")
-	if err != nil {
-		// Handle this error appropriately in a real application (e.g., log it and exit)
-		fmt.Printf("Error loading table data: %v\n", err)
-		// For now, we'll continue, but orders for "zalga" will fail if table data isn't loaded.
-	}
+	cancellableStatuses map[string]bool // Mijoz o'zi bekor qila oladigan holatlar
+	notifier            Notifier        // Xodimlarni bot orqali ogohlantirish uchun
+	staffChatID         int64
+}
 
+func NewOrderService(uow *repository.UnitOfWork, orderRepo *repository.OrderRepository, basketRepo *repository.BasketOrderRepository, foodRepo *repository.FoodRepository, tableRepo *repository.TableRepository, zoneRepo *repository.DeliveryZoneRepository, permissions *PermissionService, pricing PricingPolicy) *OrderService {
 	return &OrderService{
-		orderRepo:  orderRepo,
-		basketRepo: basketRepo,
-		foodRepo:   foodRepo,
-		tableMap:   tableMap,
-	}
-}
+		uow:         uow,
+		orderRepo:   orderRepo,
+		basketRepo:  basketRepo,
+		foodRepo:    foodRepo,
+		permissions: permissions,
+		pricing:     pricing,
+		tableRepo:   tableRepo,
+		zoneRepo:    zoneRepo,
 
-// loadTableData loads table names from a JSON file into a map (token -> table name)
-func loadTableData(filename string) (map[string]string, error) {
-	data, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("could not read table data file: %w", err)
+		cancellableStatuses: map[string]bool{models.OrderStatusAccepted: true},
 	}
+}
 
-	var rawTableMap map[string]string
-	if err := json.Unmarshal(data, &rawTableMap); err != nil {
-		return nil, fmt.Errorf("could not unmarshal table data: %w", err)
+// SetCustomerCancellableStatuses mijoz buyurtmani o'zi bekor qila oladigan holatlarni o'rnatadi.
+// Noma'lum yoki yakuniy holatlar e'tiborsiz qoldiriladi.
+func (s *OrderService) SetCustomerCancellableStatuses(statuses []string) {
+	allowed := make(map[string]bool, len(statuses))
+	for _, code := range statuses {
+		status, ok := models.LookupOrderStatus(code)
+		if !ok || status.Final {
+			log.Printf("⚠️ Bekor qilish uchun noto'g'ri holat o'tkazib yuborildi: %q", code)
+			continue
+		}
+		allowed[code] = true
 	}
+	s.cancellableStatuses = allowed
+}
 
-	// Invert the map: token -> table name
-	invertedMap := make(map[string]string)
-	for tableName, token := range rawTableMap {
-		invertedMap[token] = tableName
-	}
-	return invertedMap, nil
+// SetStaffNotifier bot ishga tushgandan keyin xodimlar chatiga xabar yuboruvchini o'rnatadi (chatID 0 bo'lsa yuborilmaydi)
+func (s *OrderService) SetStaffNotifier(notifier Notifier, chatID int64) {
+	s.notifier = notifier
+	s.staffChatID = chatID
 }
 
-// CreateOrder savatchadagi mahsulotlardan yangi buyurtma yaratadi
+// CreateOrder savatchadagi mahsulotlardan yangi buyurtma yaratadi.
+// Savatchani o'qish, narxlarni olish, buyurtma va uning elementlarini yozish hamda savatchani tozalash
+// bitta tranzaksiyada bajariladi; savatcha qatorlari FOR UPDATE bilan qulflanadi.
 func (s *OrderService) CreateOrder(telegramID int64, req *models.CreateOrderRequest) (*models.OrderDetailsResponse, error) {
-	// 1. Foydalanuvchining savatchasini olish
-	basketItems, err := s.basketRepo.GetBasketOrdersByTelegramID(telegramID)
-	if err != nil {
-		return nil, fmt.Errorf("savatchani olishda xatolik: %w", err)
-	}
-	if len(basketItems) == 0 {
-		return nil, errors.New("savatcha bo'sh, buyurtma berish mumkin emas")
-	}
-
-	var totalOrderPrice float64
-	var orderItemsToCreate []*models.OrderItem // Buyurtma uchun qo'shiladigan mahsulotlar (vaqtinchalik pointerlar slice'i)
-
-	// 2. Har bir savatcha elementi uchun mahsulot narxini olish va umumiy narxni hisoblash
-	for _, item := range basketItems {
-		food, err := s.foodRepo.GetByID(item.FoodID)
-		if err != nil {
-			// Agar ovqat topilmasa, bu buyurtmani yaratishga to'sqinlik qilishi kerak
-			return nil, fmt.Errorf("FoodID %d uchun ovqat topilmadi: %w", item.FoodID, err)
-		}
-		itemTotalPrice := food.FoodPrice * float64(item.Quantity)
-		totalOrderPrice += itemTotalPrice
-
-		orderItemsToCreate = append(orderItemsToCreate, &models.OrderItem{
-			FoodID:    item.FoodID,
-			Quantity:  item.Quantity,
-			ItemPrice: food.FoodPrice, // Buyurtma qilingan vaqtdagi narx
-		})
-	}
-
-	// 3. Buyurtma yaratish (asosiy order ma'lumotlari)
+	// Buyurtma asosiy ma'lumotlari (narx tranzaksiya ichida hisoblanadi)
 	order := &models.Order{
 		TelegramID:   telegramID,
 		OrderTime:    time.Now(),
-		OrderStatus:  "buyurtma qabul qilindi", // Default holat
+		OrderStatus:  models.OrderStatusAccepted, // Boshlang'ich holat
 		DeliveryType: req.DeliveryType,
-		TotalPrice:   totalOrderPrice,
 		Comment:      req.Comment, // Buyurtma izohi
 	}
 
 	// Handle delivery type specific logic
 	switch req.DeliveryType {
-	case "yetkazib berish":
+	case models.DeliveryTypeDelivery:
 		if req.DeliveryLatitude == nil || req.DeliveryLongitude == nil {
-			return nil, errors.New("yetkazib berish uchun lokatsiya ma'lumotlari (latitude va longitude) majburiy")
+			return nil, ErrDeliveryLocationRequired
+		}
+		if point := (geo.Point{Lat: *req.DeliveryLatitude, Lng: *req.DeliveryLongitude}); !point.Valid() {
+			return nil, ErrInvalidDeliveryLocation
 		}
 		order.DeliveryLatitude = req.DeliveryLatitude
 		order.DeliveryLongitude = req.DeliveryLongitude
-	case "o'zi olib ketish":
+	case models.DeliveryTypePickup:
 		order.DeliveryLatitude = nil
 		order.DeliveryLongitude = nil
-		order.TableID = nil // Ensure TableID is null for this type
-	case "zalga":
+	case models.DeliveryTypeDineIn:
 		order.DeliveryLatitude = nil
 		order.DeliveryLongitude = nil
-		if req.TableID == nil {
-			return nil, errors.New("zalga buyurtma berish uchun stol ID (QR kod tokeni) majburiy")
+		if req.TableToken == nil || strings.TrimSpace(*req.TableToken) == "" {
+			return nil, ErrTableTokenRequired
 		}
-
-		// Look up the table name using the provided token
-		tableName, ok := s.tableMap[*req.TableID]
-		if !ok {
-		}
-		// Set the TableID field in the Order model to the resolved table name
-		tableIDStr := tableName // Convert string to *string
-		order.TableID = &tableIDStr
-		// You can also set the comment based on the table name if you wish
-		// If req.Comment is not already set, you could do:
-		// if order.Comment == nil {
-		// 	comment := fmt.Sprintf("Stol: %s", tableName)
-		// 	order.Comment = &comment
-		// }
 	default:
-		return nil, fmt.Errorf("noto'g'ri yetkazib berish turi: %s", req.DeliveryType)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDeliveryType, req.DeliveryType)
 	}
 
-	createdOrder, err := s.orderRepo.CreateOrder(order)
-	if err != nil {
-		return nil, fmt.Errorf("buyurtma yaratishda xatolik: %w", err)
-	}
+	err := s.uow.Do(func(tx *sql.Tx) error {
+		basketRepo := s.basketRepo.WithTx(tx)
+		orderRepo := s.orderRepo.WithTx(tx)
+		foodRepo := s.foodRepo.WithTx(tx)
 
-	// 4. Buyurtma elementlarini (order_items) qo'shish
-	for _, item := range orderItemsToCreate { // `orderItemsToCreate` dan foydalanamiz
-		item.OrderID = createdOrder.OrderID // Yangi yaratilgan buyurtma ID'sini bog'lash
-		if err := s.orderRepo.AddOrderItem(item); err != nil {
-			return nil, fmt.Errorf("buyurtma elementini qo'shishda xatolik: %w", err)
+		// Savatchaga yozuvchi boshqa so'rovlar (AddToBasket, ReplaceBasket, SetQuantity) bilan navbat: qulfsiz
+		// o'qish va tozalash orasida qo'shilgan qator na buyurtmaga, na savatchaga tushmay yo'qolib qolardi
+		if err := basketRepo.LockBasket(telegramID); err != nil {
+			return fmt.Errorf("savatchani qulflashda xatolik: %w", err)
 		}
-	}
 
-	// 5. Savatchani tozalash
-	if err := s.basketRepo.ClearBasket(telegramID); err != nil {
-		fmt.Printf("Savatchani tozalashda xatolik (Buyurtma yaratildi, lekin savatcha tozalanmadi): %v\n", err)
-	}
+		// Zalga buyurtmada QR token bazadagi faol stolga mos kelishi shart; buyurtma stolning ochiq hisobiga qo'shiladi
+		if order.DeliveryType == models.DeliveryTypeDineIn {
+			tableRepo := s.tableRepo.WithTx(tx)
+			table, err := resolveTable(tableRepo, *req.TableToken)
+			if err != nil {
+				return err
+			}
+			session, _, err := tableRepo.OpenSession(table.TableID, telegramID)
+			if err != nil {
+				return fmt.Errorf("stol hisobini ochishda xatolik: %w", err)
+			}
+			order.TableID = &table.TableID
+			order.TableSessionID = &session.SessionID
+		}
 
-	// 6. To'liq buyurtma ma'lumotlarini qaytarish
-	// Repositorydan pointerlar slice'ini olamiz
-	_, fetchedOrderItemsPointers, err := s.orderRepo.GetOrderWithItemsByID(createdOrder.OrderID)
-	if err != nil {
-		return nil, fmt.Errorf("yaratilgan buyurtmani olishda xatolik: %w", err)
-	}
+		// 1. Savatchani qulflab olish: ikkinchi parallel so'rov shu yerda kutadi
+		basketItems, err := basketRepo.GetBasketOrdersForUpdate(telegramID)
+		if err != nil {
+			return fmt.Errorf("savatchani olishda xatolik: %w", err)
+		}
+		if len(basketItems) == 0 {
+			return ErrEmptyBasket
+		}
 
-	// `[]*models.OrderItem` ni `[]models.OrderItem` ga aylantiramiz
-	var finalOrderItems []models.OrderItem
-	for _, itemPtr := range fetchedOrderItemsPointers {
-		if itemPtr != nil {
-			finalOrderItems = append(finalOrderItems, *itemPtr) // Pointerdan qiymatni olish
+		foodIDs := make([]int, 0, len(basketItems))
+		for _, item := range basketItems {
+			foodIDs = append(foodIDs, item.FoodID)
 		}
+		modifierGroups, err := foodRepo.GetModifierGroups(foodIDs)
+		if err != nil {
+			return fmt.Errorf("modifikatorlarni olishda xatolik: %w", err)
+		}
+
+		// 2. Har bir savatcha elementi uchun joriy narxni (modifikatorlar bilan) olish va subtotalni hisoblash
+		var subtotal float64
+		var orderItemsToCreate []*models.OrderItem
+		for _, item := range basketItems {
+			food, err := foodRepo.GetByID(item.FoodID)
+			if err != nil {
+				// Agar ovqat topilmasa, bu buyurtmani yaratishga to'sqinlik qilishi kerak
+				return fmt.Errorf("FoodID %d uchun ovqat topilmadi: %w", item.FoodID, err)
+			}
+			modifiers, delta, err := resolveModifiers(modifierGroups[item.FoodID], item.ModifierIDs)
+			if err != nil {
+				return fmt.Errorf("'%s': %w", food.FoodName, err)
+			}
+			unitPrice := roundMoney(food.FoodPrice + delta)
+			subtotal += roundMoney(unitPrice * float64(item.Quantity))
+
+			orderItem := &models.OrderItem{
+				FoodID:    item.FoodID,
+				Quantity:  item.Quantity,
+				ItemPrice: unitPrice, // Buyurtma qilingan vaqtdagi narx
+				Modifiers: modifiers,
+			}
+			if item.Note != "" {
+				note := item.Note
+				orderItem.Note = &note
+			}
+			orderItemsToCreate = append(orderItemsToCreate, orderItem)
+		}
+
+		// Yetkazib berishda manzil faol hududlardan biriga tushishi va subtotal hudud minimal summasidan kam bo'lmasligi shart;
+		// yetkazib berish narxi hududdan olinadi
+		var deliveryFee float64
+		if order.DeliveryType == models.DeliveryTypeDelivery {
+			zones, err := s.zoneRepo.WithTx(tx).GetZones(false)
+			if err != nil {
+				return fmt.Errorf("yetkazib berish hududlarini olishda xatolik: %w", err)
+			}
+			zone, err := locateDeliveryZone(zones, *order.DeliveryLatitude, *order.DeliveryLongitude)
+			if err != nil {
+				return err
+			}
+			if roundMoney(subtotal) < zone.MinOrderAmount {
+				return fmt.Errorf("%w: '%s' hududi uchun kamida %.2f, savatchada %.2f", ErrDeliveryMinimumNotMet,
+					zone.ZoneName, zone.MinOrderAmount, roundMoney(subtotal))
+			}
+			deliveryFee = zone.DeliveryFee
+			order.DeliveryZoneID = &zone.ZoneID
+			order.DeliveryETA = &zone.EtaMinutes
+		}
+
+		// Savatcha xulosasidagi bilan bir xil qoidalar bo'yicha to'lovlar va yakuniy summa
+		breakdown := s.pricing.Breakdown(subtotal, order.DeliveryType, deliveryFee)
+		order.Subtotal = breakdown.Subtotal
+		order.DeliveryFee = breakdown.DeliveryFee
+		order.ServiceCharge = breakdown.ServiceCharge
+		order.DiscountAmount = breakdown.Discount
+		order.TotalPrice = breakdown.GrandTotal
+
+		// 3. Buyurtma yaratish
+		if _, err := orderRepo.CreateOrder(order); err != nil {
+			return fmt.Errorf("buyurtma yaratishda xatolik: %w", err)
+		}
+
+		// Tarixning birinchi yozuvi: buyurtmani mijozning o'zi yaratdi
+		if err := orderRepo.AddStatusHistory(&models.OrderStatusHistory{
+			OrderID:         order.OrderID,
+			ToStatus:        order.OrderStatus,
+			ActorTelegramID: &telegramID,
+		}); err != nil {
+			return fmt.Errorf("holat tarixini yozishda xatolik: %w", err)
+		}
+
+		// 4. Buyurtma elementlarini (order_items) qo'shish
+		for _, item := range orderItemsToCreate {
+			item.OrderID = order.OrderID
+			if err := orderRepo.AddOrderItem(item); err != nil {
+				return fmt.Errorf("buyurtma elementini qo'shishda xatolik: %w", err)
+			}
+		}
+
+		// 5. Savatchani tozalash: muvaffaqiyatsiz bo'lsa buyurtma ham bekor qilinadi
+		if err := basketRepo.ClearBasket(telegramID); err != nil {
+			return fmt.Errorf("savatchani tozalashda xatolik: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &models.OrderDetailsResponse{
-		Order:      *createdOrder,
-		OrderItems: finalOrderItems, // Endi to'g'ri tip
-	}, nil
+	// 6. To'liq buyurtma ma'lumotlarini qaytarish
+	return s.GetOrderDetails(order.OrderID)
 }
 
 // GetOrderDetails buyurtma va uning elementlarini qaytaradi
@@ -176,7 +252,7 @@ func (s *OrderService) GetOrderDetails(orderID int) (*models.OrderDetailsRespons
 	order, orderItemsPointers, err := s.orderRepo.GetOrderWithItemsByID(orderID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("id=%d bo'lgan buyurtma topilmadi", orderID)
+			return nil, fmt.Errorf("%w: id=%d", ErrOrderNotFound, orderID)
 		}
 		return nil, fmt.Errorf("buyurtma ma'lumotlarini olishda xatolik: %w", err)
 	}
@@ -190,80 +266,133 @@ func (s *OrderService) GetOrderDetails(orderID int) (*models.OrderDetailsRespons
 	}
 
 	return &models.OrderDetailsResponse{
-		Order:      *order,
+		Order:      *withStatusLabel(order),
 		OrderItems: orderItems, // Endi to'g'ri tip
 	}, nil
 }
 
-// GetUserOrdersWithDetails foydalanuvchining barcha buyurtmalarini va ularning elementlarini oladi
+// GetUserOrdersWithDetails foydalanuvchining barcha buyurtmalarini va ularning elementlarini oladi.
+// Elementlar har bir buyurtma uchun alohida emas, bitta so'rovda olinadi.
 func (s *OrderService) GetUserOrdersWithDetails(telegramID int64) ([]models.OrderDetailsResponse, error) {
 	orders, err := s.orderRepo.GetUserOrders(telegramID)
 	if err != nil {
 		return nil, fmt.Errorf("foydalanuvchi buyurtmalarini olishda xatolik: %w", err)
 	}
+	return s.attachOrderItems(orders)
+}
 
-	var allOrderDetails []models.OrderDetailsResponse
-	for _, order := range orders {
-		_, orderItemsPointers, err := s.orderRepo.GetOrderWithItemsByID(order.OrderID)
+// UpdateOrderStatus buyurtma holatini yetkazib berish turi bo'yicha ruxsat etilgan o'tishlar asosida o'zgartiradi
+// va o'zgarishni kim, qachon va nima sababdan qilganini tarixga yozadi.
+// newStatus holat kodi yoki eski o'zbekcha matn bo'lishi mumkin; bekor qilishda sabab majburiy.
+// Kuryer faqat o'ziga biriktirilgan buyurtma holatini o'zgartira oladi, boshqalari uchun buyurtma "topilmadi".
+func (s *OrderService) UpdateOrderStatus(orderID int, actor *models.Principal, newStatus, reason string) (*models.Order, error) {
+	cleanReason, err := normalizeStatusReason(reason)
+	if err != nil {
+		return nil, err
+	}
+
+	var order *models.Order
+	err = s.uow.Do(func(tx *sql.Tx) error {
+		orderRepo := s.orderRepo.WithTx(tx)
+
+		order, err = orderRepo.GetOrderForUpdate(orderID)
 		if err != nil {
-			fmt.Printf("Order ID %d uchun buyurtma elementlarini olishda xatolik: %v\n", order.OrderID, err)
-			continue
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: id=%d", ErrOrderNotFound, orderID)
+			}
+			return fmt.Errorf("buyurtmani olishda xatolik: %w", err)
+		}
+		if order.DeletedAt != nil || !canManageOrder(actor, order) {
+			return fmt.Errorf("%w: id=%d", ErrOrderNotFound, orderID)
 		}
 
-		// `[]*models.OrderItem` ni `[]models.OrderItem` ga aylantiramiz
-		var orderItems []models.OrderItem
-		for _, itemPtr := range orderItemsPointers {
-			if itemPtr != nil {
-				orderItems = append(orderItems, *itemPtr) // Pointerdan qiymatni olish
-			}
+		status, ok := models.ParseOrderStatus(newStatus, order.DeliveryType)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrInvalidOrderStatus, newStatus)
+		}
+		if !canTransition(order.DeliveryType, order.OrderStatus, status) {
+			return fmt.Errorf("%w: '%s' -> '%s'", ErrInvalidStatusTransition,
+				models.OrderStatusLabel(order.OrderStatus, models.DefaultLanguage), models.OrderStatusLabel(status, models.DefaultLanguage))
+		}
+		if status == models.OrderStatusCancelled && cleanReason == nil {
+			return ErrStatusReasonRequired
 		}
 
-		allOrderDetails = append(allOrderDetails, models.OrderDetailsResponse{
-			Order:      *order,
-			OrderItems: orderItems, // Endi to'g'ri tip
-		})
+		if err := orderRepo.UpdateOrderStatus(orderID, status); err != nil {
+			return fmt.Errorf("buyurtma holatini yangilashda xatolik: %w", err)
+		}
+		previous := order.OrderStatus
+		if err := orderRepo.AddStatusHistory(&models.OrderStatusHistory{
+			OrderID:         orderID,
+			FromStatus:      &previous,
+			ToStatus:        status,
+			ActorTelegramID: &actor.TelegramID,
+			Reason:          cleanReason,
+		}); err != nil {
+			return fmt.Errorf("holat tarixini yozishda xatolik: %w", err)
+		}
+		order.OrderStatus = status
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := s.GetOrderDetails(orderID)
+	if err != nil {
+		return nil, err
 	}
-	return allOrderDetails, nil
+	return &details.Order, nil
 }
 
-// UpdateOrderStatus buyurtma holatini yangilaydi
-func (s *OrderService) UpdateOrderStatus(orderID int, newStatus string) error {
-	validStatuses := map[string]bool{
-		"buyurtma qabul qilindi":  true,
-		"buyurtma tayyorlanmoqda": true,
-		"buyurtma tayyor":         true,
-		"buyurtma yetkazildi":     true,
-		"bekor qilindi":           true,
+// GetOrderStatusHistory buyurtma holatlari tarixini qaytaradi.
+// Buyurtmani ko'rish huquqi bo'lganlar (egasi, biriktirilgan kuryer, orders.read.all) ko'ra oladi; boshqalar uchun buyurtma "topilmadi".
+func (s *OrderService) GetOrderStatusHistory(orderID int, viewer *models.Principal) ([]models.OrderStatusHistory, error) {
+	order, _, err := s.orderRepo.GetOrderWithItemsByID(orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: id=%d", ErrOrderNotFound, orderID)
+		}
+		return nil, fmt.Errorf("buyurtmani olishda xatolik: %w", err)
 	}
-	if !validStatuses[newStatus] {
-		return fmt.Errorf("noto'g'ri buyurtma holati: %s", newStatus)
+	if !canReadOrder(viewer, order) {
+		return nil, fmt.Errorf("%w: id=%d", ErrOrderNotFound, orderID)
 	}
 
-	err := s.orderRepo.UpdateOrderStatus(orderID, newStatus)
+	entries, err := s.orderRepo.GetStatusHistory(orderID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("id=%d bo'lgan buyurtma topilmadi", orderID)
+		return nil, fmt.Errorf("holat tarixini olishda xatolik: %w", err)
+	}
+	history := make([]models.OrderStatusHistory, 0, len(entries))
+	for _, entry := range entries {
+		if entry.FromStatus != nil {
+			entry.FromStatusLabel = models.OrderStatusLabel(*entry.FromStatus, models.DefaultLanguage)
 		}
-		return fmt.Errorf("buyurtma holatini yangilashda xatolik: %w", err)
+		entry.ToStatusLabel = models.OrderStatusLabel(entry.ToStatus, models.DefaultLanguage)
+		history = append(history, *entry)
 	}
-	return nil
+	return history, nil
 }
 
-// GetOrderStats buyurtma statistikasini oladi
-func (s *OrderService) GetOrderStats() (int, error) {
-	count, err := s.orderRepo.GetOrderStats()
+// GetOrderStats buyurtma statistikasini oladi (includeDeleted - o'chirilganlarni ham hisoblash)
+func (s *OrderService) GetOrderStats(includeDeleted bool) (int, error) {
+	count, err := s.orderRepo.GetOrderStats(includeDeleted)
 	if err != nil {
 		return 0, fmt.Errorf("buyurtma statistikasini olishda xatolik: %w", err)
 	}
 	return count, nil
 }
 
-// DeleteOrderAdmin buyurtmani o'chiradi (FAQAT ADMIN UCHUN)
-func (s *OrderService) DeleteOrderAdmin(orderID int) error {
-	err := s.orderRepo.DeleteOrder(orderID)
+// DeleteOrderAdmin buyurtmani o'chirilgan deb belgilaydi (FAQAT ADMIN UCHUN).
+// Buyurtma, elementlari va tarixi bazada qoladi, faqat mijoz ro'yxatlari va statistikadan chiqariladi.
+func (s *OrderService) DeleteOrderAdmin(orderID int, actorTelegramID int64, reason string) error {
+	cleanReason, err := normalizeStatusReason(reason)
 	if err != nil {
+		return err
+	}
+	if err := s.orderRepo.SoftDeleteOrder(orderID, actorTelegramID, cleanReason); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("id=%d bo'lgan buyurtma topilmadi", orderID)
+			return fmt.Errorf("%w: id=%d", ErrOrderNotFound, orderID)
 		}
 		return fmt.Errorf("buyurtmani o'chirishda xatolik: %w", err)
 	}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"amur/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// MaxStatusReasonLength holat o'zgarishi sababining eng ko'p uzunligi (belgilarda)
+const MaxStatusReasonLength = 500
+
+var (
+	ErrOrderNotFound           = errors.New("buyurtma topilmadi")
+	ErrInvalidOrderStatus      = errors.New("noto'g'ri buyurtma holati")
+	ErrInvalidStatusTransition = errors.New("buyurtmani bu holatga o'tkazib bo'lmaydi")
+	ErrStatusReasonRequired    = errors.New("buyurtmani bekor qilish uchun sabab ko'rsatilishi majburiy")
+	ErrStatusReasonTooLong     = fmt.Errorf("sabab %d belgidan oshmasligi kerak", MaxStatusReasonLength)
+	ErrCancellationNotAllowed  = errors.New("buyurtmani endi bekor qilib bo'lmaydi, iltimos, xodimlarga murojaat qiling")
+)
+
+// orderStatusFlows har bir yetkazib berish turi uchun ruxsat etilgan holat o'tishlari.
+// Yakuniy holatlardan (yetkazildi, olib ketildi, stolga berildi, bekor qilindi) hech qayerga o'tilmaydi.
+var orderStatusFlows = map[string]map[string][]string{
+	models.DeliveryTypeDelivery: {
+		models.OrderStatusAccepted:  {models.OrderStatusPreparing, models.OrderStatusCancelled},
+		models.OrderStatusPreparing: {models.OrderStatusReady, models.OrderStatusCancelled},
+		models.OrderStatusReady:     {models.OrderStatusOnTheWay, models.OrderStatusCancelled},
+		models.OrderStatusOnTheWay:  {models.OrderStatusDelivered, models.OrderStatusCancelled},
+	},
+	models.DeliveryTypePickup: {
+		models.OrderStatusAccepted:  {models.OrderStatusPreparing, models.OrderStatusCancelled},
+		models.OrderStatusPreparing: {models.OrderStatusReady, models.OrderStatusCancelled},
+		models.OrderStatusReady:     {models.OrderStatusPickedUp, models.OrderStatusCancelled},
+	},
+	models.DeliveryTypeDineIn: {
+		models.OrderStatusAccepted:  {models.OrderStatusPreparing, models.OrderStatusCancelled},
+		models.OrderStatusPreparing: {models.OrderStatusReady, models.OrderStatusCancelled},
+		models.OrderStatusReady:     {models.OrderStatusServed, models.OrderStatusCancelled},
+	},
+}
+
+// canTransition buyurtmani from holatidan to holatiga o'tkazish mumkinligini tekshiradi
+func canTransition(deliveryType, from, to string) bool {
+	for _, next := range orderStatusFlows[deliveryType][from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeStatusReason sababni tozalaydi va uzunligini tekshiradi (bo'sh sabab nil bo'ladi)
+func normalizeStatusReason(reason string) (*string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, nil
+	}
+	if len([]rune(reason)) > MaxStatusReasonLength {
+		return nil, ErrStatusReasonTooLong
+	}
+	return &reason, nil
+}
+
+// withStatusLabel buyurtmaga holatning o'zbekcha nomini qo'shadi
+func withStatusLabel(order *models.Order) *models.Order {
+	order.OrderStatusLabel = models.OrderStatusLabel(order.OrderStatus, models.DefaultLanguage)
+	return order
+}
+
+// CancelOrderByCustomer mijozning o'z buyurtmasini bekor qilishi. Buyurtma o'chirilmaydi, "cancelled" holatiga o'tkaziladi.
+// Faqat dastlabki (sozlamalarda ko'rsatilgan) holatlarda va sabab ko'rsatilganda ruxsat beriladi; xodimlar bot orqali ogohlantiriladi.
+func (s *OrderService) CancelOrderByCustomer(orderID int, telegramID int64, reason string) (*models.Order, error) {
+	cleanReason, err := normalizeStatusReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	if cleanReason == nil {
+		return nil, ErrStatusReasonRequired
+	}
+
+	var order *models.Order
+	err = s.uow.Do(func(tx *sql.Tx) error {
+		orderRepo := s.orderRepo.WithTx(tx)
+
+		order, err = orderRepo.GetOrderForUpdate(orderID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: id=%d", ErrOrderNotFound, orderID)
+			}
+			return fmt.Errorf("buyurtmani olishda xatolik: %w", err)
+		}
+		// Boshqa mijozning (yoki o'chirilgan) buyurtmasi mavjudligini oshkor qilmaymiz
+		if order.TelegramID != telegramID || order.DeletedAt != nil {
+			return fmt.Errorf("%w: id=%d", ErrOrderNotFound, orderID)
+		}
+		if !s.cancellableStatuses[order.OrderStatus] || !canTransition(order.DeliveryType, order.OrderStatus, models.OrderStatusCancelled) {
+			return fmt.Errorf("%w (holat: %s)", ErrCancellationNotAllowed, models.OrderStatusLabel(order.OrderStatus, models.DefaultLanguage))
+		}
+
+		if err := orderRepo.UpdateOrderStatus(orderID, models.OrderStatusCancelled); err != nil {
+			return fmt.Errorf("buyurtmani bekor qilishda xatolik: %w", err)
+		}
+		previous := order.OrderStatus
+		if err := orderRepo.AddStatusHistory(&models.OrderStatusHistory{
+			OrderID:         orderID,
+			FromStatus:      &previous,
+			ToStatus:        models.OrderStatusCancelled,
+			ActorTelegramID: &telegramID,
+			Reason:          cleanReason,
+		}); err != nil {
+			return fmt.Errorf("holat tarixini yozishda xatolik: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyStaffCancellation(order, *cleanReason)
+
+	details, err := s.GetOrderDetails(orderID)
+	if err != nil {
+		return nil, err
+	}
+	return &details.Order, nil
+}
+
+// notifyStaffCancellation mijoz bekor qilgan buyurtma haqida xodimlar chatiga xabar yuboradi.
+// Xabar yuborilmasa ham bekor qilish kuchda qoladi, xato faqat logga yoziladi.
+func (s *OrderService) notifyStaffCancellation(order *models.Order, reason string) {
+	if s.notifier == nil || s.staffChatID == 0 {
+		return
+	}
+	text := fmt.Sprintf("❌ Mijoz buyurtmani bekor qildi\n🧾 Buyurtma: #%d\n🚚 Turi: %s\n💰 Summa: %.2f\n👤 Mijoz ID: %d\n📝 Sabab: %s",
+		order.OrderID, order.DeliveryType, order.TotalPrice, order.TelegramID, reason)
+	if err := s.notifier.SendMessage(s.staffChatID, text); err != nil {
+		log.Printf("Xodimlarni bekor qilish haqida ogohlantirishda xatolik (OrderID: %d): %v", order.OrderID, err)
+	}
+}
+
+// GetOrderStatuses barcha holatlar va har bir yetkazib berish turi uchun o'tishlar xaritasini qaytaradi
+func (s *OrderService) GetOrderStatuses() *models.OrderStatusesResponse {
+	response := &models.OrderStatusesResponse{Statuses: models.OrderStatuses()}
+	for _, deliveryType := range []string{models.DeliveryTypeDelivery, models.DeliveryTypePickup, models.DeliveryTypeDineIn} {
+		response.Flows = append(response.Flows, models.OrderStatusFlow{
+			DeliveryType: deliveryType,
+			Initial:      models.OrderStatusAccepted,
+			Transitions:  orderStatusFlows[deliveryType],
+		})
+	}
+	return response
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"amur/models"
+	"amur/repository"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+)
+
+var (
+	ErrUnknownRole         = errors.New("bunday rol mavjud emas")
+	ErrLastSuperAdmin      = errors.New("oxirgi superadmin rolini olib bo'lmaydi")
+	ErrUserNotFoundForRole = errors.New("foydalanuvchi topilmadi")
+)
+
+// PermissionService rollar, ruxsatlar va ularni foydalanuvchilarga berish bilan ishlaydi
+type PermissionService struct {
+	permissionRepo *repository.PermissionRepository
+	userRepo       *repository.UserRepository
+	sessionService *SessionService
+}
+
+func NewPermissionService(permissionRepo *repository.PermissionRepository, userRepo *repository.UserRepository, sessionService *SessionService) *PermissionService {
+	return &PermissionService{
+		permissionRepo: permissionRepo,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+	}
+}
+
+// PermissionsForRole rolning ruxsatlarini qaytaradi (JWT claimlariga joylash uchun)
+func (s *PermissionService) PermissionsForRole(role string) ([]string, error) {
+	permissions, err := s.permissionRepo.GetPermissionsByRole(role)
+	if err != nil {
+		return nil, fmt.Errorf("rol ruxsatlarini olishda xatolik: %w", err)
+	}
+	return permissions, nil
+}
+
+// HasPermission foydalanuvchining joriy roli (bazadagi) berilgan ruxsatga egami yoki yo'qligini tekshiradi.
+// Token bo'lmagan joylarda (masalan, bot buyruqlarida) ishlatiladi.
+func (s *PermissionService) HasPermission(telegramID int64, permission string) (bool, error) {
+	user, err := s.userRepo.GetByTgID(telegramID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("foydalanuvchini olishda xatolik: %w", err)
+	}
+
+	permissions, err := s.PermissionsForRole(user.Role)
+	if err != nil {
+		return false, err
+	}
+	for _, perm := range permissions {
+		if perm == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListRoles barcha rollarni ruxsatlari bilan qaytaradi
+func (s *PermissionService) ListRoles() ([]*models.Role, error) {
+	roles, err := s.permissionRepo.GetAllRoles()
+	if err != nil {
+		return nil, fmt.Errorf("rollarni olishda xatolik: %w", err)
+	}
+	return roles, nil
+}
+
+// AssignRole foydalanuvchiga yangi rol beradi. Eski tokenlardagi ruxsatlar darhol kuchini yo'qotishi uchun
+// foydalanuvchining barcha sessiyalari bekor qilinadi.
+func (s *PermissionService) AssignRole(actorTelegramID, targetTelegramID int64, role string) (*models.User, error) {
+	exists, err := s.permissionRepo.RoleExists(role)
+	if err != nil {
+		return nil, fmt.Errorf("rolni tekshirishda xatolik: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRole, role)
+	}
+
+	user, err := s.userRepo.GetByTgID(targetTelegramID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFoundForRole
+		}
+		return nil, fmt.Errorf("foydalanuvchini olishda xatolik: %w", err)
+	}
+	if user.Role == role {
+		return user, nil
+	}
+
+	if user.Role == models.RoleSuperAdmin {
+		count, err := s.userRepo.CountByRole(models.RoleSuperAdmin)
+		if err != nil {
+			return nil, fmt.Errorf("superadminlar sonini olishda xatolik: %w", err)
+		}
+		if count <= 1 {
+			return nil, ErrLastSuperAdmin
+		}
+	}
+
+	if err := s.userRepo.UpdateRole(targetTelegramID, role); err != nil {
+		return nil, err
+	}
+	if _, err := s.sessionService.RevokeAllForUser(targetTelegramID, "rol o'zgartirildi"); err != nil {
+		log.Printf("Rol o'zgargandan keyin sessiyalarni bekor qilishda xatolik: %v", err)
+	}
+
+	log.Printf("👤 Rol o'zgartirildi: TelegramID=%d, '%s' -> '%s' (o'zgartirgan: %d)", targetTelegramID, user.Role, role, actorTelegramID)
+	user.Role = role
+	return user, nil
+}
+
+// EnsureSuperAdmins konfiguratsiyada ko'rsatilgan foydalanuvchilarga superadmin rolini beradi.
+// Birinchi superadminni bazaga qo'lda kirmasdan tayinlash uchun ishga tushishda chaqiriladi.
+func (s *PermissionService) EnsureSuperAdmins(telegramIDs []int64) {
+	for _, id := range telegramIDs {
+		user, err := s.userRepo.GetByTgID(id)
+		if err != nil {
+			log.Printf("ℹ️ Superadmin %d hali ro'yxatdan o'tmagan", id)
+			continue
+		}
+		if user.Role == models.RoleSuperAdmin {
+			continue
+		}
+		if err := s.userRepo.UpdateRole(id, models.RoleSuperAdmin); err != nil {
+			log.Printf("Superadmin rolini berishda xatolik (ID: %d): %v", id, err)
+		}
+	}
+}
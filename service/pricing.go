@@ -0,0 +1,34 @@
+package service
+
+import (
+	"amur/models"
+	"math"
+)
+
+// PricingPolicy savatcha va buyurtma uchun qo'shimcha to'lovlar qoidalari.
+// Savatcha xulosasi ham, buyurtma yaratish ham shu hisobdan foydalanadi, shuning uchun mijoz ko'rgan summa buyurtmadagi bilan bir xil bo'ladi.
+type PricingPolicy struct {
+	ServiceChargePercent float64 // "zalga" buyurtmalari uchun xizmat haqi (subtotal foizida)
+}
+
+// Breakdown subtotal va yetkazib berish turi bo'yicha narx tarkibini hisoblaydi.
+// deliveryFee - manzil tushgan hudud narxi, faqat "yetkazib berish" turida qo'shiladi.
+// Yetkazib berish turi ko'rsatilmagan bo'lsa faqat subtotal hisoblanadi.
+func (p PricingPolicy) Breakdown(subtotal float64, deliveryType string, deliveryFee float64) models.PriceBreakdown {
+	breakdown := models.PriceBreakdown{Subtotal: roundMoney(subtotal)}
+	switch deliveryType {
+	case models.DeliveryTypeDelivery:
+		breakdown.DeliveryFee = roundMoney(deliveryFee)
+	case models.DeliveryTypeDineIn:
+		breakdown.ServiceCharge = roundMoney(subtotal * p.ServiceChargePercent / 100)
+	}
+	// Chegirmalar hozircha yo'q, lekin maydon buyurtmada saqlanadi
+	breakdown.Discount = 0
+	breakdown.GrandTotal = roundMoney(breakdown.Subtotal + breakdown.DeliveryFee + breakdown.ServiceCharge - breakdown.Discount)
+	return breakdown
+}
+
+// roundMoney summani tiyingacha (2 xona) yaxlitlaydi
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
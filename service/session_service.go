@@ -0,0 +1,195 @@
+package service
+
+import (
+	"amur/models"
+	"amur/pkg/jwt_auth"
+	"amur/repository"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// RefreshTokenTTL refresh tokenning amal qilish muddati (har almashtirishda yangilanadi)
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token yaroqsiz yoki muddati o'tgan, qaytadan tizimga kiring")
+	ErrRefreshTokenReused  = errors.New("refresh token qayta ishlatildi, xavfsizlik uchun sessiya bekor qilindi")
+)
+
+type SessionService struct {
+	sessionRepo    *repository.SessionRepository
+	userRepo       *repository.UserRepository
+	permissionRepo *repository.PermissionRepository
+}
+
+func NewSessionService(sessionRepo *repository.SessionRepository, userRepo *repository.UserRepository, permissionRepo *repository.PermissionRepository) *SessionService {
+	return &SessionService{
+		sessionRepo:    sessionRepo,
+		userRepo:       userRepo,
+		permissionRepo: permissionRepo,
+	}
+}
+
+// CreateSession foydalanuvchi uchun yangi sessiya ochadi va access/refresh token juftligini qaytaradi
+func (s *SessionService) CreateSession(user *models.User, client models.ClientInfo) (*models.LoginResponse, error) {
+	sessionID, err := randomHex(16)
+	if err != nil {
+		return nil, fmt.Errorf("sessiya ID yaratishda xatolik: %w", err)
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("refresh token yaratishda xatolik: %w", err)
+	}
+
+	session := &models.Session{
+		SessionID:        sessionID,
+		TelegramID:       user.TelegramID,
+		RefreshTokenHash: hashToken(secret),
+		UserAgent:        nullableString(client.UserAgent),
+		IPAddress:        nullableString(client.IP),
+	}
+	if err := s.sessionRepo.Create(session, RefreshTokenTTL); err != nil {
+		return nil, fmt.Errorf("sessiyani saqlashda xatolik: %w", err)
+	}
+
+	return s.issueTokens(user, sessionID, secret, session.ExpiresAt)
+}
+
+// Refresh refresh tokenni tekshiradi, uni yangisiga almashtiradi va yangi access token beradi.
+// Shu sessiyada avval almashtirilgan istalgan token (faqat oxirgisi emas) qayta kelsa, u o'g'irlangan deb
+// hisoblanadi va sessiya bekor qilinadi.
+func (s *SessionService) Refresh(refreshToken string) (*models.LoginResponse, error) {
+	sessionID, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("sessiyani olishda xatolik: %w", err)
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	presentedHash := hashToken(secret)
+	if !tokenHashEqual(presentedHash, session.RefreshTokenHash) {
+		return nil, s.rejectRefreshToken(session, presentedHash)
+	}
+
+	newSecret, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("refresh token yaratishda xatolik: %w", err)
+	}
+	expiresAt, err := s.sessionRepo.Rotate(session.SessionID, presentedHash, hashToken(newSecret), RefreshTokenTTL)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Token parallel so'rovda almashtirilgan: endi u ham eskirgan tokenlardan biri
+			return nil, s.rejectRefreshToken(session, presentedHash)
+		}
+		return nil, fmt.Errorf("refresh tokenni almashtirishda xatolik: %w", err)
+	}
+
+	// Rol va ruxsatlar o'zgargan bo'lishi mumkin, shuning uchun foydalanuvchini qaytadan o'qiymiz
+	user, err := s.userRepo.GetByTgID(session.TelegramID)
+	if err != nil {
+		return nil, fmt.Errorf("foydalanuvchini olishda xatolik: %w", err)
+	}
+
+	return s.issueTokens(user, session.SessionID, newSecret, expiresAt)
+}
+
+// rejectRefreshToken joriy bo'lmagan tokenni rad etadi. Token shu sessiyada avval almashtirilganlardan biri bo'lsa
+// sessiya bekor qilinadi va ErrRefreshTokenReused, aks holda ErrInvalidRefreshToken qaytadi.
+func (s *SessionService) rejectRefreshToken(session *models.Session, presentedHash string) error {
+	superseded, err := s.sessionRepo.IsSupersededToken(session.SessionID, presentedHash)
+	if err != nil {
+		return fmt.Errorf("refresh token tarixini tekshirishda xatolik: %w", err)
+	}
+	if !superseded {
+		return ErrInvalidRefreshToken
+	}
+
+	log.Printf("⚠️ Refresh token qayta ishlatildi: SessionID=%s, TelegramID=%d", session.SessionID, session.TelegramID)
+	if err := s.sessionRepo.Revoke(session.SessionID, "refresh token qayta ishlatildi"); err != nil {
+		return fmt.Errorf("sessiyani bekor qilishda xatolik: %w", err)
+	}
+	return ErrRefreshTokenReused
+}
+
+// Logout joriy sessiyani bekor qiladi
+func (s *SessionService) Logout(sessionID string) error {
+	if err := s.sessionRepo.Revoke(sessionID, "logout"); err != nil {
+		return fmt.Errorf("sessiyani bekor qilishda xatolik: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllForUser foydalanuvchining barcha sessiyalarini bekor qiladi (admin funksiyasi)
+func (s *SessionService) RevokeAllForUser(telegramID int64, reason string) (int, error) {
+	count, err := s.sessionRepo.RevokeAllByTelegramID(telegramID, reason)
+	if err != nil {
+		return 0, fmt.Errorf("sessiyalarni bekor qilishda xatolik: %w", err)
+	}
+	return count, nil
+}
+
+// IsSessionActive middleware.SessionChecker interfeysini amalga oshiradi
+func (s *SessionService) IsSessionActive(sessionID string) (bool, error) {
+	return s.sessionRepo.IsActive(sessionID)
+}
+
+func (s *SessionService) issueTokens(user *models.User, sessionID, secret string, refreshExpiresAt time.Time) (*models.LoginResponse, error) {
+	permissions, err := s.permissionRepo.GetPermissionsByRole(user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("rol ruxsatlarini olishda xatolik: %w", err)
+	}
+
+	token, tokenExpiresAt, err := jwt_auth.GenerateToken(user.TelegramID, user.Role, sessionID, permissions)
+	if err != nil {
+		return nil, fmt.Errorf("token yaratishda xatolik: %w", err)
+	}
+
+	return &models.LoginResponse{
+		Token:            token,
+		TokenExpiresAt:   tokenExpiresAt,
+		RefreshToken:     sessionID + "." + secret,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// randomHex n baytlik kriptografik tasodifiy qiymatni hex ko'rinishida qaytaradi
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func tokenHashEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"amur/models"
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// QR kod rasmi o'lchami chegaralari (piksel)
+const (
+	DefaultQRCodeSize = 512
+	MinQRCodeSize     = 128
+	MaxQRCodeSize     = 2048
+)
+
+// QR kod formatlari
+const (
+	QRFormatPNG = "png"
+	QRFormatSVG = "svg"
+	QRFormatPDF = "pdf"
+	QRFormatZIP = "zip"
+)
+
+var (
+	ErrMiniAppURLNotConfigured = errors.New("Mini App havolasi sozlanmagan (MINI_APP_URL)")
+	ErrInvalidQRFormat         = errors.New("noto'g'ri QR kod formati")
+	ErrInvalidQRSize           = fmt.Errorf("QR kod o'lchami %d dan %d pikselgacha bo'lishi kerak", MinQRCodeSize, MaxQRCodeSize)
+	ErrHallHasNoTables         = errors.New("zalda faol stollar yo'q")
+)
+
+// QRFile tayyor QR kod fayli (rasm yoki chop etish uchun varaq)
+type QRFile struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// SetMiniAppURL QR kodlarga yoziladigan Mini App havolasini o'rnatadi (masalan, https://t.me/amur_bot/menu)
+func (s *TableService) SetMiniAppURL(miniAppURL string) {
+	s.miniAppURL = strings.TrimSpace(miniAppURL)
+}
+
+// TableLink stol tokeni bilan Mini App deep linkini qaytaradi: <MINI_APP_URL>?startapp=<token>
+func (s *TableService) TableLink(token string) (string, error) {
+	if s.miniAppURL == "" {
+		return "", ErrMiniAppURLNotConfigured
+	}
+	link, err := url.Parse(s.miniAppURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrMiniAppURLNotConfigured, err)
+	}
+	query := link.Query()
+	query.Set("startapp", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+// TableQRCode bitta stol uchun PNG yoki SVG QR kodini yaratadi (size faqat PNG uchun, 0 - standart)
+func (s *TableService) TableQRCode(tableID int, format string, size int) (*QRFile, error) {
+	if size == 0 {
+		size = DefaultQRCodeSize
+	}
+	if size < MinQRCodeSize || size > MaxQRCodeSize {
+		return nil, ErrInvalidQRSize
+	}
+	if format == "" {
+		format = QRFormatPNG
+	}
+
+	table, err := s.GetTable(tableID)
+	if err != nil {
+		return nil, err
+	}
+	code, err := s.tableQRCode(table)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case QRFormatPNG:
+		data, err := code.PNG(size)
+		if err != nil {
+			return nil, fmt.Errorf("QR kod rasmini yaratishda xatolik: %w", err)
+		}
+		return &QRFile{Filename: qrFilename(table.TableName) + ".png", ContentType: "image/png", Data: data}, nil
+	case QRFormatSVG:
+		return &QRFile{Filename: qrFilename(table.TableName) + ".svg", ContentType: "image/svg+xml", Data: qrSVG(code, "")}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s (png, svg)", ErrInvalidQRFormat, format)
+	}
+}
+
+// HallQRSheet zaldagi barcha faol stollar QR kodlarini chop etish uchun PDF varaq yoki ZIP arxiv qilib beradi.
+// Har bir QR kod ostida stol nomi yoziladi.
+func (s *TableService) HallQRSheet(hallID int, format string) (*QRFile, error) {
+	if format == "" {
+		format = QRFormatPDF
+	}
+	if format != QRFormatPDF && format != QRFormatZIP {
+		return nil, fmt.Errorf("%w: %s (pdf, zip)", ErrInvalidQRFormat, format)
+	}
+
+	hall, err := s.tableRepo.GetHallByID(hallID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: id=%d", ErrHallNotFound, hallID)
+		}
+		return nil, fmt.Errorf("zalni olishda xatolik: %w", err)
+	}
+	tables, err := s.GetTables(&hallID, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrHallHasNoTables, hall.HallName)
+	}
+
+	codes := make([]*qrcode.QRCode, len(tables))
+	for i := range tables {
+		if codes[i], err = s.tableQRCode(&tables[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if format == QRFormatZIP {
+		data, err := qrZIP(tables, codes)
+		if err != nil {
+			return nil, err
+		}
+		return &QRFile{Filename: qrFilename(hall.HallName) + ".zip", ContentType: "application/zip", Data: data}, nil
+	}
+	data, err := qrPDF(hall.HallName, tables, codes)
+	if err != nil {
+		return nil, err
+	}
+	return &QRFile{Filename: qrFilename(hall.HallName) + ".pdf", ContentType: "application/pdf", Data: data}, nil
+}
+
+// tableQRCode stol havolasini QR kodga aylantiradi (o'rtacha xatoni tuzatish darajasi - chop etilgan stikerlar uchun yetarli)
+func (s *TableService) tableQRCode(table *models.Table) (*qrcode.QRCode, error) {
+	link, err := s.TableLink(table.QRCodeToken)
+	if err != nil {
+		return nil, err
+	}
+	code, err := qrcode.New(link, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' stoli uchun QR kod yaratishda xatolik: %w", table.TableName, err)
+	}
+	return code, nil
+}
+
+// qrFilenameUnsafe fayl nomida ishlatib bo'lmaydigan belgilar
+var qrFilenameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// qrFilename stol yoki zal nomidan xavfsiz fayl nomi yasaydi: "Zal-1 Stol-3" -> "Zal-1_Stol-3"
+func qrFilename(name string) string {
+	name = strings.Trim(qrFilenameUnsafe.ReplaceAllString(name, "_"), "_")
+	if name == "" {
+		return "qr"
+	}
+	return name
+}
+
+// qrSVG QR kodni vektor (SVG) ko'rinishida chizadi; label bo'sh bo'lmasa kod ostiga yoziladi
+func qrSVG(code *qrcode.QRCode, label string) []byte {
+	bitmap := code.Bitmap() // Atrofidagi oq hoshiya bilan birga
+	size := len(bitmap)
+	height := size
+	if label != "" {
+		height += 6
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, height)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, size, height)
+	for y, row := range bitmap {
+		for x, black := range row {
+			if black {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	buf.WriteString(`"/>`)
+	if label != "" {
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" font-family="sans-serif" font-size="4" text-anchor="middle">%s</text>`,
+			size/2, size+3, html.EscapeString(label))
+	}
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
+// qrZIP har bir stol uchun PNG va nomi yozilgan SVG fayllardan ZIP arxiv yasaydi
+func qrZIP(tables []models.Table, codes []*qrcode.QRCode) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	used := make(map[string]bool, len(tables))
+	for i, table := range tables {
+		png, err := codes[i].PNG(DefaultQRCodeSize)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' stoli QR rasmini yaratishda xatolik: %w", table.TableName, err)
+		}
+		name := qrFilename(table.TableName)
+		if used[name] { // Turli nomlar bir xil fayl nomiga aylanib qolsa
+			name = fmt.Sprintf("%s-%d", name, table.TableID)
+		}
+		used[name] = true
+		files := []struct {
+			filename string
+			data     []byte
+		}{
+			{name + ".png", png},
+			{name + ".svg", qrSVG(codes[i], table.TableName)},
+		}
+		for _, file := range files {
+			writer, err := archive.Create(file.filename)
+			if err != nil {
+				return nil, fmt.Errorf("ZIP arxivga yozishda xatolik: %w", err)
+			}
+			if _, err := writer.Write(file.data); err != nil {
+				return nil, fmt.Errorf("ZIP arxivga yozishda xatolik: %w", err)
+			}
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("ZIP arxivni yopishda xatolik: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// PDF varaqdagi QR kodlar joylashuvi (A4, millimetrda): 3 ustun x 4 qator
+const (
+	qrSheetColumns = 3
+	qrSheetRows    = 4
+	qrSheetMargin  = 12.0
+	qrSheetHeader  = 14.0
+	qrCodeMM       = 50.0
+	qrLabelMM      = 8.0
+)
+
+// qrPDF zal stollarining QR kodlarini A4 varaqlarga nomlari bilan joylashtiradi (kesish uchun har bir katak ramkada)
+func qrPDF(hallName string, tables []models.Table, codes []*qrcode.QRCode) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(hallName+" - QR kodlar", true)
+	pdf.SetAutoPageBreak(false, 0)
+	translate := pdf.UnicodeTranslatorFromDescriptor("") // cp1252: o'zbekcha apostrof va h.k. uchun
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	cellWidth := (pageWidth - 2*qrSheetMargin) / qrSheetColumns
+	cellHeight := (pageHeight - 2*qrSheetMargin - qrSheetHeader) / qrSheetRows
+	perPage := qrSheetColumns * qrSheetRows
+
+	for i, table := range tables {
+		if i%perPage == 0 {
+			pdf.AddPage()
+			pdf.SetFont("Helvetica", "B", 16)
+			pdf.SetXY(qrSheetMargin, qrSheetMargin)
+			pdf.CellFormat(pageWidth-2*qrSheetMargin, qrSheetHeader-4, translate(hallName), "", 0, "C", false, 0, "")
+		}
+
+		png, err := codes[i].PNG(DefaultQRCodeSize)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' stoli QR rasmini yaratishda xatolik: %w", table.TableName, err)
+		}
+		imageName := fmt.Sprintf("qr-%d", table.TableID)
+		pdf.RegisterImageOptionsReader(imageName, gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+
+		position := i % perPage
+		x := qrSheetMargin + float64(position%qrSheetColumns)*cellWidth
+		y := qrSheetMargin + qrSheetHeader + float64(position/qrSheetColumns)*cellHeight
+
+		pdf.SetDrawColor(200, 200, 200)
+		pdf.Rect(x, y, cellWidth, cellHeight, "D")
+		pdf.ImageOptions(imageName, x+(cellWidth-qrCodeMM)/2, y+4, qrCodeMM, qrCodeMM, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+		pdf.SetFont("Helvetica", "B", 13)
+		pdf.SetXY(x, y+4+qrCodeMM+1)
+		pdf.CellFormat(cellWidth, qrLabelMM, translate(table.TableName), "", 0, "C", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("PDF yaratishda xatolik: %w", err)
+	}
+	return buf.Bytes(), nil
+}
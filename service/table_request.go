@@ -0,0 +1,131 @@
+package service
+
+import (
+	"amur/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// TableRequestAckCallbackPrefix xodimlar chatidagi "Boryapman" tugmasi callback ma'lumoti prefiksi: "tblreq_ack:<request_id>"
+const TableRequestAckCallbackPrefix = "tblreq_ack:"
+
+var (
+	ErrInvalidTableRequestType  = fmt.Errorf("chaqiruv turi '%s' yoki '%s' bo'lishi kerak", models.TableRequestCallWaiter, models.TableRequestBringBill)
+	ErrTableRequestNotFound     = errors.New("stol chaqiruvi topilmadi")
+	ErrTableRequestAcknowledged = errors.New("stol chaqiruvi allaqachon qabul qilingan")
+)
+
+// SetStaffNotifier bot ishga tushgandan keyin stol chaqiruvlarini xodimlar chatiga yuboruvchini o'rnatadi (chatID 0 bo'lsa yuborilmaydi)
+func (s *TableService) SetStaffNotifier(notifier ActionNotifier, chatID int64) {
+	s.notifier = notifier
+	s.staffChatID = chatID
+}
+
+// CreateTableRequest mehmon stol QR havolasidan ofitsiantni yoki hisobni chaqiradi (buyurtma berish shart emas).
+// Shu stoldan shu turdagi chaqiruv hali qabul qilinmagan bo'lsa yangisi yaratilmaydi va xodimlarga qayta yuborilmaydi.
+func (s *TableService) CreateTableRequest(token, requestType string, telegramID int64) (*models.TableRequestResponse, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, ErrTableTokenRequired
+	}
+	if requestType != models.TableRequestCallWaiter && requestType != models.TableRequestBringBill {
+		return nil, ErrInvalidTableRequestType
+	}
+
+	table, err := resolveTable(s.tableRepo, token)
+	if err != nil {
+		return nil, err
+	}
+	request, created, err := s.tableRepo.CreateOrTouchRequest(table.TableID, requestType, telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("stol chaqiruvini saqlashda xatolik: %w", err)
+	}
+
+	if created {
+		s.notifyStaffTableRequest(request)
+	}
+	return &models.TableRequestResponse{Request: *request, Duplicate: !created}, nil
+}
+
+// GetPendingTableRequests ofitsiantlar uchun hali qabul qilinmagan chaqiruvlar
+func (s *TableService) GetPendingTableRequests() ([]models.TableRequest, error) {
+	requests, err := s.tableRepo.GetPendingRequests()
+	if err != nil {
+		return nil, fmt.Errorf("stol chaqiruvlarini olishda xatolik: %w", err)
+	}
+	return requests, nil
+}
+
+// AcknowledgeTableRequest xodim chaqiruvni qabul qiladi ("Boryapman"); xodimlar chatidagi xabardan tugma olib tashlanadi.
+// Shundan keyin mehmon yana tugmani bossa yangi chaqiruv yaratiladi.
+func (s *TableService) AcknowledgeTableRequest(requestID int, actorID int64) (*models.TableRequest, error) {
+	if err := s.tableRepo.AcknowledgeRequest(requestID, actorID); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("stol chaqiruvini qabul qilishda xatolik: %w", err)
+		}
+		if _, err := s.tableRepo.GetRequestByID(requestID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("%w: id=%d", ErrTableRequestNotFound, requestID)
+			}
+			return nil, fmt.Errorf("stol chaqiruvini olishda xatolik: %w", err)
+		}
+		return nil, fmt.Errorf("%w: id=%d", ErrTableRequestAcknowledged, requestID)
+	}
+
+	request, err := s.tableRepo.GetRequestByID(requestID)
+	if err != nil {
+		return nil, fmt.Errorf("stol chaqiruvini olishda xatolik: %w", err)
+	}
+	s.updateStaffTableRequest(request)
+	return request, nil
+}
+
+// tableRequestText xodimlar chatidagi chaqiruv xabari matni
+func tableRequestText(request *models.TableRequest) string {
+	title := "🔔 Ofitsiant chaqirilmoqda"
+	if request.RequestType == models.TableRequestBringBill {
+		title = "💳 Hisob so'ralmoqda"
+	}
+	place := request.TableName
+	if request.HallName != nil {
+		place = *request.HallName + " / " + request.TableName
+	}
+	text := fmt.Sprintf("%s\n🪑 Stol: %s", title, place)
+	if request.SessionID != nil {
+		text += fmt.Sprintf("\n🧾 Stol hisobi: #%d", *request.SessionID)
+	}
+	return text
+}
+
+// notifyStaffTableRequest yangi chaqiruvni "Boryapman" tugmasi bilan xodimlar chatiga yuboradi.
+// Xabar yuborilmasa ham chaqiruv saqlanib qoladi va GET /api/admin/table-requests orqali ko'rinadi, xato faqat logga yoziladi.
+func (s *TableService) notifyStaffTableRequest(request *models.TableRequest) {
+	if s.notifier == nil || s.staffChatID == 0 {
+		return
+	}
+	messageID, err := s.notifier.SendMessageWithButton(s.staffChatID, tableRequestText(request),
+		"🏃 Boryapman", fmt.Sprintf("%s%d", TableRequestAckCallbackPrefix, request.RequestID))
+	if err != nil {
+		log.Printf("Stol chaqiruvini xodimlarga yuborishda xatolik (RequestID: %d): %v", request.RequestID, err)
+		return
+	}
+	if err := s.tableRepo.SetRequestMessage(request.RequestID, messageID); err != nil {
+		log.Printf("Stol chaqiruvi xabar ID sini saqlashda xatolik (RequestID: %d): %v", request.RequestID, err)
+	}
+}
+
+// updateStaffTableRequest qabul qilingan chaqiruv xabarini tugmasiz holatga o'zgartiradi
+func (s *TableService) updateStaffTableRequest(request *models.TableRequest) {
+	if s.notifier == nil || s.staffChatID == 0 || request.StaffMessageID == nil {
+		return
+	}
+	text := tableRequestText(request)
+	if request.AcknowledgedBy != nil {
+		text += fmt.Sprintf("\n✅ Qabul qildi: xodim ID %d", *request.AcknowledgedBy)
+	}
+	if err := s.notifier.EditMessage(s.staffChatID, *request.StaffMessageID, text); err != nil {
+		log.Printf("Stol chaqiruvi xabarini yangilashda xatolik (RequestID: %d): %v", request.RequestID, err)
+	}
+}
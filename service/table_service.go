@@ -0,0 +1,367 @@
+package service
+
+import (
+	"amur/models"
+	"amur/repository"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Stol sig'imi chegaralari
+const (
+	DefaultTableCapacity = 4
+	MaxTableCapacity     = 100
+)
+
+var (
+	ErrHallNotFound      = errors.New("zal topilmadi")
+	ErrHallNameRequired  = errors.New("zal nomi majburiy")
+	ErrHallNameTaken     = errors.New("bu nomli zal allaqachon mavjud")
+	ErrTableNotFound     = errors.New("stol topilmadi")
+	ErrTableNameRequired = errors.New("stol nomi majburiy")
+	ErrTableNameTaken    = errors.New("bu nomli stol allaqachon mavjud")
+	ErrInvalidCapacity   = fmt.Errorf("stol sig'imi 1 dan %d gacha bo'lishi kerak", MaxTableCapacity)
+	ErrTableHasOrders    = errors.New("stolga buyurtmalar bog'langan, uni o'chirish o'rniga nofaol qiling")
+)
+
+type TableService struct {
+	uow        *repository.UnitOfWork
+	tableRepo  *repository.TableRepository
+	miniAppURL string // QR kodlarga yoziladigan Mini App havolasi
+
+	notifier    ActionNotifier // Stol chaqiruvlarini xodimlar chatiga yuborish uchun (nil bo'lishi mumkin)
+	staffChatID int64
+}
+
+func NewTableService(uow *repository.UnitOfWork, tableRepo *repository.TableRepository) *TableService {
+	return &TableService{uow: uow, tableRepo: tableRepo}
+}
+
+// newTableToken stol uchun yangi QR token yaratadi (table.json dagi kabi 32 belgili hex)
+func newTableToken() (string, error) {
+	token, err := randomHex(16)
+	if err != nil {
+		return "", fmt.Errorf("QR token yaratishda xatolik: %w", err)
+	}
+	return token, nil
+}
+
+// --- Zallar ---
+
+// GetHalls barcha zallarni qaytaradi
+func (s *TableService) GetHalls() ([]models.Hall, error) {
+	halls, err := s.tableRepo.GetHalls()
+	if err != nil {
+		return nil, fmt.Errorf("zallarni olishda xatolik: %w", err)
+	}
+	return halls, nil
+}
+
+// checkHallName zal nomini tozalaydi va boshqa zalda band emasligini tekshiradi (exceptID - o'zgartirilayotgan zal)
+func (s *TableService) checkHallName(hallName string, exceptID int) (string, error) {
+	hallName = strings.TrimSpace(hallName)
+	if hallName == "" {
+		return "", ErrHallNameRequired
+	}
+	existing, err := s.tableRepo.GetHallByName(hallName)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("zal nomini tekshirishda xatolik: %w", err)
+	}
+	if existing != nil && existing.HallID != exceptID {
+		return "", ErrHallNameTaken
+	}
+	return hallName, nil
+}
+
+// CreateHall yangi zal yaratadi
+func (s *TableService) CreateHall(req *models.HallRequest) (*models.Hall, error) {
+	hallName, err := s.checkHallName(req.HallName, 0)
+	if err != nil {
+		return nil, err
+	}
+	hall, err := s.tableRepo.CreateHall(hallName)
+	if err != nil {
+		return nil, fmt.Errorf("zalni yaratishda xatolik: %w", err)
+	}
+	log.Printf("✅ Yangi zal yaratildi: %s (ID: %d)", hall.HallName, hall.HallID)
+	return hall, nil
+}
+
+// RenameHall zal nomini o'zgartiradi
+func (s *TableService) RenameHall(hallID int, req *models.HallRequest) (*models.Hall, error) {
+	hallName, err := s.checkHallName(req.HallName, hallID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tableRepo.RenameHall(hallID, hallName); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: id=%d", ErrHallNotFound, hallID)
+		}
+		return nil, fmt.Errorf("zal nomini o'zgartirishda xatolik: %w", err)
+	}
+	return s.tableRepo.GetHallByID(hallID)
+}
+
+// DeleteHall zalni o'chiradi; uning stollari o'chirilmaydi, zalsiz qoladi
+func (s *TableService) DeleteHall(hallID int) error {
+	if err := s.tableRepo.DeleteHall(hallID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: id=%d", ErrHallNotFound, hallID)
+		}
+		return fmt.Errorf("zalni o'chirishda xatolik: %w", err)
+	}
+	log.Printf("🗑️ Zal o'chirildi: ID=%d", hallID)
+	return nil
+}
+
+// --- Stollar ---
+
+// GetTables stollarni qaytaradi (hallID berilsa faqat shu zal stollari)
+func (s *TableService) GetTables(hallID *int, includeInactive bool) ([]models.Table, error) {
+	tables, err := s.tableRepo.GetTables(hallID, includeInactive)
+	if err != nil {
+		return nil, fmt.Errorf("stollarni olishda xatolik: %w", err)
+	}
+	return tables, nil
+}
+
+// GetTable stolni ID bo'yicha qaytaradi
+func (s *TableService) GetTable(tableID int) (*models.Table, error) {
+	table, err := s.tableRepo.GetTableByID(tableID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: id=%d", ErrTableNotFound, tableID)
+		}
+		return nil, fmt.Errorf("stolni olishda xatolik: %w", err)
+	}
+	return table, nil
+}
+
+// checkTableName stol nomini tozalaydi va boshqa stolda band emasligini tekshiradi (exceptID - o'zgartirilayotgan stol)
+func (s *TableService) checkTableName(tableName string, exceptID int) (string, error) {
+	tableName = strings.TrimSpace(tableName)
+	if tableName == "" {
+		return "", ErrTableNameRequired
+	}
+	existing, err := s.tableRepo.GetTableByName(tableName)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("stol nomini tekshirishda xatolik: %w", err)
+	}
+	if existing != nil && existing.TableID != exceptID {
+		return "", ErrTableNameTaken
+	}
+	return tableName, nil
+}
+
+// checkHall zal mavjudligini tekshiradi (nil - stol zalsiz)
+func (s *TableService) checkHall(hallID *int) error {
+	if hallID == nil {
+		return nil
+	}
+	if _, err := s.tableRepo.GetHallByID(*hallID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: id=%d", ErrHallNotFound, *hallID)
+		}
+		return fmt.Errorf("zalni olishda xatolik: %w", err)
+	}
+	return nil
+}
+
+// CreateTable yangi stol yaratadi va unga QR token beradi
+func (s *TableService) CreateTable(req *models.CreateTableRequest) (*models.Table, error) {
+	tableName, err := s.checkTableName(req.TableName, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkHall(req.HallID); err != nil {
+		return nil, err
+	}
+	capacity := req.Capacity
+	if capacity == 0 {
+		capacity = DefaultTableCapacity
+	}
+	if capacity < 1 || capacity > MaxTableCapacity {
+		return nil, ErrInvalidCapacity
+	}
+	token, err := newTableToken()
+	if err != nil {
+		return nil, err
+	}
+
+	table := &models.Table{
+		TableName:   tableName,
+		QRCodeToken: token,
+		HallID:      req.HallID,
+		Capacity:    capacity,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+	}
+	if _, err := s.tableRepo.CreateTable(table); err != nil {
+		return nil, fmt.Errorf("stolni yaratishda xatolik: %w", err)
+	}
+	log.Printf("✅ Yangi stol yaratildi: %s (ID: %d)", table.TableName, table.TableID)
+	return s.GetTable(table.TableID)
+}
+
+// UpdateTable stol nomi, zali, sig'imi yoki faolligini o'zgartiradi (faqat yuborilgan maydonlar)
+func (s *TableService) UpdateTable(tableID int, req *models.UpdateTableRequest) (*models.Table, error) {
+	table, err := s.GetTable(tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TableName != nil {
+		if table.TableName, err = s.checkTableName(*req.TableName, tableID); err != nil {
+			return nil, err
+		}
+	}
+	if req.RemoveHall {
+		table.HallID = nil
+	} else if req.HallID != nil {
+		if err := s.checkHall(req.HallID); err != nil {
+			return nil, err
+		}
+		table.HallID = req.HallID
+	}
+	if req.Capacity != nil {
+		if *req.Capacity < 1 || *req.Capacity > MaxTableCapacity {
+			return nil, ErrInvalidCapacity
+		}
+		table.Capacity = *req.Capacity
+	}
+	if req.IsActive != nil {
+		table.IsActive = *req.IsActive
+	}
+
+	if err := s.tableRepo.UpdateTable(table); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: id=%d", ErrTableNotFound, tableID)
+		}
+		return nil, fmt.Errorf("stolni yangilashda xatolik: %w", err)
+	}
+	return s.GetTable(tableID)
+}
+
+// RegenerateToken stolga yangi QR token beradi. Eski QR kod bilan endi buyurtma berib bo'lmaydi.
+func (s *TableService) RegenerateToken(tableID int) (*models.Table, error) {
+	token, err := newTableToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tableRepo.UpdateToken(tableID, token); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: id=%d", ErrTableNotFound, tableID)
+		}
+		return nil, fmt.Errorf("QR tokenni yangilashda xatolik: %w", err)
+	}
+	log.Printf("🔄 Stol QR tokeni yangilandi: ID=%d", tableID)
+	return s.GetTable(tableID)
+}
+
+// DeleteTable stolni o'chiradi. Buyurtmalari bor stol o'chirilmaydi (buyurtma tarixida stol saqlanib qolishi uchun).
+func (s *TableService) DeleteTable(tableID int) error {
+	return s.uow.Do(func(tx *sql.Tx) error {
+		tableRepo := s.tableRepo.WithTx(tx)
+
+		orders, err := tableRepo.CountTableOrders(tableID)
+		if err != nil {
+			return fmt.Errorf("stol buyurtmalarini tekshirishda xatolik: %w", err)
+		}
+		if orders > 0 {
+			return ErrTableHasOrders
+		}
+		if err := tableRepo.DeleteTable(tableID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: id=%d", ErrTableNotFound, tableID)
+			}
+			return fmt.Errorf("stolni o'chirishda xatolik: %w", err)
+		}
+		log.Printf("🗑️ Stol o'chirildi: ID=%d", tableID)
+		return nil
+	})
+}
+
+// hallNameFromTable stol nomidan zal nomini ajratadi: "Zal-1 Stol-3" -> "Zal-1", "Terassa-2" -> "Terassa"
+func hallNameFromTable(tableName string) string {
+	tableName = strings.TrimSpace(tableName)
+	if i := strings.LastIndex(tableName, " "); i > 0 {
+		return strings.TrimSpace(tableName[:i])
+	}
+	if i := strings.LastIndex(tableName, "-"); i > 0 {
+		return tableName[:i]
+	}
+	return tableName
+}
+
+// ImportFromFile table.json formatidagi fayldan ({"stol nomi": "token"}) stollarni bir tranzaksiyada yuklaydi.
+// Zallar stol nomidan aniqlanadi; bazada shu nomli stol bo'lsa u o'zgartirilmaydi (tokeni ham), shuning uchun qayta ishga tushirish xavfsiz.
+func (s *TableService) ImportFromFile(filename string) (*models.TableImportResult, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s faylini o'qishda xatolik: %w", filename, err)
+	}
+	var tokens map[string]string
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("%s faylini o'qishda xatolik: %w", filename, err)
+	}
+
+	names := make([]string, 0, len(tokens))
+	for name := range tokens {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := &models.TableImportResult{}
+	err = s.uow.Do(func(tx *sql.Tx) error {
+		tableRepo := s.tableRepo.WithTx(tx)
+		hallIDs := make(map[string]int)
+		for _, name := range names {
+			tableName := strings.TrimSpace(name)
+			token := strings.TrimSpace(tokens[name])
+			if tableName == "" || token == "" {
+				return fmt.Errorf("%w: %q", ErrTableNameRequired, name)
+			}
+
+			_, err := tableRepo.GetTableByName(tableName)
+			if err == nil {
+				result.Skipped++
+				continue
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("'%s' stolini tekshirishda xatolik: %w", tableName, err)
+			}
+
+			hallName := hallNameFromTable(tableName)
+			hallID, ok := hallIDs[hallName]
+			if !ok {
+				if hallID, err = tableRepo.EnsureHall(hallName); err != nil {
+					return fmt.Errorf("'%s' zalini yaratishda xatolik: %w", hallName, err)
+				}
+				hallIDs[hallName] = hallID
+			}
+			table := &models.Table{
+				TableName:   tableName,
+				QRCodeToken: token,
+				HallID:      &hallID,
+				Capacity:    DefaultTableCapacity,
+				IsActive:    true,
+			}
+			if _, err := tableRepo.CreateTable(table); err != nil {
+				return fmt.Errorf("'%s' stolini yozishda xatolik: %w", tableName, err)
+			}
+			result.Created++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ %s: %d ta stol yuklandi, %d tasi allaqachon mavjud", filename, result.Created, result.Skipped)
+	return result, nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"amur/models"
+	"amur/repository"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrTableSessionNotFound        = errors.New("stol hisobi topilmadi")
+	ErrTableSessionClosed          = errors.New("stol hisobi allaqachon yopilgan")
+	ErrTableSessionHasActiveOrders = errors.New("stolda hali yakunlanmagan buyurtmalar bor, hisobni yopib bo'lmaydi")
+)
+
+// resolveTable QR token bo'yicha buyurtma qabul qiladigan (faol) stolni topadi
+func resolveTable(tableRepo *repository.TableRepository, token string) (*models.Table, error) {
+	table, err := tableRepo.GetByToken(strings.TrimSpace(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUnknownTable
+		}
+		return nil, fmt.Errorf("stolni olishda xatolik: %w", err)
+	}
+	if !table.IsActive {
+		return nil, ErrTableInactive
+	}
+	return table, nil
+}
+
+// canManageTableSessions foydalanuvchi barcha stol hisoblarini ko'rib, yopa olishini tekshiradi (ofitsiant va boshqaruv)
+func canManageTableSessions(principal *models.Principal) bool {
+	return principal.HasPermission(models.PermTableSessionsManage) || principal.HasPermission(models.PermOrdersReadAll)
+}
+
+// ScanTable mehmon stol QR kodini skanerlaganda chaqiriladi: stolning ochiq hisobini qaytaradi, bo'lmasa yangisini ochadi.
+// QR token stolda o'tirganlikning isboti, shuning uchun skanerlagan har bir mehmon umumiy hisobni ko'radi.
+func (s *OrderService) ScanTable(token string, telegramID int64) (*models.TableSessionBill, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, ErrTableTokenRequired
+	}
+
+	var session *models.TableSession
+	err := s.uow.Do(func(tx *sql.Tx) error {
+		tableRepo := s.tableRepo.WithTx(tx)
+		table, err := resolveTable(tableRepo, token)
+		if err != nil {
+			return err
+		}
+		session, _, err = tableRepo.OpenSession(table.TableID, telegramID)
+		if err != nil {
+			return fmt.Errorf("stol hisobini ochishda xatolik: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.buildTableSessionBill(session)
+}
+
+// GetTableSessionBill stol hisobini qaytaradi. Hisobni ofitsiantlar, sessiyani ochgan mehmon va
+// unga buyurtma bergan mehmonlar ko'radi; boshqalar uchun hisob "topilmadi".
+func (s *OrderService) GetTableSessionBill(sessionID int, viewer *models.Principal) (*models.TableSessionBill, error) {
+	session, err := s.tableRepo.GetSessionByID(sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: id=%d", ErrTableSessionNotFound, sessionID)
+		}
+		return nil, fmt.Errorf("stol hisobini olishda xatolik: %w", err)
+	}
+
+	bill, err := s.buildTableSessionBill(session)
+	if err != nil {
+		return nil, err
+	}
+	if canManageTableSessions(viewer) || (session.OpenedBy != nil && *session.OpenedBy == viewer.TelegramID) {
+		return bill, nil
+	}
+	for _, details := range bill.Orders {
+		if details.Order.TelegramID == viewer.TelegramID {
+			return bill, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: id=%d", ErrTableSessionNotFound, sessionID)
+}
+
+// GetOpenTableSessions ofitsiantlar uchun barcha ochiq stol hisoblari
+func (s *OrderService) GetOpenTableSessions() ([]models.TableSession, error) {
+	sessions, err := s.tableRepo.GetOpenSessions()
+	if err != nil {
+		return nil, fmt.Errorf("ochiq stol hisoblarini olishda xatolik: %w", err)
+	}
+	return sessions, nil
+}
+
+// CloseTableSession ofitsiant stol hisobini yopadi; shundan keyingi buyurtmalar yangi hisobga tushadi.
+// Stolda hali tayyorlanayotgan yoki berilmagan buyurtmalar bo'lsa hisob yopilmaydi.
+func (s *OrderService) CloseTableSession(sessionID int, actor *models.Principal) (*models.TableSessionBill, error) {
+	var session *models.TableSession
+	err := s.uow.Do(func(tx *sql.Tx) error {
+		tableRepo := s.tableRepo.WithTx(tx)
+
+		var err error
+		session, err = tableRepo.GetSessionForUpdate(sessionID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: id=%d", ErrTableSessionNotFound, sessionID)
+			}
+			return fmt.Errorf("stol hisobini olishda xatolik: %w", err)
+		}
+		if !session.IsOpen() {
+			return fmt.Errorf("%w: id=%d", ErrTableSessionClosed, sessionID)
+		}
+
+		orders, err := s.orderRepo.WithTx(tx).GetSessionOrders(sessionID)
+		if err != nil {
+			return fmt.Errorf("stol buyurtmalarini olishda xatolik: %w", err)
+		}
+		for _, order := range orders {
+			if status, ok := models.LookupOrderStatus(order.OrderStatus); !ok || !status.Final {
+				return fmt.Errorf("%w (buyurtma #%d: %s)", ErrTableSessionHasActiveOrders,
+					order.OrderID, models.OrderStatusLabel(order.OrderStatus, models.DefaultLanguage))
+			}
+		}
+
+		if err := tableRepo.CloseSession(sessionID, actor.TelegramID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: id=%d", ErrTableSessionClosed, sessionID)
+			}
+			return fmt.Errorf("stol hisobini yopishda xatolik: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if session, err = s.tableRepo.GetSessionByID(sessionID); err != nil {
+		return nil, fmt.Errorf("stol hisobini olishda xatolik: %w", err)
+	}
+	return s.buildTableSessionBill(session)
+}
+
+// buildTableSessionBill sessiya buyurtmalarini elementlari bilan yig'adi va bekor qilinmaganlari bo'yicha jami summani hisoblaydi
+func (s *OrderService) buildTableSessionBill(session *models.TableSession) (*models.TableSessionBill, error) {
+	orders, err := s.orderRepo.GetSessionOrders(session.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("stol buyurtmalarini olishda xatolik: %w", err)
+	}
+	details, err := s.attachOrderItems(orders)
+	if err != nil {
+		return nil, err
+	}
+
+	bill := &models.TableSessionBill{Session: *session, Orders: details}
+	for _, order := range orders {
+		if order.OrderStatus == models.OrderStatusCancelled {
+			continue
+		}
+		bill.OrderCount++
+		bill.Subtotal += order.Subtotal
+		bill.ServiceCharge += order.ServiceCharge
+		bill.DiscountAmount += order.DiscountAmount
+		bill.TotalPrice += order.TotalPrice
+	}
+	bill.Subtotal = roundMoney(bill.Subtotal)
+	bill.ServiceCharge = roundMoney(bill.ServiceCharge)
+	bill.DiscountAmount = roundMoney(bill.DiscountAmount)
+	bill.TotalPrice = roundMoney(bill.TotalPrice)
+	return bill, nil
+}
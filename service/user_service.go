@@ -2,21 +2,58 @@ package service
 
 import (
 	"amur/models"
-	"amur/pkg/jwt_auth"
+	"amur/pkg/phone"
+	"amur/pkg/telegram_auth"
 	"amur/repository"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
-	// string to int64 conversion for code check
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	LoginCodeLength      = 4               // Kod uzunligi (raqamlar soni)
+	LoginCodeTTL         = 5 * time.Minute // Kod amal qilish muddati
+	loginCodeMaxAttempts = 5               // Bitta kod uchun ruxsat etilgan noto'g'ri urinishlar
+	telegramAuthMaxAge   = 24 * time.Hour  // initData / widget imzosining amal qilish muddati
+)
+
+var (
+	ErrInvalidLoginCode    = errors.New("kod noto'g'ri, iltimos, tekshirib qaytadan urinib ko'ring")
+	ErrLoginCodeExpired    = errors.New("kod eskirgan yoki topilmadi, botda /code buyrug'i orqali yangi kod oling")
+	ErrInvalidTelegramAuth = errors.New("telegram ma'lumotlari tasdiqlanmadi")
+	ErrInvalidPhoneNumber  = errors.New("telefon raqami noto'g'ri formatda")
+	ErrPhoneNumberTaken    = errors.New("bu telefon raqami boshqa foydalanuvchiga biriktirilgan")
 )
 
 type UserService struct {
-	userRepo *repository.UserRepository
+	uow             *repository.UnitOfWork
+	userRepo        *repository.UserRepository
+	loginCodeRepo   *repository.LoginCodeRepository
+	throttleService *LoginThrottleService
+	sessionService  *SessionService
+	botToken        string // Telegram initData / Login Widget imzosini tekshirish uchun
+	loginCodeKey    []byte // Kirish kodlari hashining kaliti (bot tokenidan olinadi)
 }
 
-func NewUserService(userRepo *repository.UserRepository) *UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(uow *repository.UnitOfWork, userRepo *repository.UserRepository, loginCodeRepo *repository.LoginCodeRepository, throttleService *LoginThrottleService, sessionService *SessionService, botToken string) *UserService {
+	return &UserService{
+		uow:             uow,
+		userRepo:        userRepo,
+		loginCodeRepo:   loginCodeRepo,
+		throttleService: throttleService,
+		sessionService:  sessionService,
+		botToken:        botToken,
+		loginCodeKey:    loginCodeKey(botToken),
+	}
 }
 
 // CreateUser yangi foydalanuvchi yaratadi yoki agar mavjud bo'lsa, uni qaytaradi
@@ -30,7 +67,7 @@ func (s *UserService) CreateUser(user *models.User) (*models.User, error) {
 		return nil, fmt.Errorf("foydalanuvchini tekshirishda xatolik: %w", err)
 	}
 
-	user.Role = "user"
+	user.Role = models.RoleCustomer
 	if err := s.userRepo.Create(user); err != nil {
 		return nil, fmt.Errorf("foydalanuvchini yaratishda xatolik: %w", err)
 	}
@@ -68,33 +105,87 @@ func (s *UserService) GetUserStats() (int, error) {
 
 // --- Yangilangan Login funksiyasi ---
 
-// Login foydalanuvchini telefon raqami va kod orqali tizimga kiritadi
-func (s *UserService) Login(phoneNumber, code string) (string, error) {
+// Login foydalanuvchini telefon raqami va kod orqali tizimga kiritadi.
+// client.IP noto'g'ri urinishlarni IP bo'yicha cheklash uchun ishlatiladi.
+func (s *UserService) Login(phoneNumber, code string, client models.ClientInfo) (*models.LoginResponse, error) {
+	// Raqam qanday yozilganidan qat'i nazar (+998..., 998..., bo'shliqlar bilan) bir xil ko'rinishga keltiriladi
+	phoneNumber, err := phone.Normalize(phoneNumber)
+	if err != nil {
+		return nil, ErrInvalidPhoneNumber
+	}
+
+	// 0. Telefon raqami yoki IP bloklanganligini tekshirish
+	if err := s.throttleService.Check(phoneNumber, client.IP); err != nil {
+		return nil, err
+	}
+
 	// 1. Telefon raqami bo'yicha foydalanuvchini topish
-	user, err := s.userRepo.GetByPhoneNumber(phoneNumber) // repository/user_repository.go da GetByPhoneNumber ni yaratish kerak!
+	user, err := s.userRepo.GetByPhoneNumber(phoneNumber)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return "", fmt.Errorf("telefon raqamiga ega foydalanuvchi topilmadi: %w", err)
+			s.throttleService.RegisterFailure(phoneNumber, client.IP, nil)
+			return nil, fmt.Errorf("telefon raqamiga ega foydalanuvchi topilmadi: %w", err)
 		}
-		return "", fmt.Errorf("foydalanuvchini telefon raqami bo'yicha tekshirishda xatolik: %w", err)
+		return nil, fmt.Errorf("foydalanuvchini telefon raqami bo'yicha tekshirishda xatolik: %w", err)
 	}
 
-	// 2. Foydalanuvchining Telegram ID'sidan kodni generatsiya qilish
-	generatedCode := s.GenerateUserCode(user.TelegramID)
-
-	// 3. Foydalanuvchi kiritgan kodni tekshirish
-	if generatedCode != code {
-		return "", errors.New("kod noto'g'ri, iltimos, tekshirib qaytadan urinib ko'ring")
+	// 2. Bot bergan amaldagi kodni tekshirish va ishlatilgan deb belgilash
+	if err := s.verifyLoginCode(user.TelegramID, code); err != nil {
+		if errors.Is(err, ErrInvalidLoginCode) || errors.Is(err, ErrLoginCodeExpired) {
+			s.throttleService.RegisterFailure(phoneNumber, client.IP, user)
+		}
+		return nil, err
 	}
+	s.throttleService.RegisterSuccess(phoneNumber)
 
-	// 4. Tekshiruvdan o'tdi, endi JWT tokenini yaratamiz
-	token, err := jwt_auth.GenerateToken(user.TelegramID, user.Role)
+	// 3. Tekshiruvdan o'tdi, endi sessiya ochib token juftligini yaratamiz
+	tokens, err := s.sessionService.CreateSession(user, client)
 	if err != nil {
-		return "", fmt.Errorf("token yaratishda xatolik: %w", err)
+		return nil, err
 	}
 
 	log.Printf("Foydalanuvchi tizimga kirdi, Telegram ID: %d", user.TelegramID)
-	return token, nil
+	return tokens, nil
+}
+
+// LoginWithTelegram Telegram Mini App initData yoki Login Widget ma'lumotlarini bot tokeni bilan tekshiradi,
+// foydalanuvchini saqlaydi/yangilaydi va Login bilan bir xil token juftligini qaytaradi.
+func (s *UserService) LoginWithTelegram(req *models.TelegramAuthRequest, client models.ClientInfo) (*models.LoginResponse, error) {
+	var tgUser *telegram_auth.User
+	var err error
+	switch {
+	case req.InitData != "":
+		tgUser, err = telegram_auth.ValidateWebAppInitData(req.InitData, s.botToken, telegramAuthMaxAge)
+	case len(req.Widget) > 0:
+		var fields map[string]string
+		if fields, err = telegram_auth.WidgetFields(req.Widget); err == nil {
+			tgUser, err = telegram_auth.ValidateLoginWidget(fields, s.botToken, telegramAuthMaxAge)
+		}
+	default:
+		return nil, fmt.Errorf("%w: init_data yoki widget maydoni majburiy", ErrInvalidTelegramAuth)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTelegramAuth, err)
+	}
+
+	firstName := strings.TrimSpace(tgUser.FirstName + " " + tgUser.LastName)
+	user, err := s.SaveOrUpdateUser(&models.User{
+		TelegramID:   tgUser.ID,
+		FirstName:    firstName,
+		Username:     tgUser.Username,
+		LanguageCode: tgUser.LanguageCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.sessionService.CreateSession(user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Foydalanuvchi Telegram orqali tizimga kirdi, Telegram ID: %d", user.TelegramID)
+	return tokens, nil
 }
 
 // validateAndCleanUser foydalanuvchi ma'lumotlarini tozalaydi
@@ -110,18 +201,56 @@ func (s *UserService) validateAndCleanUser(user *models.User) {
 	}
 }
 
-// SaveOrUpdateUser funksiyasi CreateUser va UpdateUser ni o'z ichiga oladi
+// SaveOrUpdateUser funksiyasi CreateUser va UpdateUser ni o'z ichiga oladi.
+// Telefon raqami E.164 ga keltiriladi; raqam boshqa Telegram hisobiga tegishli bo'lsa ErrPhoneNumberTaken qaytadi.
 func (s *UserService) SaveOrUpdateUser(user *models.User) (*models.User, error) {
+	return s.saveUser(s.userRepo, user)
+}
+
+// saveUser SaveOrUpdateUser mantig'ini berilgan repository (tranzaksiya ichidagi ham bo'lishi mumkin) orqali bajaradi
+func (s *UserService) saveUser(userRepo *repository.UserRepository, user *models.User) (*models.User, error) {
 	s.validateAndCleanUser(user)
 
-	if s.userRepo.Exists(user.TelegramID) {
-		if err := s.userRepo.Update(user); err != nil {
+	if user.PhoneNumber != "" {
+		normalized, err := phone.Normalize(user.PhoneNumber)
+		if err != nil {
+			return nil, ErrInvalidPhoneNumber
+		}
+		user.PhoneNumber = normalized
+
+		owner, err := userRepo.GetByPhoneNumber(normalized)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("telefon raqamini tekshirishda xatolik: %w", err)
+		}
+		if owner != nil && owner.TelegramID != user.TelegramID {
+			return nil, ErrPhoneNumberTaken
+		}
+	}
+
+	existingUser, err := userRepo.GetByTgID(user.TelegramID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("foydalanuvchini tekshirishda xatolik: %w", err)
+	}
+
+	if existingUser != nil {
+		// Bot yoki ro'yxatdan o'tish so'rovi rolni bilmaydi, shuning uchun mavjud rolni saqlab qolamiz
+		if user.Role == "" {
+			user.Role = existingUser.Role
+		}
+		// Telefon raqami faqat bot kontakti orqali keladi, boshqa manbalar uni o'chirib yubormasligi kerak
+		if user.PhoneNumber == "" {
+			user.PhoneNumber = existingUser.PhoneNumber
+		}
+		if err := userRepo.Update(user); err != nil {
 			return nil, fmt.Errorf("foydalanuvchini yangilashda xatolik: %w", err)
 		}
 		log.Printf("Foydalanuvchi yangilandi, ID: %d", user.TelegramID)
 		return user, nil
 	} else {
-		if err := s.userRepo.Create(user); err != nil {
+		if user.Role == "" {
+			user.Role = models.RoleCustomer
+		}
+		if err := userRepo.Create(user); err != nil {
 			return nil, fmt.Errorf("foydalanuvchini yaratishda xatolik: %w", err)
 		}
 		log.Printf("Yangi foydalanuvchi yaratildi, ID: %d", user.TelegramID)
@@ -129,13 +258,128 @@ func (s *UserService) SaveOrUpdateUser(user *models.User) (*models.User, error)
 	}
 }
 
-// GenerateUserCode foydalanuvchi uchun kod yaratadi (service qatlamida qoldiriladi)
-func (s *UserService) GenerateUserCode(tgID int64) string {
-	tgIDStr := fmt.Sprintf("%d", tgID)
-	if len(tgIDStr) >= 4 {
-		return tgIDStr[len(tgIDStr)-4:]
+// SaveContact bot orqali yuborilgan (Telegram tomonidan tasdiqlangan) kontaktni saqlaydi.
+// Raqam boshqa Telegram hisobiga o'tgan bo'lsa (masalan, SIM karta yangi egaga berilgan), u eski hisobdan
+// olib tashlanadi va yangi hisobga biriktiriladi: eski hisob buyurtmalari va Telegram orqali kirish
+// imkoniyatini saqlab qoladi, lekin telefon + kod orqali kirish endi yangi egaga tegishli bo'ladi.
+// Raqami olib tashlangan hisoblarning Telegram ID'lari xabar berish uchun qaytariladi.
+func (s *UserService) SaveContact(user *models.User) (*models.User, []int64, error) {
+	normalized, err := phone.Normalize(user.PhoneNumber)
+	if err != nil {
+		return nil, nil, ErrInvalidPhoneNumber
+	}
+	user.PhoneNumber = normalized
+
+	// Raqamni eski egasidan olish va yangisiga yozish bitta tranzaksiyada: saqlash muvaffaqiyatsiz bo'lsa
+	// raqam eski egasida qoladi
+	var savedUser *models.User
+	var previousOwners []int64
+	err = s.uow.Do(func(tx *sql.Tx) error {
+		userRepo := s.userRepo.WithTx(tx)
+
+		var err error
+		if previousOwners, err = userRepo.ReleasePhone(normalized, user.TelegramID); err != nil {
+			return err
+		}
+		savedUser, err = s.saveUser(userRepo, user)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(previousOwners) > 0 {
+		log.Printf("📱 Telefon raqami %v hisob(lar)idan TelegramID=%d ga o'tkazildi", previousOwners, user.TelegramID)
+	}
+	return savedUser, previousOwners, nil
+}
+
+// IssueLoginCode foydalanuvchi uchun tasodifiy bir martalik kod yaratadi va uning hashini saqlaydi.
+// Kodning o'zi faqat bot orqali foydalanuvchiga yuboriladi.
+func (s *UserService) IssueLoginCode(tgID int64) (string, error) {
+	code, err := generateLoginCode()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := s.loginCodeRepo.Create(tgID, hashLoginCode(s.loginCodeKey, tgID, code), LoginCodeTTL); err != nil {
+		return "", fmt.Errorf("kodni saqlashda xatolik: %w", err)
+	}
+	return code, nil
+}
+
+// generateLoginCode LoginCodeLength xonali tasodifiy kod yaratadi (boshidagi nollar bilan)
+func generateLoginCode() (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(LoginCodeLength), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", fmt.Errorf("kod generatsiya qilishda xatolik: %w", err)
+	}
+	return fmt.Sprintf("%0*d", LoginCodeLength, n), nil
+}
+
+// invalidateLoginCode urinishlar limiti tugagan kodni yaroqsiz qiladi; kod allaqachon ishlatilgan bo'lsa xato emas
+func (s *UserService) invalidateLoginCode(codeID int) error {
+	if err := s.loginCodeRepo.MarkUsed(codeID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("kodni yaroqsiz qilishda xatolik: %w", err)
+	}
+	return nil
+}
+
+// verifyLoginCode kiritilgan kodni amaldagi kod bilan solishtiradi.
+// Noto'g'ri urinishlar hisoblanadi va limitdan oshsa kod yaroqsiz qilinadi.
+func (s *UserService) verifyLoginCode(tgID int64, code string) error {
+	loginCode, err := s.loginCodeRepo.GetActiveByTelegramID(tgID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrLoginCodeExpired
+		}
+		return fmt.Errorf("kirish kodini olishda xatolik: %w", err)
+	}
+
+	if loginCode.Attempts >= loginCodeMaxAttempts {
+		if err := s.invalidateLoginCode(loginCode.CodeID); err != nil {
+			return err
+		}
+		return ErrLoginCodeExpired
+	}
+
+	if subtle.ConstantTimeCompare([]byte(loginCode.CodeHash), []byte(hashLoginCode(s.loginCodeKey, tgID, code))) != 1 {
+		attempts, err := s.loginCodeRepo.IncrementAttempts(loginCode.CodeID)
+		if err != nil {
+			return fmt.Errorf("urinishlar sonini yangilashda xatolik: %w", err)
+		}
+		if attempts >= loginCodeMaxAttempts {
+			// Limit tugadi: kodni yaroqsiz qilamiz, foydalanuvchi yangisini olishi kerak
+			if err := s.invalidateLoginCode(loginCode.CodeID); err != nil {
+				return err
+			}
+		}
+		return ErrInvalidLoginCode
+	}
+
+	if err := s.loginCodeRepo.MarkUsed(loginCode.CodeID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrLoginCodeExpired // Kod parallel so'rovda allaqachon ishlatilgan
+		}
+		return fmt.Errorf("kodni ishlatilgan deb belgilashda xatolik: %w", err)
 	}
-	return "0000" // Agar Telegram ID 4 raqamdan kam bo'lsa default
+	return nil
+}
+
+// loginCodeKey kirish kodlari uchun kalitni bot tokenidan oladi (Telegram'ning "WebAppData" kalitiga o'xshab),
+// shuning uchun bot tokeni boshqa maqsadlardagi imzolarda to'g'ridan-to'g'ri kalit sifatida ishlatilmaydi
+func loginCodeKey(botToken string) []byte {
+	mac := hmac.New(sha256.New, []byte("LoginCode"))
+	mac.Write([]byte(botToken))
+	return mac.Sum(nil)
+}
+
+// hashLoginCode kodni Telegram ID bilan birga server kaliti bilan HMAC-SHA256 qiladi.
+// Kod qisqa (10^LoginCodeLength variant), shuning uchun kalitsiz hashni jadval sizib chiqsa bir zumda tiklash mumkin bo'lardi.
+func hashLoginCode(key []byte, tgID int64, code string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(fmt.Sprintf("%d:%s", tgID, code)))
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 // GetUserCount foydalanuvchilar sonini qaytaradi
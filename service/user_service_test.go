@@ -0,0 +1,44 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestGenerateLoginCode(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		code, err := generateLoginCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != LoginCodeLength {
+			t.Fatalf("kod %q uzunligi %d, kutilgan %d", code, len(code), LoginCodeLength)
+		}
+		if _, err := strconv.ParseUint(code, 10, 64); err != nil {
+			t.Fatalf("kod %q faqat raqamlardan iborat emas", code)
+		}
+		seen[code] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("200 ta kodning barchasi bir xil: %v", seen)
+	}
+}
+
+func TestHashLoginCode(t *testing.T) {
+	key := loginCodeKey("123456:TEST-bot-token")
+	hash := hashLoginCode(key, 100, "0421")
+
+	if hashLoginCode(key, 100, "0421") != hash {
+		t.Error("bir xil kalit va kod uchun hash har xil")
+	}
+	if hashLoginCode(key, 100, "0422") == hash || hashLoginCode(key, 200, "0421") == hash {
+		t.Error("boshqa kod yoki Telegram ID uchun hash bir xil")
+	}
+	if hashLoginCode(loginCodeKey("654321:OTHER-bot-token"), 100, "0421") == hash {
+		t.Error("boshqa kalit bilan hash bir xil: kalit hashga ta'sir qilmayapti")
+	}
+	if hashLoginCode(nil, 100, "0421") == hash {
+		t.Error("kalitsiz hash kalitli hash bilan bir xil")
+	}
+}